@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// One mutating operation: a replacement, a file operation or a custom action
+type auditEntry struct {
+	Time    time.Time `json:"time"`
+	Action  string    `json:"action"`
+	Files   []string  `json:"files,omitempty"`
+	Command string    `json:"command,omitempty"`
+}
+
+func (e auditEntry) Title() string {
+	return e.Time.Format("2006-01-02 15:04:05") + "  " + e.Action
+}
+
+func (e auditEntry) Description() string {
+	var parts []string
+	switch len(e.Files) {
+	case 0:
+	case 1:
+		parts = append(parts, e.Files[0])
+	default:
+		parts = append(parts, fmt.Sprintf("%d files: %s, ...", len(e.Files), e.Files[0]))
+	}
+	if e.Command != "" {
+		parts = append(parts, "$ "+e.Command)
+	}
+	return strings.Join(parts, " · ")
+}
+
+func (e auditEntry) FilterValue() string {
+	return e.Action + " " + strings.Join(e.Files, " ") + " " + e.Command
+}
+
+// The directory for state kept between sessions
+func stateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "lazyrg"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", "lazyrg"), nil
+}
+
+func auditLogPath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "audit.log"), nil
+}
+
+// Append an entry to the audit log. Every mutating operation calls this
+// once it has been applied.
+func recordAudit(entry auditEntry) error {
+	path, err := auditLogPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// Read the audit log, newest entry first
+func readAuditLog() ([]auditEntry, error) {
+	path, err := auditLogPath()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []auditEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var entry auditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			log.Printf("Skipping bad audit log line: %v", err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, scanner.Err()
+}
+
+func newAuditList() list.Model {
+	auditList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	auditList.Title = "Audit Log"
+	auditList.SetShowHelp(false)
+	auditList.SetStatusBarItemName("operation", "operations")
+	auditList.Styles.Title = lipgloss.NewStyle().
+		Foreground(special).
+		Bold(true).
+		MarginLeft(2)
+	return auditList
+}
+
+// Open the audit log viewer with the log as it is on disk now
+func (m *model) openAuditLog() tea.Cmd {
+	entries, err := readAuditLog()
+	if err != nil {
+		m.statusMessage = fmt.Sprintf("Error reading audit log: %s", err)
+		m.statusMessageType = "error"
+		return nil
+	}
+
+	items := make([]list.Item, len(entries))
+	for i, entry := range entries {
+		items[i] = entry
+	}
+	m.showAudit = true
+	return m.auditLog.SetItems(items)
+}
+
+// Handle keys while the audit log viewer is open
+func (m model) updateAuditLog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.auditLog.SettingFilter() {
+		switch {
+		case key.Matches(msg, m.keymap.Quit):
+			return m, tea.Quit
+
+		case key.Matches(msg, m.keymap.Back) || key.Matches(msg, m.keymap.AuditLog):
+			m.showAudit = false
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.auditLog, cmd = m.auditLog.Update(msg)
+	return m, cmd
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// How the blame column lays out a line: a short hash, the author cut to a
+// width, the date and a separator
+const (
+	blameAuthorWidth = 12
+	blameWidth       = 7 + 1 + blameAuthorWidth + 1 + 10 + 3
+)
+
+// Who last changed a line of a file, and in which commit
+type blameLine struct {
+	hash   string
+	author string
+	time   time.Time
+}
+
+func (b blameLine) uncommitted() bool {
+	return strings.Trim(b.hash, "0") == ""
+}
+
+// git blame for the viewed file, a line at a time (1-based, with 0 unused)
+type fileBlame struct {
+	path  string
+	lines []blameLine
+}
+
+type blameLoadedMsg struct {
+	token int
+	blame *fileBlame
+	err   error
+}
+
+// Run git blame on a file in the background
+func loadBlame(path string, token int) tea.Cmd {
+	return func() tea.Msg {
+		out, err := exec.Command("git", "-C", filepath.Dir(path), "blame", "--porcelain", "--", filepath.Base(path)).Output()
+		if err != nil {
+			if exit, ok := err.(*exec.ExitError); ok && len(exit.Stderr) > 0 {
+				err = fmt.Errorf("%s", strings.TrimSpace(string(exit.Stderr)))
+			}
+			return blameLoadedMsg{token: token, err: err}
+		}
+		return blameLoadedMsg{token: token, blame: &fileBlame{path: path, lines: parseBlame(out)}}
+	}
+}
+
+// Read git blame --porcelain output: a header for each line with its
+// commit and line number, followed by the commit's details the first time
+// it comes up, then the line itself after a tab
+func parseBlame(out []byte) []blameLine {
+	commits := map[string]*blameLine{}
+	lines := []blameLine{{}}
+	var current *blameLine
+	var line int
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	for scanner.Scan() {
+		text := scanner.Text()
+		switch {
+		case strings.HasPrefix(text, "\t"):
+			for len(lines) <= line {
+				lines = append(lines, blameLine{})
+			}
+			if current != nil {
+				lines[line] = *current
+			}
+		case strings.HasPrefix(text, "author "):
+			if current != nil {
+				current.author = strings.TrimPrefix(text, "author ")
+			}
+		case strings.HasPrefix(text, "author-time "):
+			if current != nil {
+				if secs, err := strconv.ParseInt(strings.TrimPrefix(text, "author-time "), 10, 64); err == nil {
+					current.time = time.Unix(secs, 0)
+				}
+			}
+		default:
+			fields := strings.Fields(text)
+			if len(fields) >= 3 && len(fields[0]) == 40 {
+				hash := fields[0]
+				if commits[hash] == nil {
+					commits[hash] = &blameLine{hash: hash}
+				}
+				current = commits[hash]
+				line, _ = strconv.Atoi(fields[2])
+			}
+		}
+	}
+	return lines
+}
+
+// Show or hide the blame column for the viewed file. It stays on for the
+// files opened after, like wrapping.
+func (m *model) toggleBlame() tea.Cmd {
+	if m.fileState.blameOn {
+		m.fileState.blameOn = false
+		m.statusMessage = "Blame hidden"
+		m.statusMessageType = "info"
+		return nil
+	}
+	if m.fileState.doc == nil || m.fileState.binary || m.fileState.item.remote != nil || m.review.stagedFile(m.fileState.item.fullPath) != nil {
+		m.statusMessage = "Only text files on disk can be blamed"
+		m.statusMessageType = "error"
+		return nil
+	}
+	m.fileState.blameOn = true
+	m.statusMessage = "Running git blame…"
+	m.statusMessageType = "info"
+	return m.blameFile()
+}
+
+// Blame the viewed file, unless that's been done already
+func (m *model) blameFile() tea.Cmd {
+	item := m.fileState.item
+	if !m.fileState.blameOn || m.fileState.binary || item.remote != nil || m.review.stagedFile(item.fullPath) != nil {
+		return nil
+	}
+	if b := m.fileState.blame; b != nil && b.path == m.fileState.item.fullPath {
+		return nil
+	}
+	m.fileState.blame = nil
+	return loadBlame(item.fullPath, m.fileToken)
+}
+
+func (m *model) handleBlameLoaded(msg blameLoadedMsg) {
+	if msg.token != m.fileToken || !m.fileState.blameOn {
+		return
+	}
+	if msg.err != nil {
+		m.fileState.blameOn = false
+		m.statusMessage = fmt.Sprintf("Can't blame %s: %s", m.paths.show(m.fileState.item.fileName), msg.err)
+		m.statusMessageType = "error"
+		return
+	}
+	m.fileState.blame = msg.blame
+	if line, ok := m.blameFor(m.fileState.item.lineNum); ok && m.fileState.item.lineNum > 0 {
+		m.statusMessage = fmt.Sprintf("Line %d: %s, %s, %s", m.fileState.item.lineNum, line.shortHash(), line.author, line.time.Format("2006-01-02"))
+		m.statusMessageType = "info"
+	}
+}
+
+func (m model) blameFor(n int) (blameLine, bool) {
+	b := m.fileState.blame
+	if b == nil || n < 1 || n >= len(b.lines) || b.lines[n].hash == "" {
+		return blameLine{}, false
+	}
+	return b.lines[n], true
+}
+
+func (b blameLine) shortHash() string {
+	return b.hash[:7]
+}
+
+// The columns the blame takes up beside the file, while it's shown
+func (m model) blameColumns() int {
+	if !m.fileState.blameOn || m.fileState.doc == nil || m.fileState.binary {
+		return 0
+	}
+	return blameWidth
+}
+
+// The blame for document line i, shown where the commit differs from the
+// line above's (and on the top line in view), and blank otherwise so
+// changes made together read as a block. The matched line's stands out.
+func (m model) blameGutter(i, top int) string {
+	n := i - m.fileState.lineOffset + 1
+	line, ok := m.blameFor(n)
+	style := previewGutterStyle
+	if n == m.fileState.item.lineNum {
+		style = previewMatchGutterStyle
+	}
+	if !ok {
+		return style.Render(strings.Repeat(" ", blameWidth-3) + " │ ")
+	}
+	if above, ok := m.blameFor(n - 1); ok && i > top && above.hash == line.hash && n != m.fileState.item.lineNum {
+		return style.Render(strings.Repeat(" ", blameWidth-3) + " │ ")
+	}
+	if line.uncommitted() {
+		return style.Render(fmt.Sprintf("%-*s │ ", blameWidth-3, "Not committed yet"))
+	}
+	author := ansi.Truncate(line.author, blameAuthorWidth, "…")
+	author += strings.Repeat(" ", blameAuthorWidth-ansi.StringWidth(author))
+	return style.Render(fmt.Sprintf("%s %s %s │ ", line.shortHash(), author, line.time.Format("2006-01-02")))
+}
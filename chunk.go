@@ -0,0 +1,397 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How very large files are loaded: a chunk of lines around the match
+// first, then more as the viewer nears either end of what's loaded
+const (
+	chunkThreshold = 32 << 20 // files larger than this are loaded a chunk at a time
+	chunkLines     = 5000
+	chunkMargin    = 200 // lines from the end of what's loaded at which more is
+	chunkDigits    = 9   // the line count isn't known up front, so the gutter fits any
+)
+
+const chunkPlaceholder = "  ⋯ loading…"
+
+// The part of a very large file that's loaded into the viewer's document,
+// with a placeholder line above it unless it starts at the top of the file
+// and one below it unless it reaches the end
+type fileChunk struct {
+	first, last int   // the file lines loaded, 1-based
+	start, end  int64 // offsets of the start of first and the end of last
+	eof         bool
+	loading     bool
+	want        int // a line that isn't loaded to show once it is (-1 for the end)
+}
+
+// Document lines before the file's first line, which go negative once the
+// chunk starts any further down than the second line
+func (c *fileChunk) lineOffset() int {
+	head := 0
+	if c.first > 1 {
+		head = 1
+	}
+	return head - (c.first - 1)
+}
+
+func (c *fileChunk) loaded(line int) bool {
+	return line >= c.first && line <= c.last
+}
+
+// Whether the file at path is too large to load whole
+func chunkedFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Size() > chunkThreshold
+}
+
+// The offset of the start of a line (1-based), counting lines from the
+// nearest indexed one if the file's indexed. Past the end is the file's size.
+func seekLine(file *os.File, line int, entry *indexedFile) (int64, error) {
+	n, offset := 1, int64(0)
+	if info, err := file.Stat(); err == nil && entry != nil && entry.fresh(info) {
+		checkpoint := min((line-1)/indexLineStride, len(entry.Lines)-1)
+		n, offset = checkpoint*indexLineStride+1, entry.Lines[checkpoint]
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	buf := make([]byte, 256*1024)
+	for n < line {
+		read, err := file.Read(buf)
+		block := buf[:read]
+		for n < line {
+			i := bytes.IndexByte(block, '\n')
+			if i < 0 {
+				break
+			}
+			n++
+			offset += int64(i + 1)
+			block = block[i+1:]
+		}
+		if n < line {
+			offset += int64(len(block))
+		}
+		if err == io.EOF {
+			return offset, nil
+		} else if err != nil {
+			return 0, err
+		}
+	}
+	return offset, nil
+}
+
+// Up to n lines from offset on, and the offset they end at
+func readLinesFrom(file *os.File, offset int64, n int) ([]string, int64, bool, error) {
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, 0, false, err
+	}
+	reader := bufio.NewReaderSize(file, 64*1024)
+	var lines []string
+	for len(lines) < n {
+		line, err := reader.ReadString('\n')
+		offset += int64(len(line))
+		if line != "" {
+			lines = append(lines, strings.TrimRight(line, "\r\n"))
+		}
+		if err == io.EOF {
+			return lines, offset, true, nil
+		} else if err != nil {
+			return nil, 0, false, err
+		}
+	}
+	_, err := reader.Peek(1)
+	return lines, offset, err == io.EOF, nil
+}
+
+// Up to n lines before offset (the start of a line, or the end of the
+// file), read backwards a block at a time, and the offset they start at
+func readLinesBefore(file *os.File, offset int64, n int) ([]string, int64, error) {
+	var buf []byte
+	start := offset
+	block := make([]byte, 64*1024)
+	for start > 0 && bytes.Count(buf, []byte("\n")) <= n {
+		size := min(int64(len(block)), start)
+		start -= size
+		if _, err := file.ReadAt(block[:size], start); err != nil && err != io.EOF {
+			return nil, 0, err
+		}
+		buf = append(append([]byte{}, block[:size]...), buf...)
+	}
+
+	text := strings.TrimSuffix(string(buf), "\n")
+	lines := strings.Split(text, "\n")
+	if start > 0 {
+		// The first is the end of a line further up
+		start += int64(len(lines[0]) + 1)
+		lines = lines[1:]
+	}
+	for len(lines) > n {
+		start += int64(len(lines[0]) + 1)
+		lines = lines[1:]
+	}
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, "\r")
+	}
+	if text == "" {
+		lines = nil
+	}
+	return lines, start, nil
+}
+
+// Load the chunk of a very large file around a line (or, for -1, at its
+// end), to replace the viewer's document
+func loadChunk(ctx context.Context, item Item, hits []fileHit, token int, line int, entry *indexedFile) tea.Cmd {
+	return func() tea.Msg {
+		file, err := os.Open(item.fullPath)
+		if err != nil {
+			return fileLoadedMsg{token: token, final: true, err: err}
+		}
+		defer file.Close()
+
+		chunk := &fileChunk{}
+		var lines []string
+		if line > 0 {
+			chunk.first = max(line-chunkLines/2, 1)
+			if chunk.start, err = seekLine(file, chunk.first, entry); err == nil {
+				lines, chunk.end, chunk.eof, err = readLinesFrom(file, chunk.start, chunkLines)
+			}
+		}
+		if err == nil && len(lines) == 0 {
+			// At the end, or past it
+			var info os.FileInfo
+			if info, err = file.Stat(); err == nil {
+				chunk.end, chunk.eof = info.Size(), true
+				if lines, chunk.start, err = readLinesBefore(file, chunk.end, chunkLines); err == nil {
+					var before int64
+					before, err = countLines(file, chunk.start)
+					chunk.first = int(before) + 1
+				}
+			}
+		}
+		if err != nil {
+			return fileLoadedMsg{token: token, final: true, err: err}
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		chunk.last = chunk.first + len(lines) - 1
+		if line < 0 {
+			line = chunk.last
+		}
+		line = min(max(line, chunk.first), chunk.last)
+
+		doc := &fileDocument{gutter: chunkDigits + 5}
+		if chunk.first > 1 {
+			doc.lines = append(doc.lines, chunkPlaceholder)
+		}
+		doc.lines = append(doc.lines, lines...)
+		if !chunk.eof {
+			doc.lines = append(doc.lines, chunkPlaceholder)
+		}
+		doc.highlight = chunkHighlight(doc, chunk, item, hits)
+
+		msg := fileLoadedMsg{token: token, final: true, doc: doc, chunk: chunk, focus: line - 1 + chunk.lineOffset()}
+		if chunk.eof {
+			// Following it goes on from the end, taking over an unfinished
+			// last line
+			msg.followFrom = chunk.end
+			last := []byte{'\n'}
+			if chunk.end > 0 {
+				file.ReadAt(last, chunk.end-1)
+			}
+			if last[0] != '\n' && len(lines) > 0 {
+				msg.followFrom -= int64(len(lines[len(lines)-1]))
+				msg.followPartial = true
+			}
+		}
+		if line == item.lineNum && len(item.lineMatches) > 0 {
+			if text := lines[line-chunk.first]; item.lineMatches[0].start <= len(text) {
+				msg.matchCol = displayWidth(text[:item.lineMatches[0].start], batTabWidth)
+			}
+		}
+		return msg
+	}
+}
+
+// How many lines start before offset
+func countLines(file *os.File, offset int64) (int64, error) {
+	var count int64
+	buf := make([]byte, 256*1024)
+	for read := int64(0); read < offset; {
+		n, err := file.ReadAt(buf[:min(int64(len(buf)), offset-read)], read)
+		count += int64(bytes.Count(buf[:n], []byte("\n")))
+		read += int64(n)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+// Render a chunked document's lines like loadPlainFile does, with the
+// search's matches highlighted. There's no syntax highlighting: it needs
+// the whole file.
+func chunkHighlight(doc *fileDocument, chunk *fileChunk, item Item, hits []fileHit) func(i int, line string) string {
+	spans := hitSpans(hits)
+	return func(i int, line string) string {
+		n := i - chunk.lineOffset() + 1
+		if (chunk.first > 1 && i == 0) || (!chunk.eof && i == len(doc.lines)-1) {
+			return jumpHintStyle.Render(line)
+		}
+		gutter := fileGutter(n, chunkDigits, n == item.lineNum, len(spans[n]) > 0)
+		if n == item.lineNum {
+			line = highlightSpans(line, item.lineMatches, highlightStyle, matchStyle)
+			return gutter + expandTabs(line, batTabWidth)
+		}
+		raw := line
+		line = expandTabs(line, batTabWidth)
+		if len(spans[n]) > 0 {
+			line = restyleSpans(line, 0, raw, spans[n], matchStyle)
+		}
+		return gutter + line
+	}
+}
+
+// More lines of a chunked file, for above or below what's loaded
+type chunkLoadedMsg struct {
+	token  int
+	up     bool
+	lines  []string
+	offset int64 // where the lines start, going up, or end, going down
+	eof    bool
+	err    error
+}
+
+func loadMoreChunk(path string, token int, chunk fileChunk, up bool) tea.Cmd {
+	return func() tea.Msg {
+		msg := chunkLoadedMsg{token: token, up: up}
+		file, err := os.Open(path)
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		defer file.Close()
+		if up {
+			msg.lines, msg.offset, msg.err = readLinesBefore(file, chunk.start, chunkLines)
+		} else {
+			msg.lines, msg.offset, msg.eof, msg.err = readLinesFrom(file, chunk.end, chunkLines)
+		}
+		return msg
+	}
+}
+
+// Whether a line (1-based) of the open file is in the document; for a
+// chunked file that isn't, it's loaded and shown once it arrives
+func (m *model) fileLineLoaded(line int) bool {
+	chunk := m.fileState.chunk
+	if chunk == nil || chunk.loaded(line) || (line < 0 && chunk.eof) {
+		return true
+	}
+	chunk.want = line
+	return false
+}
+
+// Load more of a chunked file when it's needed: a line that's been jumped
+// to, or the lines past either end when the viewer gets near it
+func (m *model) moreOfFile() tea.Cmd {
+	chunk := m.fileState.chunk
+	if chunk == nil || m.fileState.doc == nil {
+		return nil
+	}
+	if chunk.want != 0 {
+		line := chunk.want
+		chunk.want = 0
+		m.fileToken++
+		m.fileLoading = true
+		m.fileState.doc = nil
+		m.fileState.chunk = nil
+		ctx, cancel := context.WithCancel(context.Background())
+		m.cancelRender = cancel
+		item := m.fileState.item
+		return tea.Batch(m.fileSpinner.Tick, loadChunk(ctx, item, m.fileState.hits, m.fileToken, line, m.indexedFile(item.fullPath)))
+	}
+	if chunk.loading {
+		return nil
+	}
+	top := m.fileState.viewer.YOffset
+	switch {
+	case chunk.first > 1 && top < chunkMargin:
+		chunk.loading = true
+		return loadMoreChunk(m.fileState.item.fullPath, m.fileToken, *chunk, true)
+	case !chunk.eof && top+m.fileState.viewer.Height > len(m.fileState.doc.lines)-chunkMargin:
+		chunk.loading = true
+		return loadMoreChunk(m.fileState.item.fullPath, m.fileToken, *chunk, false)
+	}
+	return nil
+}
+
+// Put more lines of a chunked file in place of the placeholder they were
+// loaded for, keeping the view where it was
+func (m *model) handleChunkLoaded(msg chunkLoadedMsg) tea.Cmd {
+	chunk, doc := m.fileState.chunk, m.fileState.doc
+	if msg.token != m.fileToken || chunk == nil || doc == nil {
+		return nil
+	}
+	chunk.loading = false
+	if msg.err != nil {
+		m.statusMessage = fmt.Sprintf("Error loading file: %s", msg.err)
+		m.statusMessageType = "error"
+		return nil
+	}
+
+	if doc.rendered == nil {
+		doc.rendered = make([]bool, len(doc.lines))
+	}
+	if msg.up {
+		// Everything below moves down by the lines added, less the
+		// placeholder if the top's been reached
+		added := len(msg.lines)
+		lines := doc.lines[1:]
+		chunk.first -= added
+		chunk.start = msg.offset
+		if chunk.first > 1 {
+			lines = append(append([]string{chunkPlaceholder}, msg.lines...), lines...)
+		} else {
+			lines = append(append([]string{}, msg.lines...), lines...)
+			added--
+		}
+		rendered := make([]bool, len(lines))
+		copy(rendered[len(lines)-len(doc.rendered)+1:], doc.rendered[1:])
+		doc.lines, doc.rendered = lines, rendered
+		m.setFileDocument(doc)
+		m.fileState.viewer.SetYOffset(m.fileState.viewer.YOffset + added)
+		m.fileState.autoTop += added
+		m.fileState.findFrom += added
+		m.fileState.selAnchor += added
+		m.fileState.selCursor += added
+	} else {
+		lines := append(doc.lines[:len(doc.lines)-1], msg.lines...)
+		chunk.last += len(msg.lines)
+		chunk.end = msg.offset
+		chunk.eof = msg.eof
+		if !chunk.eof {
+			lines = append(lines, chunkPlaceholder)
+		}
+		rendered := make([]bool, len(lines))
+		copy(rendered, doc.rendered[:len(doc.rendered)-1])
+		yOffset := m.fileState.viewer.YOffset
+		doc.lines, doc.rendered = lines, rendered
+		m.setFileDocument(doc)
+		m.fileState.viewer.SetYOffset(yOffset)
+	}
+	m.fileState.lineOffset = chunk.lineOffset()
+	m.renderVisibleFile()
+	return m.moreOfFile()
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Something that was copied to the clipboard during this session
+type clipboardEntry struct {
+	kind   string // "path", "line", "command", ...
+	text   string
+	copied time.Time
+}
+
+func (e clipboardEntry) Title() string {
+	title, _, _ := strings.Cut(e.text, "\n")
+	return title
+}
+
+func (e clipboardEntry) Description() string {
+	desc := fmt.Sprintf("%s · %s", e.kind, e.copied.Format("15:04:05"))
+	if lines := strings.Count(e.text, "\n") + 1; lines > 1 {
+		desc += fmt.Sprintf(" · %d lines", lines)
+	}
+	return desc
+}
+
+func (e clipboardEntry) FilterValue() string { return e.text }
+
+func newClipboardList() list.Model {
+	clipboardList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	clipboardList.Title = "Clipboard History"
+	// Paste only works in here, so it's in the list's help rather than
+	// the global one
+	clipboardList.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "copy again")),
+			keys.Paste,
+		}
+	}
+	clipboardList.SetStatusBarItemName("entry", "entries")
+	clipboardList.Styles.Title = lipgloss.NewStyle().
+		Foreground(special).
+		Bold(true).
+		MarginLeft(2)
+	return clipboardList
+}
+
+// Copy text to the system clipboard and remember it in the session history
+func (m *model) copyToClipboard(kind, text string) error {
+	if err := m.writeClipboard(text); err != nil {
+		return err
+	}
+	m.recordClipboard(kind, text)
+	return nil
+}
+
+// Add an entry to the top of the clipboard history, dropping any older
+// entry with the same text so re-copying moves it back to the top.
+func (m *model) recordClipboard(kind, text string) {
+	items := []list.Item{clipboardEntry{kind: kind, text: text, copied: time.Now()}}
+	for _, item := range m.clipboardHistory.Items() {
+		if item.(clipboardEntry).text != text {
+			items = append(items, item)
+		}
+	}
+	m.clipboardHistory.SetItems(items)
+	m.clipboardHistory.ResetSelected()
+}
+
+// Update the status bar after a copy attempt
+func (m *model) reportCopy(what string, err error) {
+	if err != nil {
+		m.statusMessage = fmt.Sprintf("Error copying to clipboard: %s", err)
+		m.statusMessageType = "error"
+		return
+	}
+	m.statusMessage = fmt.Sprintf("Copied %s to clipboard", what)
+	m.statusMessageType = "info"
+}
+
+// Past either of these, copying every result asks first: it's a lot for
+// a clipboard, and OSC 52 terminals may drop it
+const (
+	copyAllConfirmResults = 5000
+	copyAllConfirmBytes   = 1 << 20
+)
+
+// Copy the results (the marked ones, or all that get through the filters)
+// as grep-style path:line:col:text lines
+func (m *model) copyAll() {
+	items := m.targetResults()
+	if len(items) == 0 {
+		m.statusMessage = "No results to copy"
+		m.statusMessageType = "error"
+		return
+	}
+	var b strings.Builder
+	writeQuickfix(&b, items) //nolint: errcheck
+	text := strings.TrimSuffix(b.String(), "\n")
+	what := fmt.Sprintf("%d results", len(items))
+	copyText := func(m *model) tea.Cmd {
+		m.reportCopy(what, m.copyToClipboard("results", text))
+		return nil
+	}
+	if len(items) > copyAllConfirmResults || len(text) > copyAllConfirmBytes {
+		m.ask(fmt.Sprintf("Copy %s (%s) to the clipboard?", what, formatBytes(int64(len(text)))), copyText)
+		return
+	}
+	copyText(m)
+}
+
+// Copy the selected results as path:line, one per line
+func (m *model) copyLocations() {
+	items := m.selectedResults()
+	if len(items) == 0 {
+		return
+	}
+	locations := make([]string, len(items))
+	for i, item := range items {
+		locations[i] = fmt.Sprintf("%s:%d", item.fullPath, item.lineNum)
+	}
+	what := locations[0]
+	if len(items) > 1 {
+		what = fmt.Sprintf("%d locations", len(items))
+	}
+	m.reportCopy(what, m.copyToClipboard("location", strings.Join(locations, "\n")))
+}
+
+// Copy the selected results' lines as they are in the file, indentation
+// and all
+func (m *model) copyLines() {
+	items := m.selectedResults()
+	if len(items) == 0 {
+		return
+	}
+	lines := make([]string, len(items))
+	for i, item := range items {
+		line, ok := readLine(item.fullPath, item.lineNum)
+		if !ok {
+			line = item.content
+		}
+		lines[i] = line
+	}
+	what := "line"
+	if len(items) > 1 {
+		what = fmt.Sprintf("%d lines", len(items))
+	}
+	m.reportCopy(what, m.copyToClipboard("line", strings.Join(lines, "\n")))
+}
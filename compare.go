@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var compareStyle = lipgloss.NewStyle().
+	BorderStyle(lipgloss.RoundedBorder()).
+	BorderForeground(subtle).
+	Padding(0, 1)
+
+// Two results side by side, each with the lines around its match
+type comparison struct {
+	left, right *preview
+	scroll      int // lines both are scrolled by, from their matches
+}
+
+type compareLoadedMsg struct {
+	comparison *comparison
+}
+
+// Compare the marked result with the selected one. With a single result
+// marked, the two open side by side, scrolling together.
+func (m *model) openCompare() tea.Cmd {
+	item, ok := m.resultsState.list.selected()
+	if !ok {
+		return nil
+	}
+	var marked []Item
+	for _, result := range m.results {
+		if m.marked[result.key()] && result.key() != item.key() {
+			marked = append(marked, result)
+		}
+	}
+	if len(marked) != 1 || item.remote != nil || marked[0].remote != nil {
+		m.statusMessage = fmt.Sprintf("Mark one result with %s, then press %s on another to compare them", m.keymap.Mark.Help().Key, m.keymap.Compare.Help().Key)
+		m.statusMessageType = "info"
+		return nil
+	}
+	left, right := marked[0], item
+	indexed := func(item Item) *indexedFile { return m.indexedFile(item.fullPath) }
+	leftEntry, rightEntry := indexed(left), indexed(right)
+	return func() tea.Msg {
+		c := &comparison{}
+		c.left = loadPreview(left, leftEntry)().(previewLoadedMsg).preview
+		c.right = loadPreview(right, rightEntry)().(previewLoadedMsg).preview
+		return compareLoadedMsg{c}
+	}
+}
+
+// Handle keys while the comparison is open: both sides scroll together
+func (m model) updateCompare(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	keys := m.fileState.viewer.KeyMap
+	height := m.compareHeight()
+	switch {
+	case msg.String() == "ctrl+c":
+		return m, tea.Quit
+	case key.Matches(msg, m.keymap.Back), key.Matches(msg, m.keymap.Compare):
+		m.comparison = nil
+		return m, nil
+	case key.Matches(msg, keys.Up):
+		m.comparison.scroll--
+	case key.Matches(msg, keys.Down):
+		m.comparison.scroll++
+	case key.Matches(msg, keys.PageUp):
+		m.comparison.scroll -= height
+	case key.Matches(msg, keys.PageDown):
+		m.comparison.scroll += height
+	case key.Matches(msg, keys.HalfPageUp):
+		m.comparison.scroll -= height / 2
+	case key.Matches(msg, keys.HalfPageDown):
+		m.comparison.scroll += height / 2
+	}
+	m.comparison.scroll = min(max(m.comparison.scroll, -previewContext), previewContext)
+	return m, nil
+}
+
+func (m model) compareHeight() int {
+	return m.listHeight - compareStyle.GetVerticalFrameSize()
+}
+
+func (m model) compareView() string {
+	width := (m.resultsWidth()-2*compareStyle.GetHorizontalFrameSize())/2 - 1
+	height := m.compareHeight()
+	pane := func(p *preview) string {
+		style := m.bordered(compareStyle).Width(width + compareStyle.GetHorizontalPadding()).Height(height)
+		if p.err != nil {
+			return style.Render(resultFilterErrorStyle.Render(fmt.Sprintf("Can't open %s: %s", p.item.fileName, p.err)))
+		}
+		return style.Render(m.previewLines(p, width, height, m.comparison.scroll))
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, pane(m.comparison.left), " ", pane(m.comparison.right))
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Where lazyrg starts: the search pattern input, the directory input, or
+// the results of the search given on the command line
+const (
+	focusPattern   = "pattern"
+	focusDirectory = "directory"
+	focusResults   = "results"
+)
+
+// Settings read from the config file. Command line flags override them.
+type config struct {
+	// Which input (or tab) has focus at startup. Defaults to the results
+	// when a pattern is passed on the command line, and the pattern input
+	// otherwise.
+	Focus string `json:"focus"`
+
+	// Search from the root of the git repository containing the working
+	// directory, rather than the working directory itself
+	GitRoot bool `json:"gitRoot"`
+
+	// Disable everything that changes files or runs commands on them
+	ReadOnly bool `json:"readOnly"`
+
+	// Show paths relative to the search directory, and the home directory
+	// as ~, rather than as rg prints them
+	RelativePaths bool `json:"relativePaths"`
+
+	// Truncate paths too long for the results list from the middle instead
+	// of the end, so the file name stays visible
+	TruncateMiddle bool `json:"truncateMiddle"`
+
+	// Make the results' paths and the file view's line numbers terminal
+	// hyperlinks (OSC 8) to the file, for terminals that open them. On
+	// unless this is false.
+	Hyperlinks *bool `json:"hyperlinks"`
+
+	// Git repositories to search together, or directories of clones to
+	// search every repository in
+	Repos []string `json:"repos"`
+
+	// Large directories to index in the background while lazyrg is idle,
+	// so searches and previews in them are quicker
+	Index []string `json:"index"`
+
+	// Code search on GitHub or GitLab, for repositories that aren't cloned
+	Remote remoteConfig `json:"remote"`
+
+	// Where alt+w POSTs the results as JSON, for pushing findings into
+	// other tools. A project's .lazyrg.json can't set this.
+	Webhook webhookConfig `json:"webhook"`
+
+	// URL for links to results in exports, with {path} (relative to the
+	// search directory), {line} and {column} filled in
+	LinkTemplate string `json:"linkTemplate"`
+
+	// A Go text/template file to lay out Markdown reports with, in place
+	// of the built-in layout
+	MarkdownTemplate string `json:"markdownTemplate"`
+
+	// Run rg in a sandbox with no network and no access to the home
+	// directory, for searching untrusted directories
+	Sandbox bool `json:"sandbox"`
+
+	// Search as the pattern is typed, once typing pauses
+	Live bool `json:"live"`
+
+	// How results show their file type: "nerd" for Nerd Font icons,
+	// "ascii" for short tags (the default) or "off"
+	Icons string `json:"icons"`
+
+	// A command rg runs on each file and searches the output of instead,
+	// like "pdftotext" (rg's --pre). A project's .lazyrg.json can only set
+	// this once the project is trusted.
+	Pre string `json:"pre"`
+
+	// How the file viewer highlights syntax: "chroma" (built in, the
+	// default), "bat" (when it's installed) or "off"
+	Highlighter string `json:"highlighter"`
+
+	// A command the file view shows a file with instead, like "batcat
+	// --color=always --style=plain --highlight-line {line} {path}", with
+	// {path}, {line} and {column} filled in. Its output should have a line
+	// for each line of the file. A project's .lazyrg.json can only set this
+	// once the project is trusted.
+	Previewer string `json:"previewer"`
+
+	// The command ctrl+e opens a result with, like "code -g {file}:{line}"
+	// or "idea --line {line} {file}", with {file}, {line} and {column}
+	// filled in. Just an editor's name works too, and $VISUAL or $EDITOR is
+	// used when it's not set. A project's .lazyrg.json can only set this
+	// once the project is trusted.
+	Editor string `json:"editor"`
+
+	// Whether the editor opens a window of its own, so lazyrg keeps the
+	// terminal rather than handing it over until the editor exits. Worked
+	// out from the editor's name when it's not set.
+	GUIEditor *bool `json:"guiEditor"`
+
+	// The socket of a running Neovim for ctrl+e to open results in, as
+	// with nvim --listen. $NVIM, set inside Neovim's terminal, comes first.
+	NvimServer string `json:"nvimServer"`
+
+	// The tmux command alt+o opens a result in the editor with, inside
+	// tmux: "split-window -h" (a pane beside lazyrg, the default),
+	// "split-window -v" or "new-window", with any of their flags
+	Tmux string `json:"tmux"`
+
+	// The chroma style for highlighting, like "dracula" or "github".
+	// Defaults to "monokai".
+	Theme string `json:"theme"`
+
+	// When to save battery with fewer rg threads, no live search or preview
+	// and no animations: "auto" (on battery, the default), "on" or "off"
+	LowPower string `json:"lowPower"`
+
+	// How many lines the file view shows above the matched line when it
+	// opens a result. The match is centered if this isn't set.
+	ScrollOffset *int `json:"scrollOffset"`
+
+	// How result lines are cleaned up for the list: collapsing whitespace,
+	// keeping or stripping indentation and showing control characters
+	Sanitize sanitizeConfig `json:"sanitize"`
+
+	// Search scope templates, in addition to the built-in ones (or in place
+	// of those with the same name)
+	Scopes []scopeTemplate `json:"scopes"`
+}
+
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "lazyrg", "config.json")
+}
+
+// Read the config file, if there is one
+func loadConfig(path string) (config, error) {
+	var cfg config
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	} else if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, cfg.validate()
+}
+
+func (c config) validate() error {
+	switch c.Focus {
+	case "", focusPattern, focusDirectory, focusResults:
+	default:
+		return fmt.Errorf("focus must be %q, %q or %q, not %q", focusPattern, focusDirectory, focusResults, c.Focus)
+	}
+	switch c.Icons {
+	case "", iconsNerd, iconsASCII, iconsOff:
+	default:
+		return fmt.Errorf("icons must be %q, %q or %q, not %q", iconsNerd, iconsASCII, iconsOff, c.Icons)
+	}
+	switch c.LowPower {
+	case "", lowPowerAuto, lowPowerOn, lowPowerOff:
+	default:
+		return fmt.Errorf("lowPower must be %q, %q or %q, not %q", lowPowerAuto, lowPowerOn, lowPowerOff, c.LowPower)
+	}
+	switch c.Highlighter {
+	case "", highlighterChroma, highlighterBat, highlighterOff:
+	default:
+		return fmt.Errorf("highlighter must be %q, %q or %q, not %q", highlighterChroma, highlighterBat, highlighterOff, c.Highlighter)
+	}
+	if c.ScrollOffset != nil && *c.ScrollOffset < 0 {
+		return fmt.Errorf("scrollOffset can't be negative")
+	}
+	if c.Theme != "" && !knownTheme(c.Theme) {
+		return fmt.Errorf("theme %q is not a chroma style", c.Theme)
+	}
+	if err := c.Webhook.validate(); err != nil {
+		return err
+	}
+	switch c.Remote.Provider {
+	case "", remoteGitHub, remoteGitLab:
+	default:
+		return fmt.Errorf("remote.provider must be %q or %q, not %q", remoteGitHub, remoteGitLab, c.Remote.Provider)
+	}
+	return nil
+}
+
+// The top level of the git repository containing dir
+func gitRoot(dir string) (string, error) {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Set up the starting directory, inputs and focus from the config and the
+// pattern and path given on the command line. If there's a pattern, the
+// search starts as soon as the program does.
+func (m *model) applyConfig(cfg config, pattern, path string) {
+	if cfg.GitRoot {
+		if root, err := gitRoot(m.currentPath); err == nil {
+			m.currentPath = root
+		}
+	}
+	m.readOnly = cfg.ReadOnly
+	m.sandbox = cfg.Sandbox
+	m.live = cfg.Live
+	m.pre = cfg.Pre
+	m.scopes = mergeScopes(builtinScopes, cfg.Scopes)
+	m.resultsState.list.delegate.icons = cfg.Icons
+	m.resultsState.list.delegate.clean = cfg.Sanitize.options()
+	if cfg.Icons == "" {
+		m.resultsState.list.delegate.icons = iconsASCII
+	}
+	m.highlighter = highlighter{backend: cfg.Highlighter, theme: cfg.Theme}
+	if m.highlighter.backend == "" {
+		m.highlighter.backend = highlighterChroma
+	}
+	if m.highlighter.theme == "" {
+		m.highlighter.theme = defaultTheme
+	}
+	m.usePreviewer(cfg.Previewer)
+	m.editor = editorConfig{template: cfg.Editor, gui: cfg.GUIEditor, nvimServer: cfg.NvimServer, tmux: cfg.Tmux}
+	m.scrollOffset = cfg.ScrollOffset
+	m.powerMode = cfg.LowPower
+	if m.powerMode == "" {
+		m.powerMode = lowPowerAuto
+	}
+	m.setLowPower(m.powerMode == lowPowerOn || (m.powerMode == lowPowerAuto && onBattery()))
+	m.paths.relative = cfg.RelativePaths
+	m.paths.middle = cfg.TruncateMiddle
+	m.paths.links = cfg.Hyperlinks == nil || *cfg.Hyperlinks
+	m.repos = cfg.Repos
+	m.indexes = loadIndexes(cfg.Index)
+	m.remote = cfg.Remote
+	m.webhook = cfg.Webhook
+	m.linkTemplate = cfg.LinkTemplate
+	m.markdownTemplate = cfg.MarkdownTemplate
+	m.searchInput.SetValue(pattern)
+	m.directoryInput.SetValue(path)
+
+	focus := cfg.Focus
+	if focus == "" {
+		focus = focusPattern
+		if pattern != "" {
+			focus = focusResults
+		}
+	}
+
+	if pattern != "" {
+		m.startup = m.startSearch()
+		m.activeTab = searchTab
+	}
+	switch focus {
+	case focusDirectory:
+		m.searchInput.Blur()
+		m.directoryInput.Focus()
+	case focusResults:
+		m.activeTab = resultsTab
+	}
+}
+
+// Mutating features (replacing, file operations, editing ignore files,
+// hooks) check this before doing anything. In read-only mode it says why
+// nothing happened.
+func (m *model) blockedByReadOnly(action string) bool {
+	if !m.readOnly {
+		return false
+	}
+	m.statusMessage = fmt.Sprintf("Read-only mode: can't %s", action)
+	m.statusMessageType = "error"
+	return true
+}
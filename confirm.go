@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var confirmStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("#FFFFFF")).
+	Background(highlight).
+	Bold(true)
+
+// A yes/no question asked in the status bar, perhaps with other answers
+// besides. Until it's answered every other key is ignored.
+type confirmation struct {
+	question string
+	yes      func(m *model) tea.Cmd
+	others   []answer
+}
+
+// An answer other than yes or no, given with its key
+type answer struct {
+	key   string
+	label string
+	do    func(m *model) tea.Cmd
+}
+
+// Ask before doing something that can't be taken back, or takes a while
+func (m *model) ask(question string, yes func(m *model) tea.Cmd, others ...answer) {
+	m.confirm = &confirmation{question: question, yes: yes, others: others}
+}
+
+// Handle keys while a question is waiting for an answer
+func (m model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	for _, other := range m.confirm.others {
+		if msg.String() == other.key {
+			m.confirm = nil
+			return m, other.do(&m)
+		}
+	}
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "y", "Y", "enter":
+		confirm := m.confirm
+		m.confirm = nil
+		return m, confirm.yes(&m)
+	case "n", "N", "esc":
+		m.confirm = nil
+		m.statusMessage = "Cancelled"
+		m.statusMessageType = "info"
+	}
+	return m, nil
+}
+
+func (m model) confirmView() string {
+	keys := []string{"y/n"}
+	for _, other := range m.confirm.others {
+		keys = append(keys, other.key+" "+other.label)
+	}
+	return confirmStyle.Render(m.confirm.question + " (" + strings.Join(keys, ", ") + ")")
+}
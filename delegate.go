@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const marker = "● "
+
+var markerStyle = lipgloss.NewStyle().
+	Foreground(special).
+	Bold(true)
+
+// Renders search results like the default delegate, but with the matched
+// text highlighted in the description and markers on marked and pinned
+// results.
+type resultDelegate struct {
+	list.DefaultDelegate
+	marked map[resultKey]bool
+	pinned map[resultKey]bool
+	paths  *pathDisplay
+	icons  string // iconsNerd, iconsASCII or iconsOff
+	clean  sanitizeOptions
+	scroll *horizontalScroll
+	labels *resultLabels
+}
+
+func (d resultDelegate) render(w io.Writer, l resultList, index int, item Item) {
+	s := &d.Styles
+
+	// Prevent text from exceeding list width
+	textwidth := l.width - s.NormalTitle.GetPaddingLeft() - s.NormalTitle.GetPaddingRight()
+	var prefix string
+	if d.marked[item.key()] {
+		prefix += markerStyle.Render(marker)
+	}
+	if d.pinned[item.key()] {
+		prefix += pinMarker
+	}
+	// Remote results from GitHub don't know their line until they're cloned
+	var location string
+	if item.lineNum > 0 {
+		location = ":" + strconv.Itoa(item.lineNum) + ":" + strconv.Itoa(item.column)
+	}
+	if n := len(item.matches); n > 1 {
+		location += " ×" + strconv.Itoa(n)
+	}
+	icon := fileIconView(d.icons, item.fileName)
+	language := languageView(d.icons, item.fileName)
+	title := d.paths.truncate(d.paths.show(item.fileName), textwidth-lipgloss.Width(prefix)-lipgloss.Width(icon)-len(location)-len(language)) + location
+
+	var (
+		isSelected  = index == l.index()
+		emptyFilter = l.filterState() == list.Filtering && l.filterValue() == ""
+		isFiltered  = l.filterState() == list.Filtering || l.filterState() == list.FilterApplied
+	)
+
+	var titleStyle, descStyle lipgloss.Style
+	switch {
+	case emptyFilter:
+		titleStyle, descStyle = s.DimmedTitle, s.DimmedDesc
+	case isSelected && l.filterState() != list.Filtering:
+		titleStyle, descStyle = s.SelectedTitle, s.SelectedDesc
+	default:
+		titleStyle, descStyle = s.NormalTitle, s.NormalDesc
+	}
+
+	if isFiltered && !emptyFilter {
+		unmatched := titleStyle.Inline(true)
+		matched := unmatched.Inherit(s.FilterMatch)
+		title = lipgloss.StyleRunes(title, filterMatchRunes(title, l.filterValue()), matched, unmatched)
+	}
+
+	plain, spans := d.clean.line(item)
+	desc := plain
+	if !emptyFilter {
+		unmatched := descStyle.Inline(true)
+		desc = highlightSpans(desc, spans, unmatched, matchStyle.Inherit(unmatched))
+	}
+	scroll := 0
+	if isSelected && d.scroll.key == item.key() {
+		scroll = d.scroll.offset
+	}
+	desc = lineWindow(desc, plain, spans, textwidth, scroll)
+
+	if language != "" {
+		// Dropped rather than squeezing the path any further
+		if lipgloss.Width(prefix+icon+title+language) <= textwidth {
+			title += languageStyle.Render(language)
+		}
+	}
+	title = prefix + icon + title
+	if d.labels.show {
+		start, end := l.paginator.GetSliceBounds(l.count())
+		title = d.labels.view(index-start, end-start) + title
+	}
+
+	rendered := titleStyle.Render(title)
+	if item.remote == nil {
+		rendered = d.paths.link(item.fullPath, item.lineNum, rendered)
+	}
+	fmt.Fprintf(w, "%s\n%s", rendered, descStyle.Render(desc)) //nolint: errcheck
+}
+
+// The rune indices of the first case-insensitive occurrence of query in s
+func filterMatchRunes(s, query string) []int {
+	start := strings.Index(strings.ToLower(s), query)
+	if start < 0 || start > len(s) {
+		return nil
+	}
+	first := utf8.RuneCountInString(s[:start])
+	runes := make([]int, utf8.RuneCountInString(query))
+	for i := range runes {
+		runes[i] = first + i
+	}
+	return runes
+}
+
+// Render s with the bytes covered by spans in the match style and everything
+// else in the base style. Spans must be sorted and non-overlapping.
+func highlightSpans(s string, spans []matchSpan, base, match lipgloss.Style) string {
+	if len(spans) == 0 {
+		return base.Render(s)
+	}
+
+	var b strings.Builder
+	pos := 0
+	for _, span := range spans {
+		start, end := max(span.start, pos), min(span.end, len(s))
+		if start >= end {
+			continue
+		}
+		if start > pos {
+			b.WriteString(base.Render(s[pos:start]))
+		}
+		b.WriteString(match.Render(s[start:end]))
+		pos = end
+	}
+	if pos < len(s) {
+		b.WriteString(base.Render(s[pos:]))
+	}
+	return b.String()
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// A file or directory in the directory listing, or the way up to its parent
+type dirEntry struct {
+	path    string
+	name    string
+	dir     bool
+	size    int64
+	modTime time.Time
+	open    bool // the file in the viewer
+}
+
+func (e dirEntry) Title() string {
+	switch {
+	case e.dir:
+		return "📁 " + e.name + "/"
+	case e.open:
+		return e.name + "  (open)"
+	}
+	return e.name
+}
+
+func (e dirEntry) Description() string {
+	switch {
+	case e.name == "..":
+		return "Up to " + e.path
+	case e.dir:
+		return "Directory · " + formatAge(e.modTime)
+	}
+	return fmt.Sprintf("%s · %s", formatBytes(e.size), formatAge(e.modTime))
+}
+
+func (e dirEntry) FilterValue() string { return e.name }
+
+func newDirList() list.Model {
+	dirList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	dirList.SetShowHelp(false)
+	dirList.SetStatusBarItemName("entry", "entries")
+	dirList.Styles.Title = lipgloss.NewStyle().
+		Foreground(special).
+		Bold(true).
+		MarginLeft(2)
+	return dirList
+}
+
+// List a directory for opening one of its files: its subdirectories first,
+// then its files, with the entry for selected picked out
+func (m *model) listDir(dir, selected string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var listed []dirEntry
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		listed = append(listed, dirEntry{
+			path:    path,
+			name:    entry.Name(),
+			dir:     info.IsDir(),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+			open:    path == m.fileState.item.fullPath,
+		})
+	}
+	sort.SliceStable(listed, func(i, j int) bool { return listed[i].dir && !listed[j].dir })
+
+	var items []list.Item
+	if parent := filepath.Dir(dir); parent != dir {
+		items = append(items, dirEntry{path: parent, name: "..", dir: true})
+	}
+	for _, entry := range listed {
+		items = append(items, entry)
+	}
+	m.dirList.ResetFilter()
+	m.dirList.SetItems(items)
+	m.dirList.Title = m.paths.show(dir)
+	m.dirList.Select(0)
+	for i, item := range items {
+		if item.(dirEntry).path == selected {
+			m.dirList.Select(i)
+		}
+	}
+	m.dir = dir
+	return nil
+}
+
+// Open the listing of the viewed file's directory, to open a file next to it
+func (m *model) openDir() {
+	if m.fileState.doc == nil || m.fileState.item.remote != nil {
+		return
+	}
+	path := m.fileState.item.fullPath
+	if err := m.listDir(filepath.Dir(path), path); err != nil {
+		m.statusMessage = fmt.Sprintf("Error listing the directory: %s", err)
+		m.statusMessageType = "error"
+		return
+	}
+	m.showDir = true
+}
+
+// Handle keys while the directory listing is open: enter opens a file in
+// the viewer or goes into a directory, and the directory key goes up
+func (m model) updateDir(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.dirList.SettingFilter() {
+		switch {
+		case key.Matches(msg, m.keymap.Quit):
+			return m, tea.Quit
+
+		case key.Matches(msg, m.keymap.Back):
+			m.showDir = false
+			return m, nil
+
+		case key.Matches(msg, m.keymap.Directory):
+			if parent := filepath.Dir(m.dir); parent != m.dir {
+				if err := m.listDir(parent, m.dir); err != nil {
+					m.statusMessage = fmt.Sprintf("Error listing the directory: %s", err)
+					m.statusMessageType = "error"
+				}
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keymap.Enter):
+			entry, ok := m.dirList.SelectedItem().(dirEntry)
+			if !ok {
+				return m, nil
+			}
+			if entry.dir {
+				if err := m.listDir(entry.path, m.dir); err != nil {
+					m.statusMessage = fmt.Sprintf("Error listing the directory: %s", err)
+					m.statusMessageType = "error"
+				}
+				return m, nil
+			}
+			m.showDir = false
+			m.activeTab = fileTab
+			m.statusMessage = fmt.Sprintf("Viewing file: %s", m.paths.show(entry.path))
+			m.statusMessageType = "info"
+			return m, m.openFile(Item{fileName: entry.path, fullPath: entry.path})
+		}
+	}
+
+	var cmd tea.Cmd
+	m.dirList, cmd = m.dirList.Update(msg)
+	return m, cmd
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// A dismissed result. The line's content is part of it, so a line that's
+// been changed since comes back.
+type dismissKey struct {
+	resultKey
+	content string
+}
+
+func (i Item) dismissKey() dismissKey {
+	return dismissKey{resultKey: i.key(), content: i.content}
+}
+
+// A dismissal that can still be undone
+type dismissal struct {
+	item     Item
+	index    int // where it was in the results
+	searchID int
+	rule     *ignoreRule // if it was marked as a false positive for good
+}
+
+// Remove the selected result from the list, leaving its file alone, and
+// keep it out when the search is run again
+func (m *model) dismissSelected() {
+	item, ok := m.resultsState.list.selected()
+	if !ok {
+		return
+	}
+	index := slices.IndexFunc(m.results, func(result Item) bool {
+		return result.key() == item.key()
+	})
+	if index < 0 {
+		return
+	}
+	m.dismissed[item.dismissKey()] = true
+	m.dismissals = append(m.dismissals, dismissal{item: item, index: index, searchID: m.searchID})
+	delete(m.marked, item.key())
+
+	m.results = slices.Delete(m.results, index, index+1)
+	m.refreshResults()
+
+	m.statusMessage = fmt.Sprintf("Dismissed %s:%d (%d dismissed, %s to undo)", m.paths.show(item.fileName), item.lineNum, len(m.dismissed), m.keymap.Undismiss.Help().Key)
+	m.statusMessageType = "info"
+}
+
+// Bring back the last dismissed result
+func (m *model) undismiss() {
+	if len(m.dismissals) == 0 {
+		m.statusMessage = "Nothing to undo"
+		m.statusMessageType = "info"
+		return
+	}
+	last := m.dismissals[len(m.dismissals)-1]
+	m.dismissals = m.dismissals[:len(m.dismissals)-1]
+	delete(m.dismissed, last.item.dismissKey())
+
+	if last.searchID == m.searchID {
+		m.results = slices.Insert(m.results, min(last.index, len(m.results)), last.item)
+		m.refreshResults()
+		m.selectResult(min(last.index, len(m.results)-1))
+	}
+	m.statusMessage = fmt.Sprintf("Restored %s:%d", m.paths.show(last.item.fileName), last.item.lineNum)
+	m.statusMessageType = "info"
+	if last.rule != nil {
+		m.unignore(last.rule)
+	}
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Editors that go to a line with file:line:col after -g, or with just
+// file:line:col. Everything else but emacsclient gets vi's +line before
+// the file, which emacs, nano, micro, kakoune and helix all understand too.
+var (
+	gotoFlagEditors = map[string]bool{
+		"code":          true,
+		"code-insiders": true,
+		"codium":        true,
+		"cursor":        true,
+	}
+	colonEditors = map[string]bool{
+		"subl": true,
+		"zed":  true,
+		"hx":   true,
+	}
+)
+
+// Editors that open a window of their own rather than running in the
+// terminal
+var guiEditors = map[string]bool{
+	"code":              true,
+	"code-insiders":     true,
+	"codium":            true,
+	"cursor":            true,
+	"subl":              true,
+	"zed":               true,
+	"gvim":              true,
+	"mvim":              true,
+	"idea":              true,
+	"goland":            true,
+	"pycharm":           true,
+	"webstorm":          true,
+	"clion":             true,
+	"rubymine":          true,
+	"studio":            true,
+	"gedit":             true,
+	"gnome-text-editor": true,
+	"kate":              true,
+	"mate":              true,
+	"open":              true,
+	"xdg-open":          true,
+}
+
+// The editor from the config: a command with {file}, {line} and {column}
+// to fill in, or just the editor to use in place of $VISUAL and $EDITOR
+type editorConfig struct {
+	template   string
+	gui        *bool  // nil to go by the editor's name
+	nvimServer string // a running Neovim to open results in
+	tmux       string // the tmux command that opens a pane or window for it
+}
+
+type editorDoneMsg struct {
+	path   string
+	gui    bool   // the editor was started in its own window and left running
+	editor string // what the file was opened in, for the status bar
+	err    error
+}
+
+// The editor's command line for opening path at line and column (1-based,
+// with 0 meaning the start)
+func editorArgs(editor []string, path string, line, column int) []string {
+	args := append([]string(nil), editor...)
+	name := editorName(editor[0])
+	if name == "emacsclient" {
+		return emacsclientArgs(args, path, line, column)
+	}
+	if line < 1 {
+		return append(args, path)
+	}
+	at := fmt.Sprintf("%s:%d", path, line)
+	if column > 0 {
+		at += fmt.Sprintf(":%d", column)
+	}
+	switch {
+	case gotoFlagEditors[name]:
+		return append(args, "-g", at)
+	case colonEditors[name]:
+		return append(args, at)
+	}
+	return append(args, fmt.Sprintf("+%d", line), path)
+}
+
+func editorName(command string) string {
+	return strings.TrimSuffix(filepath.Base(command), ".exe")
+}
+
+// emacsclient goes to +line:column, and unless it's been told to use the
+// terminal it's told not to wait, so the Emacs daemon opens the file in
+// the frame it has and lazyrg carries on
+func emacsclientArgs(args []string, path string, line, column int) []string {
+	if !emacsclientInTerminal(args) && !slices.Contains(args, "-n") && !slices.Contains(args, "--no-wait") {
+		args = append(args, "-n")
+	}
+	if line > 0 {
+		args = append(args, fmt.Sprintf("+%d:%d", line, max(column, 1)))
+	}
+	return append(args, path)
+}
+
+func emacsclientInTerminal(args []string) bool {
+	for _, arg := range args[1:] {
+		switch arg {
+		case "-t", "-nw", "--tty":
+			return true
+		}
+	}
+	return false
+}
+
+// Where the editor should open: the selected result, or in the file view
+// the match n and N are on
+func (m model) editorTarget() (string, int, int, bool) {
+	if m.activeTab == fileTab {
+		item := m.fileState.item
+		if m.fileState.doc == nil || item.remote != nil {
+			return "", 0, 0, false
+		}
+		if hits := m.fileState.hits; len(hits) > 0 {
+			hit := hits[m.fileState.hit]
+			return item.fullPath, hit.line, hit.start + 1, true
+		}
+		return item.fullPath, item.lineNum, item.column, true
+	}
+	item, ok := m.resultsState.list.selected()
+	if !ok || item.remote != nil {
+		return "", 0, 0, false
+	}
+	return item.fullPath, item.lineNum, item.column, true
+}
+
+// The command line that opens path at line and column: the configured
+// template's words with the placeholders filled in, run without a shell
+// so paths need no quoting, or the editor with the arguments its name
+// calls for
+func (e editorConfig) args(path string, line, column int) []string {
+	fields := strings.Fields(e.template)
+	if !strings.Contains(e.template, "{file}") {
+		if len(fields) == 0 {
+			fields = editorCommand()
+		}
+		return editorArgs(fields, path, line, column)
+	}
+	replacer := strings.NewReplacer(
+		"{file}", path,
+		"{line}", strconv.Itoa(max(line, 1)),
+		"{column}", strconv.Itoa(max(column, 1)),
+	)
+	args := make([]string, len(fields))
+	for i, field := range fields {
+		args[i] = replacer.Replace(field)
+	}
+	return args
+}
+
+// The editor without a result to open at, for a whole file: a template's
+// arguments are for opening a result, so only its first word is kept
+func (e editorConfig) command() []string {
+	fields := strings.Fields(e.template)
+	switch {
+	case len(fields) == 0:
+		return editorCommand()
+	case strings.Contains(e.template, "{file}"):
+		return fields[:1]
+	}
+	return fields
+}
+
+// Whether the editor command runs in a window of its own (or, for
+// emacsclient, in Emacs's)
+func (e editorConfig) isGUI(args []string) bool {
+	if e.gui != nil {
+		return *e.gui
+	}
+	name := editorName(args[0])
+	if name == "emacsclient" {
+		return !emacsclientInTerminal(args)
+	}
+	return guiEditors[name]
+}
+
+// Open the result in the editor. A terminal editor gets the terminal until
+// it exits; a GUI one is left running in its window.
+func (m *model) openInEditor() tea.Cmd {
+	if m.blockedByReadOnly("open the editor") {
+		return nil
+	}
+	path, line, column, ok := m.editorTarget()
+	if !ok {
+		m.statusMessage = "No file on disk to open"
+		m.statusMessageType = "error"
+		return nil
+	}
+	if server := m.editor.server(); server != "" {
+		return openInNvim(server, path, line, column)
+	}
+	args := m.editor.args(path, line, column)
+	cmd := exec.Command(args[0], args[1:]...)
+	if m.editor.isGUI(args) {
+		return func() tea.Msg {
+			if err := cmd.Start(); err != nil {
+				return editorDoneMsg{path: path, gui: true, editor: "the editor", err: err}
+			}
+			go cmd.Wait() //nolint: errcheck
+			return editorDoneMsg{path: path, gui: true, editor: "the editor"}
+		}
+	}
+	cmd.Stdout = m.terminalFile()
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return editorDoneMsg{path: path, err: err}
+	})
+}
+
+// The Neovim to open results in: the one lazyrg is running in a terminal
+// of, or the configured one
+func (e editorConfig) server() string {
+	if server := os.Getenv("NVIM"); server != "" {
+		return server
+	}
+	return e.nvimServer
+}
+
+// Open a file at a line in a running Neovim, in the window lazyrg's
+// terminal was opened from when that's where it's running, and leave
+// lazyrg where it is, as a picker beside the editor
+func openInNvim(server, path string, line, column int) tea.Cmd {
+	quoted := "'" + strings.ReplaceAll(path, "'", "''") + "'"
+	expr := fmt.Sprintf(`execute(["if &buftype ==# 'terminal' | wincmd p | endif", 'edit ' .. fnameescape(%s), 'call cursor(%d, %d)', 'normal! zz'])`,
+		quoted, max(line, 1), max(column, 1))
+	return func() tea.Msg {
+		cmd := exec.Command("nvim", "--server", server, "--remote-expr", expr)
+		var stderr strings.Builder
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		if msg := strings.TrimSpace(stderr.String()); err != nil && msg != "" {
+			err = fmt.Errorf("%s", msg)
+		}
+		return editorDoneMsg{path: path, gui: true, editor: "Neovim", err: err}
+	}
+}
+
+// How results open in tmux: a new pane beside lazyrg, unless the config
+// says otherwise
+const defaultTmuxOpen = "split-window -h"
+
+// Open the result in the editor in a new tmux pane or window, so lazyrg
+// stays in view beside it. The tmux command comes from the config, like
+// "new-window" or "split-window -v -l 40%", and the editor is run by tmux
+// directly, without a shell.
+func (m *model) openInTmux() tea.Cmd {
+	if m.blockedByReadOnly("open the editor") {
+		return nil
+	}
+	if !m.session.tmux {
+		m.statusMessage = "Not running inside tmux"
+		m.statusMessageType = "error"
+		return nil
+	}
+	path, line, column, ok := m.editorTarget()
+	if !ok {
+		m.statusMessage = "No file on disk to open"
+		m.statusMessageType = "error"
+		return nil
+	}
+	tmuxArgs := strings.Fields(m.editor.tmux)
+	if len(tmuxArgs) == 0 {
+		tmuxArgs = strings.Fields(defaultTmuxOpen)
+	}
+	args := append(tmuxArgs, "-c", filepath.Dir(path))
+	args = append(args, m.editor.args(path, line, column)...)
+	where := "a tmux pane"
+	if tmuxArgs[0] == "new-window" || tmuxArgs[0] == "neww" {
+		where = "a tmux window"
+	}
+	return func() tea.Msg {
+		cmd := exec.Command("tmux", args...)
+		out, err := cmd.CombinedOutput()
+		if msg := strings.TrimSpace(string(out)); err != nil && msg != "" {
+			err = fmt.Errorf("%s", msg)
+		}
+		return editorDoneMsg{path: path, gui: true, editor: where, err: err}
+	}
+}
+
+// Back from the editor: the file may have been changed in it, so the file
+// view shows it again
+func (m *model) handleEditorDone(msg editorDoneMsg) tea.Cmd {
+	if msg.err != nil {
+		m.statusMessage = fmt.Sprintf("Error running the editor: %s", msg.err)
+		m.statusMessageType = "error"
+		return nil
+	}
+	if msg.gui {
+		// The file view notices when it's saved
+		m.statusMessage = fmt.Sprintf("Opened %s in %s", m.paths.show(msg.path), msg.editor)
+		m.statusMessageType = "info"
+		return nil
+	}
+	m.statusMessage = fmt.Sprintf("Back from editing %s", m.paths.show(msg.path))
+	m.statusMessageType = "info"
+	if m.activeTab == fileTab && m.fileState.item.fullPath == msg.path {
+		return m.reloadFile()
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// A broad pattern is sampled first, counting at most estimateMaxCount lines
+// per file for up to estimateTimeout, and the search only runs straight
+// away if the sample has fewer than broadSearchLines lines.
+const (
+	estimateMaxCount = 1000
+	estimateTimeout  = 2 * time.Second
+	broadSearchLines = 10000
+)
+
+// Characters a pattern that matches nearly every line is likely to match
+var commonCharacters = []string{"a", "e", "s", "0", " ", "_", "(", "."}
+
+// Whether a pattern looks likely to match enormously: it matches the empty
+// string (like `.*`), is a single character, or matches a single common
+// character on its own (like `.` or `\w`)
+func broadPattern(pattern string) bool {
+	if len([]rune(pattern)) <= 1 {
+		return true
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		// Not a pattern Go understands; leave it to rg
+		return false
+	}
+	if re.MatchString("") {
+		return true
+	}
+	for _, c := range commonCharacters {
+		if re.FindString(c) == c {
+			return true
+		}
+	}
+	return false
+}
+
+// What a bounded sample of a search found
+type estimateMsg struct {
+	pattern string
+	dir     string
+	lines   int
+	files   int
+	partial bool // stopped by the timeout or the per-file cap, so there are more
+	err     error
+}
+
+// Count matching lines with `rg -c --max-count`, stopping at the timeout
+func sampleSearch(pattern, dir string, flags, paths []string, sandbox bool) tea.Cmd {
+	return func() tea.Msg {
+		msg := estimateMsg{pattern: pattern, dir: dir}
+		ctx, cancel := context.WithTimeout(context.Background(), estimateTimeout)
+		defer cancel()
+
+		args := append([]string{"-c", "--max-count", strconv.Itoa(estimateMaxCount), "--with-filename"}, flags...)
+		cmd, err := rgCommand(ctx, append(args, "-e", pattern), paths, sandbox)
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		// Partial output is still a sample when the timeout kills rg
+		out, _ := cmd.Output()
+		msg.partial = ctx.Err() != nil
+
+		scanner := bufio.NewScanner(bytes.NewReader(out))
+		for scanner.Scan() {
+			line := scanner.Text()
+			count, err := strconv.Atoi(line[strings.LastIndexByte(line, ':')+1:])
+			if err != nil {
+				continue
+			}
+			msg.files++
+			msg.lines += count
+			if count >= estimateMaxCount {
+				msg.partial = true
+			}
+		}
+		return msg
+	}
+}
+
+// Search for the inputs, sampling first if the pattern looks broad enough
+// to bury the results in matches
+func (m *model) checkedSearch() tea.Cmd {
+	pattern := m.searchInput.Value()
+	if !broadPattern(pattern) {
+		return m.startSearch()
+	}
+	paths, where := m.searchPaths()
+	m.statusMessage = fmt.Sprintf("Estimating matches for: %s in %s", pattern, where)
+	m.statusMessageType = "info"
+	return sampleSearch(pattern, m.directoryInput.Value(), m.searchFlags(paths), paths, m.sandbox)
+}
+
+// Search if the sample was small, and otherwise say how big it was and ask
+func (m *model) handleEstimate(msg estimateMsg) tea.Cmd {
+	if msg.pattern != m.searchInput.Value() || msg.dir != m.directoryInput.Value() {
+		// The inputs have changed since
+		return nil
+	}
+	if msg.err != nil || msg.lines < broadSearchLines {
+		// Let the search itself report any error
+		return m.startSearch()
+	}
+
+	estimate := fmt.Sprintf("%s matching lines in %s files", formatCount(msg.lines), formatCount(msg.files))
+	if msg.partial {
+		estimate = "at least " + estimate
+	}
+	m.ask(
+		fmt.Sprintf("%q is broad: %s. Search anyway?", msg.pattern, estimate),
+		func(m *model) tea.Cmd { return m.startSearch() },
+		answer{key: "w", label: "whole words", do: func(m *model) tea.Cmd {
+			m.searchInput.SetValue(`\b(?:` + msg.pattern + `)\b`)
+			m.searchInput.CursorEnd()
+			return m.startSearch()
+		}},
+		answer{key: "d", label: "pick a directory", do: func(m *model) tea.Cmd {
+			m.searchInput.Blur()
+			m.directoryInput.Focus()
+			m.statusMessage = "Enter a narrower directory to search"
+			m.statusMessageType = "info"
+			return nil
+		}},
+	)
+	return nil
+}
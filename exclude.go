@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Whether a result path is one that's been excluded, or is inside an
+// excluded directory
+func (m model) excluded(path string) bool {
+	for _, excluded := range m.excludes {
+		if path == excluded || strings.HasPrefix(path, excluded+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Drop the selected result's file (or, with dir, its directory) from the
+// results, and leave it out of later searches
+func (m *model) excludeSelected(dir bool) {
+	item, ok := m.resultsState.list.selected()
+	if !ok || item.remote != nil {
+		return
+	}
+	path := item.fullPath
+	if dir {
+		path = filepath.Dir(path)
+	}
+	if !slices.Contains(m.excludes, path) {
+		m.excludes = append(m.excludes, path)
+	}
+
+	m.results = slices.DeleteFunc(m.results, func(result Item) bool {
+		return m.excluded(result.fullPath)
+	})
+	m.refreshResults()
+
+	m.statusMessage = fmt.Sprintf("Excluded %s from results and later searches", m.paths.show(path))
+	m.statusMessageType = "info"
+}
+
+// rg globs leaving the excluded paths out of a search of roots. Globs are
+// relative to the directory searched, and anchored with a leading slash.
+func (m model) excludeArgs(roots []string) []string {
+	var args []string
+	for _, excluded := range m.excludes {
+		excluded, err := filepath.Abs(excluded)
+		if err != nil {
+			continue
+		}
+		for _, root := range roots {
+			root, err := filepath.Abs(root)
+			if err != nil {
+				continue
+			}
+			rel, err := filepath.Rel(root, excluded)
+			if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				continue
+			}
+			args = append(args, "-g", "!/"+filepath.ToSlash(rel))
+		}
+	}
+	return args
+}
+
+// The excluded paths, as shown in the search tab
+func (m model) excludesView() string {
+	shown := make([]string, len(m.excludes))
+	for i, excluded := range m.excludes {
+		shown[i] = m.paths.show(excluded)
+	}
+	return fmt.Sprintf("🚫 Excluding %s (%s to clear)", strings.Join(shown, ", "), m.keymap.ClearExcludes.Help().Key)
+}
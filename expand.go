@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Split results with several matches on their line into one result per
+// match, each highlighting just its own match
+func splitMatches(items []Item) []Item {
+	var split []Item
+	for _, item := range items {
+		if len(item.lineMatches) < 2 {
+			split = append(split, item)
+			continue
+		}
+		// The displayed line is trimmed, so its spans are shifted from the
+		// line's on disk
+		offset := 0
+		if len(item.matches) > 0 {
+			offset = item.lineMatches[0].start - item.matches[0].start
+		}
+		for _, span := range item.lineMatches {
+			one := item
+			one.column = span.start + 1
+			one.lineMatches = []matchSpan{span}
+			one.matches = nil
+			start := min(max(span.start-offset, 0), len(item.content))
+			end := min(max(span.end-offset, 0), len(item.content))
+			if start < end {
+				one.matches = []matchSpan{{start: start, end: end}}
+			}
+			split = append(split, one)
+		}
+	}
+	return split
+}
+
+// Merge consecutive results on the same line back into one, with all of
+// their matches
+func mergeMatches(items []Item) []Item {
+	var merged []Item
+	for _, item := range items {
+		if n := len(merged); n > 0 && merged[n-1].fullPath == item.fullPath && merged[n-1].lineNum == item.lineNum {
+			last := &merged[n-1]
+			last.lineMatches = append(last.lineMatches, item.lineMatches...)
+			last.matches = append(last.matches, item.matches...)
+			continue
+		}
+		item.lineMatches = append([]matchSpan(nil), item.lineMatches...)
+		item.matches = append([]matchSpan(nil), item.matches...)
+		merged = append(merged, item)
+	}
+	return merged
+}
+
+// Switch between one result per matched line and one per match
+func (m *model) toggleExpandMatches() {
+	m.expandMatches = !m.expandMatches
+	if m.expandMatches {
+		m.results = splitMatches(m.results)
+	} else {
+		m.results = mergeMatches(m.results)
+	}
+	m.refreshResults()
+
+	m.statusMessage = fmt.Sprintf("Showing one result per matched line (%s results)", formatCount(len(m.results)))
+	if m.expandMatches {
+		m.statusMessage = fmt.Sprintf("Showing one result per match (%s results)", formatCount(len(m.results)))
+	}
+	m.statusMessageType = "info"
+}
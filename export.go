@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The results an export or bulk action covers: the marked results if there
+// are any, and otherwise every result that gets through the filters, loaded
+// or not
+func (m *model) targetResults() []Item {
+	if len(m.marked) > 0 {
+		return m.selectedResults()
+	}
+	l := &m.resultsState.list
+	query := l.filterValue()
+	var items []Item
+	for i, result := range m.results {
+		if m.resultsState.filter.keep(result) && (query == "" || l.matchesFilter(i, query)) {
+			items = append(items, result)
+		}
+	}
+	return items
+}
+
+// Fill in a link template's {path}, {line} and {column} for a result
+func resultLink(tmpl string, root string, item Item) string {
+	if tmpl == "" {
+		return ""
+	}
+	return strings.NewReplacer(
+		"{path}", exportPath(root, item.fullPath),
+		"{line}", strconv.Itoa(item.lineNum),
+		"{column}", strconv.Itoa(item.column),
+	).Replace(tmpl)
+}
+
+// A path relative to the search directory, with forward slashes
+func exportPath(root string, path string) string {
+	if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// A result as a row of the HTML table
+type htmlRow struct {
+	Path    string
+	Line    int
+	Link    string
+	Snippet template.HTML
+}
+
+type htmlExport struct {
+	Pattern   string
+	Root      string
+	Generated string
+	Rows      []htmlRow
+}
+
+// Write the results as a standalone HTML page with a filterable table, for
+// sharing with people who won't run lazyrg
+func writeHTML(w io.Writer, pattern, root, linkTemplate string, items []Item) error {
+	export := htmlExport{
+		Pattern:   pattern,
+		Root:      root,
+		Generated: time.Now().Format("2006-01-02 15:04"),
+		Rows:      make([]htmlRow, len(items)),
+	}
+	for i, item := range items {
+		export.Rows[i] = htmlRow{
+			Path:    exportPath(root, item.fullPath),
+			Line:    item.lineNum,
+			Link:    resultLink(linkTemplate, root, item),
+			Snippet: htmlSnippet(item),
+		}
+	}
+	return htmlTemplate.Execute(w, export)
+}
+
+// The result's line with its matches in <mark>s
+func htmlSnippet(item Item) template.HTML {
+	var b strings.Builder
+	pos := 0
+	for _, span := range item.matches {
+		start, end := max(span.start, pos), min(span.end, len(item.content))
+		if start >= end {
+			continue
+		}
+		b.WriteString(template.HTMLEscapeString(item.content[pos:start]))
+		b.WriteString("<mark>" + template.HTMLEscapeString(item.content[start:end]) + "</mark>")
+		pos = end
+	}
+	b.WriteString(template.HTMLEscapeString(item.content[pos:]))
+	return template.HTML(b.String())
+}
+
+// Export the results to an HTML file in the working directory
+func (m *model) exportHTML() {
+	if m.blockedByReadOnly("export results") {
+		return
+	}
+	items := m.targetResults()
+	if len(items) == 0 {
+		m.statusMessage = "No results to export"
+		m.statusMessageType = "error"
+		return
+	}
+
+	name := fmt.Sprintf("lazyrg-%s.html", time.Now().Format("20060102-150405"))
+	file, err := os.Create(name)
+	if err == nil {
+		err = writeHTML(file, m.currentSearchPattern, m.paths.root, m.linkTemplate, items)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		m.statusMessage = fmt.Sprintf("Error exporting results: %s", err)
+		m.statusMessageType = "error"
+		return
+	}
+	m.statusMessage = fmt.Sprintf("Exported %d results to %s", len(items), name)
+	m.statusMessageType = "info"
+}
+
+var htmlTemplate = template.Must(template.New("export").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>lazyrg: {{.Pattern}}</title>
+<style>
+  body { font-family: system-ui, sans-serif; margin: 2rem; color: #222; }
+  h1 { font-size: 1.3rem; margin-bottom: 0.2rem; }
+  h1 code { color: #6124DF; }
+  .meta { color: #777; margin-bottom: 1rem; }
+  input { font: inherit; padding: 0.4rem; width: 24rem; margin-bottom: 1rem; }
+  table { border-collapse: collapse; width: 100%; }
+  th, td { text-align: left; padding: 0.3rem 0.6rem; border-bottom: 1px solid #eee; vertical-align: top; }
+  th { position: sticky; top: 0; background: #fff; }
+  td.path { white-space: nowrap; }
+  td.line { text-align: right; color: #777; }
+  td.snippet { font-family: ui-monospace, monospace; white-space: pre-wrap; }
+  mark { background: #73F59F; }
+</style>
+</head>
+<body>
+<h1>Results for <code>{{.Pattern}}</code></h1>
+<div class="meta">{{len .Rows}} results in {{.Root}} · {{.Generated}}</div>
+<input id="filter" type="search" placeholder="Filter by path or line" autofocus>
+<span id="count" class="meta"></span>
+<table>
+<thead><tr><th>File</th><th>Line</th><th>Match</th></tr></thead>
+<tbody>
+{{range .Rows}}<tr><td class="path">{{if .Link}}<a href="{{.Link}}">{{.Path}}</a>{{else}}{{.Path}}{{end}}</td><td class="line">{{.Line}}</td><td class="snippet">{{.Snippet}}</td></tr>
+{{end}}</tbody>
+</table>
+<script>
+  const rows = Array.from(document.querySelectorAll("tbody tr"));
+  const count = document.getElementById("count");
+  document.getElementById("filter").addEventListener("input", (e) => {
+    const query = e.target.value.toLowerCase();
+    let shown = 0;
+    for (const row of rows) {
+      const match = row.textContent.toLowerCase().includes(query);
+      row.hidden = !match;
+      if (match) shown++;
+    }
+    count.textContent = query ? shown + " shown" : "";
+  });
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// What an export writes out: the search and the results it covers
+type exportData struct {
+	pattern      string
+	root         string
+	linkTemplate string
+	items        []Item
+
+	markdownTemplate string // a file to lay the Markdown report out with
+}
+
+// A file format the results can be written in, for the export picker
+type exportFormat struct {
+	name        string
+	ext         string
+	description string
+	write       func(w io.Writer, data exportData) error
+}
+
+func (f exportFormat) Title() string       { return f.name }
+func (f exportFormat) Description() string { return f.description }
+func (f exportFormat) FilterValue() string { return f.name }
+
+var exportFormats = []exportFormat{
+	{name: "JSON", ext: "json", description: "The pattern and every match's file, line, column and text, for scripts", write: writeJSON},
+	{name: "Markdown", ext: "md", description: "A report with each file's matches in code blocks, for a PR or an issue", write: writeMarkdown},
+	{name: "SARIF", ext: "sarif", description: "Each match as a finding, for GitHub code scanning and other SARIF tools", write: writeSARIF},
+	{name: "Quickfix", ext: "qf", description: "file:line:col:text lines, for vim -q or :cfile", write: writeQuickfixExport},
+	{name: "CSV", ext: "csv", description: "A row for each result with its file, line, column, match and line, for spreadsheets", write: writeCSV},
+}
+
+// The JSON export's layout. Version goes up when fields change meaning or
+// go away, not when they're added.
+const jsonExportVersion = 1
+
+type jsonExport struct {
+	Version   int          `json:"version"`
+	Pattern   string       `json:"pattern"`
+	Root      string       `json:"root"`
+	Generated time.Time    `json:"generated"`
+	Results   []jsonResult `json:"results"`
+}
+
+type jsonResult struct {
+	Path    string      `json:"path"` // relative to root, with forward slashes
+	Line    int         `json:"line"`
+	Column  int         `json:"column"`
+	Text    string      `json:"text"`
+	Matches []jsonMatch `json:"matches"`
+	Link    string      `json:"link,omitempty"`
+}
+
+// A match's byte offsets into the result's text, which it's the part of
+type jsonMatch struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Text  string `json:"text"`
+}
+
+func writeJSON(w io.Writer, data exportData) error {
+	export := jsonExport{
+		Version:   jsonExportVersion,
+		Pattern:   data.pattern,
+		Root:      data.root,
+		Generated: time.Now(),
+		Results:   make([]jsonResult, len(data.items)),
+	}
+	for i, item := range data.items {
+		// The text as it is in the file, with its indentation back
+		text := item.indent + item.content
+		result := jsonResult{
+			Path:    exportPath(data.root, item.fullPath),
+			Line:    item.lineNum,
+			Column:  item.column,
+			Text:    text,
+			Matches: []jsonMatch{},
+			Link:    resultLink(data.linkTemplate, data.root, item),
+		}
+		for _, span := range item.matches {
+			start, end := max(span.start, 0)+len(item.indent), min(span.end+len(item.indent), len(text))
+			if start >= end {
+				continue
+			}
+			result.Matches = append(result.Matches, jsonMatch{Start: start, End: end, Text: text[start:end]})
+		}
+		export.Results[i] = result
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(export)
+}
+
+// A header, then a row for each result. The match column has the result's
+// first match that's within the line; the line is as it is in the file.
+// encoding/csv quotes fields with commas, quotes or line breaks.
+func writeCSV(w io.Writer, data exportData) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"file", "line", "column", "match", "text"}) //nolint: errcheck
+	for _, item := range data.items {
+		match := ""
+		for _, span := range item.matches {
+			start, end := max(span.start, 0), min(span.end, len(item.content))
+			if start < end {
+				match = item.content[start:end]
+				break
+			}
+		}
+		writer.Write([]string{ //nolint: errcheck
+			exportPath(data.root, item.fullPath),
+			strconv.Itoa(item.lineNum),
+			strconv.Itoa(item.column),
+			match,
+			item.indent + item.content,
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func newExportList() list.Model {
+	exportList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	exportList.SetShowHelp(false)
+	exportList.SetFilteringEnabled(false)
+	exportList.SetStatusBarItemName("format", "formats")
+	exportList.Styles.Title = lipgloss.NewStyle().
+		Foreground(special).
+		Bold(true).
+		MarginLeft(2)
+	return exportList
+}
+
+// Open the picker of formats to export the results in
+func (m *model) openExport() {
+	if m.blockedByReadOnly("export results") {
+		return
+	}
+	items := m.targetResults()
+	if len(items) == 0 {
+		m.statusMessage = "No results to export"
+		m.statusMessageType = "error"
+		return
+	}
+	formats := make([]list.Item, len(exportFormats))
+	for i, format := range exportFormats {
+		formats[i] = format
+	}
+	m.exportList.SetItems(formats)
+	m.exportList.Title = fmt.Sprintf("Export %d results as", len(items))
+	m.showExport = true
+}
+
+// Write the results in a format to a file in the working directory
+func (m *model) exportAs(format exportFormat) {
+	items := m.targetResults()
+	name := fmt.Sprintf("lazyrg-%s.%s", time.Now().Format("20060102-150405"), format.ext)
+	file, err := os.Create(name)
+	if err == nil {
+		err = format.write(file, exportData{
+			pattern:      m.currentSearchPattern,
+			root:         m.paths.root,
+			linkTemplate: m.linkTemplate,
+			items:        items,
+
+			markdownTemplate: m.markdownTemplate,
+		})
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		m.statusMessage = fmt.Sprintf("Error exporting results: %s", err)
+		m.statusMessageType = "error"
+		return
+	}
+	m.statusMessage = fmt.Sprintf("Exported %d results to %s", len(items), name)
+	m.statusMessageType = "info"
+}
+
+// Handle keys while picking an export format
+func (m model) updateExport(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keymap.Quit):
+		return m, tea.Quit
+
+	case key.Matches(msg, m.keymap.Back) || key.Matches(msg, m.keymap.Export):
+		m.showExport = false
+		return m, nil
+
+	case key.Matches(msg, m.keymap.Enter):
+		format, ok := m.exportList.SelectedItem().(exportFormat)
+		if !ok {
+			return m, nil
+		}
+		m.showExport = false
+		m.exportAs(format)
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.exportList, cmd = m.exportList.Update(msg)
+	return m, cmd
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestWriteJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		matches []matchSpan
+		want    []jsonMatch
+	}{
+		{name: "match", content: "a foo b", matches: []matchSpan{{2, 5}}, want: []jsonMatch{{Start: 3, End: 6, Text: "foo"}}},
+		{name: "no matches", content: "a foo b", want: []jsonMatch{}},
+		{name: "past the end", content: "ab", matches: []matchSpan{{5, 7}}, want: []jsonMatch{}},
+		{name: "running off the end", content: "ab", matches: []matchSpan{{1, 7}}, want: []jsonMatch{{Start: 2, End: 3, Text: "b"}}},
+		{name: "before the start", content: "ab", matches: []matchSpan{{-3, 1}}, want: []jsonMatch{{Start: 1, End: 2, Text: "a"}}},
+		{name: "backwards", content: "abc", matches: []matchSpan{{2, 1}}, want: []jsonMatch{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := writeJSON(&out, exportData{
+				pattern: "foo",
+				root:    "/src",
+				items:   []Item{{fullPath: "/src/dir/a.go", lineNum: 3, column: 1, indent: "\t", content: test.content, matches: test.matches}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var export jsonExport
+			if err := json.Unmarshal(out.Bytes(), &export); err != nil {
+				t.Fatal(err)
+			}
+			if len(export.Results) != 1 {
+				t.Fatalf("got %d results, want 1", len(export.Results))
+			}
+			result := export.Results[0]
+			if result.Path != "dir/a.go" || result.Line != 3 || result.Text != "\t"+test.content {
+				t.Errorf("result %s:%d %q, want dir/a.go:3 %q", result.Path, result.Line, result.Text, "\t"+test.content)
+			}
+			if !reflect.DeepEqual(result.Matches, test.want) {
+				t.Errorf("matches %+v, want %+v", result.Matches, test.want)
+			}
+		})
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		matches []matchSpan
+		match   string
+	}{
+		{name: "match", content: "a foo b", matches: []matchSpan{{2, 5}}, match: "foo"},
+		{name: "no matches", content: "a foo b", match: ""},
+		{name: "past the end", content: "ab", matches: []matchSpan{{5, 7}}, match: ""},
+		{name: "running off the end", content: "ab", matches: []matchSpan{{1, 7}}, match: "b"},
+		{name: "before the start", content: "ab", matches: []matchSpan{{-3, 1}}, match: "a"},
+		{name: "backwards", content: "abc", matches: []matchSpan{{2, 1}}, match: ""},
+		{name: "first in the line", content: "abc", matches: []matchSpan{{9, 12}, {1, 2}}, match: "b"},
+		{name: "quoted", content: `say "hi", then`, matches: []matchSpan{{4, 9}}, match: `"hi",`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := writeCSV(&out, exportData{
+				root:  "/src",
+				items: []Item{{fullPath: "/src/a.go", lineNum: 3, column: 1, indent: "\t", content: test.content, matches: test.matches}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			rows, err := csv.NewReader(&out).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != 2 {
+				t.Fatalf("got %d rows, want a header and a result", len(rows))
+			}
+			want := []string{"a.go", "3", "1", test.match, "\t" + test.content}
+			for i, field := range rows[1] {
+				if field != want[i] {
+					t.Errorf("column %s is %q, want %q", rows[0][i], field, want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var findStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("#1A1A1A")).
+	Background(lipgloss.Color("#FFD75F"))
+
+func newFindInput() textinput.Model {
+	findInput := textinput.New()
+	findInput.Placeholder = "regex, ignoring case unless it has capitals"
+	findInput.Prompt = "Find ❯ "
+	findInput.PromptStyle = searchPromptStyle
+	findInput.TextStyle = lipgloss.NewStyle().Foreground(highlight)
+	findInput.Cursor.Style = lipgloss.NewStyle().Foreground(special)
+	return findInput
+}
+
+// Compile a pattern typed into the find bar. Like rg's --smart-case, it
+// ignores case unless it has an upper case letter in it.
+func compileFind(pattern string) (*regexp.Regexp, error) {
+	if !strings.ContainsFunc(pattern, unicode.IsUpper) {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// Whether there's a bar above the file for the find input
+func (m model) showFindBar() bool {
+	return m.fileState.finding || m.fileState.find != nil
+}
+
+// Whether there's a bar above the file, for finding or going to a line
+func (m model) showFileBar() bool {
+	return m.showFindBar() || m.fileState.goingTo
+}
+
+// Size the viewer around the bar above it, if it's showing
+func (m *model) layoutFile() {
+	height := m.listHeight
+	if m.showFileBar() {
+		height -= 2
+	}
+	m.fileState.viewer.Height = height
+	m.fileState.viewer.SetYOffset(m.fileState.viewer.YOffset)
+}
+
+// Start typing a pattern to find in the open file, separate from the search
+func (m *model) openFind() tea.Cmd {
+	if m.fileState.doc == nil {
+		return nil
+	}
+	if m.review.stagedFile(m.fileState.item.fullPath) != nil {
+		m.statusMessage = "Finding in a staged replacement isn't supported"
+		m.statusMessageType = "error"
+		return nil
+	}
+	if m.fileState.chunk != nil {
+		m.statusMessage = "Finding in a file this large isn't supported; search it with rg instead"
+		m.statusMessageType = "error"
+		return nil
+	}
+	if m.fileState.binary {
+		m.statusMessage = fmt.Sprintf("Finding in the hex view isn't supported (%s to show the file as text)", m.keymap.Hex.Help().Key)
+		m.statusMessageType = "error"
+		return nil
+	}
+	if m.fileState.text == nil {
+		content, err := os.ReadFile(m.fileState.item.fullPath)
+		if err != nil {
+			m.statusMessage = fmt.Sprintf("Error reading file: %v", err)
+			m.statusMessageType = "error"
+			return nil
+		}
+		m.fileState.text = strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+	}
+
+	m.fileState.finding = true
+	m.fileState.findFrom = m.fileState.viewer.YOffset
+	m.fileState.findInput.CursorEnd()
+	m.layoutFile()
+	return m.fileState.findInput.Focus()
+}
+
+// Drop the find pattern, going back to the search's matches for n and N
+func (m *model) clearFind() {
+	m.resetFind()
+	m.trackResultHits()
+}
+
+// Close the find bar and forget the pattern
+func (m *model) resetFind() {
+	m.fileState.finding = false
+	m.fileState.findInput.Blur()
+	m.fileState.findInput.SetValue("")
+	m.fileState.find = nil
+	m.fileState.findErr = nil
+	m.layoutFile()
+}
+
+// Handle keys while the find input has focus. The viewer moves to the first
+// match at or below where it was as you type.
+func (m model) updateFind(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.String() == "ctrl+c":
+		return m, tea.Quit
+
+	case key.Matches(msg, m.keymap.Enter):
+		m.fileState.finding = false
+		m.fileState.findInput.Blur()
+		m.layoutFile()
+		return m, nil
+
+	case key.Matches(msg, m.keymap.Back):
+		m.clearFind()
+		m.fileState.viewer.SetYOffset(m.fileState.findFrom)
+		m.renderVisibleFile()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.fileState.findInput, cmd = m.fileState.findInput.Update(msg)
+
+	pattern := m.fileState.findInput.Value()
+	if pattern == "" {
+		m.fileState.find = nil
+		m.fileState.findErr = nil
+		m.trackResultHits()
+		m.fileState.viewer.SetYOffset(m.fileState.findFrom)
+		m.renderVisibleFile()
+		return m, cmd
+	}
+	find, err := compileFind(pattern)
+	m.fileState.findErr = err
+	if err != nil {
+		return m, cmd
+	}
+	m.fileState.find = find
+	m.fileState.hits = m.findHits(find)
+	m.fileState.hit = 0
+	for i, hit := range m.fileState.hits {
+		if hit.line-1+m.fileState.lineOffset >= m.fileState.findFrom {
+			m.fileState.hit = i
+			break
+		}
+	}
+	if len(m.fileState.hits) == 0 {
+		m.fileState.viewer.SetYOffset(m.fileState.findFrom)
+		m.renderVisibleFile()
+		m.statusMessage = fmt.Sprintf("No matches for %s in this file", pattern)
+		m.statusMessageType = "info"
+		return m, cmd
+	}
+	m.showHit()
+	return m, cmd
+}
+
+// Every match of find in the open file, in order
+func (m model) findHits(find *regexp.Regexp) []fileHit {
+	var hits []fileHit
+	for i, line := range m.fileState.text {
+		for _, loc := range find.FindAllStringIndex(line, -1) {
+			if loc[0] < loc[1] {
+				hits = append(hits, fileHit{line: i + 1, start: loc[0], end: loc[1]})
+			}
+		}
+	}
+	return hits
+}
+
+// Highlight the find pattern's matches on a rendered line of the document
+func (m model) highlightFind(i int, rendered string) string {
+	if m.fileState.find == nil || m.fileState.doc.gutter == 0 {
+		return rendered
+	}
+	n := i - m.fileState.lineOffset
+	if n < 0 || n >= len(m.fileState.text) {
+		return rendered
+	}
+	line := m.fileState.text[n]
+	var spans []matchSpan
+	for _, loc := range m.fileState.find.FindAllStringIndex(line, -1) {
+		spans = append(spans, matchSpan{start: loc[0], end: loc[1]})
+	}
+	if len(spans) == 0 {
+		return rendered
+	}
+	return restyleSpans(rendered, m.fileState.doc.gutter, line, spans, findStyle)
+}
+
+func (m model) findView() string {
+	view := m.fileState.findInput.View()
+	switch {
+	case m.fileState.findErr != nil:
+		view += "  " + resultFilterErrorStyle.Render(m.fileState.findErr.Error())
+	case m.fileState.find != nil && len(m.fileState.hits) == 0:
+		view += "  " + resultFilterErrorStyle.Render("no matches")
+	case m.fileState.find != nil:
+		view += "  " + jumpHintStyle.Render(fmt.Sprintf("(%d of %d, n for the next)", m.fileState.hit+1, len(m.fileState.hits)))
+	}
+	return resultFilterStyle.Render(view) + "\n"
+}
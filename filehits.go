@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// A place in the open file to jump to with n and N: a line (1-based) and
+// the byte offsets of the text on it
+type fileHit struct {
+	line       int
+	start, end int
+}
+
+// Every match of the search in path, in order. They come from the results,
+// so they're only the ones rg reported.
+func (m model) resultHits(path string) []fileHit {
+	seen := map[fileHit]bool{}
+	var hits []fileHit
+	for _, result := range m.results {
+		if result.fullPath != path || result.lineNum == 0 {
+			continue
+		}
+		for _, span := range result.lineMatches {
+			hit := fileHit{line: result.lineNum, start: span.start, end: span.end}
+			if !seen[hit] {
+				seen[hit] = true
+				hits = append(hits, hit)
+			}
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].line != hits[j].line {
+			return hits[i].line < hits[j].line
+		}
+		return hits[i].start < hits[j].start
+	})
+	return hits
+}
+
+// The hits on each line, for highlighting them
+func hitSpans(hits []fileHit) map[int][]matchSpan {
+	spans := map[int][]matchSpan{}
+	for _, hit := range hits {
+		spans[hit.line] = append(spans[hit.line], matchSpan{start: hit.start, end: hit.end})
+	}
+	return spans
+}
+
+// The lines with hits on them as bat --highlight-line ranges, with runs of
+// lines in a row as one range
+func hitRanges(hits []fileHit) []string {
+	var ranges []string
+	for i := 0; i < len(hits); {
+		first, last := hits[i].line, hits[i].line
+		for ; i < len(hits) && hits[i].line <= last+1; i++ {
+			last = hits[i].line
+		}
+		if first == last {
+			ranges = append(ranges, strconv.Itoa(first))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%d:%d", first, last))
+		}
+	}
+	return ranges
+}
+
+// Track the matches in a newly opened file, starting from the one that was
+// opened. A staged replacement's lines don't line up with the file's, so
+// it has none.
+func (m *model) trackHits(item Item, staged bool) {
+	m.fileState.item = item
+	m.fileState.hits = nil
+	m.fileState.hit = 0
+	m.fileState.lineOffset = 0
+	if staged {
+		return
+	}
+	m.trackResultHits()
+}
+
+// Go through the search's matches in the open file with n and N, from the
+// one it was opened at
+func (m *model) trackResultHits() {
+	item := m.fileState.item
+	m.fileState.hits = m.resultHits(item.fullPath)
+	m.fileState.hit = 0
+	for i, hit := range m.fileState.hits {
+		if hit.line == item.lineNum && (len(item.lineMatches) == 0 || hit.start == item.lineMatches[0].start) {
+			m.fileState.hit = i
+			break
+		}
+	}
+}
+
+// Scroll the viewer to the next (or with a negative delta, previous) match
+// in the file, or of the find pattern if there is one, going round at
+// either end
+func (m *model) stepHit(delta int) {
+	hits := m.fileState.hits
+	if len(hits) == 0 || m.fileState.doc == nil {
+		m.statusMessage = "No matches in this file"
+		m.statusMessageType = "info"
+		return
+	}
+
+	m.fileState.hit = (m.fileState.hit + delta%len(hits) + len(hits)) % len(hits)
+	m.showHit()
+}
+
+// Scroll the viewer to the current hit and say where it is
+func (m *model) showHit() {
+	hits := m.fileState.hits
+	hit := hits[m.fileState.hit]
+	m.statusMessage = fmt.Sprintf("Match %d/%d, line %d", m.fileState.hit+1, len(hits), hit.line)
+	m.statusMessageType = "info"
+	if !m.fileLineLoaded(hit.line) {
+		return
+	}
+	m.scrollToLine(hit.line - 1 + m.fileState.lineOffset)
+	if m.fileState.chunk != nil {
+		// Reading up to the line would mean reading most of the file
+		m.fileState.xOffset = 0
+	} else if line, ok := readLine(m.fileState.item.fullPath, hit.line); ok && hit.start <= len(line) {
+		m.revealColumn(displayWidth(line[:hit.start], batTabWidth))
+	}
+	m.renderVisibleFile()
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How often a followed file is checked for new lines
+const followInterval = time.Second
+
+type followTickMsg struct {
+	token int
+}
+
+// What's been added to a followed file since it was last read
+type followReadMsg struct {
+	token     int
+	lines     []string // complete lines
+	partial   string   // the start of a line still being written
+	to        int64    // where the complete lines end
+	truncated bool     // the file's shorter than it was, so it's been rotated or rewritten
+	err       error
+}
+
+// Where a loaded file's complete lines end, for following it from there:
+// the last of its lines, the one after the last newline, is replaced by
+// what's read next
+func followOffset(content []byte) int64 {
+	return int64(bytes.LastIndexByte(content, '\n') + 1)
+}
+
+// Start or stop following the open file like tail -f: the viewer goes to
+// the end and new lines are added as they're written, staying at the end
+// unless it's been scrolled up
+func (m *model) toggleFollow() tea.Cmd {
+	if m.fileState.doc == nil {
+		return nil
+	}
+	if m.fileState.follow {
+		m.fileState.follow = false
+		m.statusMessage = "Stopped following the file"
+		m.statusMessageType = "info"
+		return m.watchFile()
+	}
+	item := m.fileState.item
+	if _, err := os.Stat(item.fullPath); err != nil || m.fileState.binary || item.remote != nil || m.review.stagedFile(item.fullPath) != nil {
+		m.statusMessage = "Only text files on disk can be followed"
+		m.statusMessageType = "error"
+		return nil
+	}
+	m.fileState.follow = true
+	m.statusMessage = fmt.Sprintf("Following %s (%s to stop)", m.paths.show(item.fileName), m.keymap.Follow.Help().Key)
+	m.statusMessageType = "info"
+	// Loaded again, from the end for a very large file and with the built-in
+	// highlighter, whose lines can be added to
+	return m.openFile(item)
+}
+
+func followTick(token int) tea.Cmd {
+	return tea.Tick(followInterval, func(time.Time) tea.Msg { return followTickMsg{token} })
+}
+
+// Read what's been added to a file since from
+func readFollow(path string, token int, from int64) tea.Cmd {
+	return func() tea.Msg {
+		msg := followReadMsg{token: token, to: from}
+		file, err := os.Open(path)
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		defer file.Close()
+		info, err := file.Stat()
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		if info.Size() < from {
+			msg.truncated = true
+			return msg
+		}
+		if info.Size() == from {
+			return msg
+		}
+		added, err := io.ReadAll(io.NewSectionReader(file, from, info.Size()-from))
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		complete := followOffset(added)
+		if complete > 0 {
+			text := strings.TrimSuffix(string(added[:complete]), "\n")
+			for _, line := range strings.Split(text, "\n") {
+				msg.lines = append(msg.lines, strings.TrimRight(line, "\r"))
+			}
+		}
+		msg.partial = string(added[complete:])
+		msg.to = from + complete
+		return msg
+	}
+}
+
+func (m *model) handleFollowTick(msg followTickMsg) tea.Cmd {
+	if !m.fileState.follow || msg.token != m.fileToken || m.fileState.doc == nil {
+		return nil
+	}
+	return readFollow(m.fileState.item.fullPath, m.fileToken, m.fileState.followFrom)
+}
+
+// Add a followed file's new lines to the document in place of its last
+// line, and keep the viewer at the end if that's where it was
+func (m *model) handleFollowRead(msg followReadMsg) tea.Cmd {
+	doc := m.fileState.doc
+	if !m.fileState.follow || msg.token != m.fileToken || doc == nil {
+		return nil
+	}
+	switch {
+	case msg.err != nil:
+		m.fileState.follow = false
+		m.statusMessage = fmt.Sprintf("Stopped following the file: %s", msg.err)
+		m.statusMessageType = "error"
+		return nil
+	case msg.truncated:
+		m.statusMessage = "The file was truncated or replaced, so it's been loaded again"
+		m.statusMessageType = "info"
+		return m.openFile(m.fileState.item)
+	case msg.to == m.fileState.followFrom && len(msg.partial) == 0:
+		return followTick(m.fileToken)
+	}
+
+	atEnd := m.fileState.viewer.AtBottom()
+	if doc.rendered == nil {
+		doc.rendered = make([]bool, len(doc.lines))
+	}
+	keep := len(doc.lines)
+	if m.fileState.followPartial {
+		keep--
+	}
+	added := append(msg.lines, msg.partial)
+	if m.fileState.chunk != nil {
+		// A chunk's lines are rendered by number already, and don't end
+		// with an empty one for a final newline
+		m.fileState.chunk.last += len(msg.lines) - (len(doc.lines) - keep)
+		m.fileState.chunk.end = msg.to
+		if msg.partial == "" {
+			added = msg.lines
+		} else {
+			m.fileState.chunk.last++
+		}
+	} else if m.fileState.followPlain < 0 {
+		m.fileState.followPlain = keep
+		doc.highlight = followHighlight(doc.highlight, keep, doc.gutter-5)
+	}
+	doc.lines = append(doc.lines[:keep], added...)
+	doc.rendered = append(doc.rendered[:keep], make([]bool, len(added))...)
+	m.fileState.followPartial = msg.partial != "" || m.fileState.chunk == nil
+	m.fileState.followFrom = msg.to
+	yOffset := m.fileState.viewer.YOffset
+	m.setFileDocument(doc)
+	if atEnd {
+		m.fileState.viewer.GotoBottom()
+	} else {
+		m.fileState.viewer.SetYOffset(yOffset)
+	}
+	m.renderVisibleFile()
+	if n := len(msg.lines); n > 0 {
+		m.statusMessage = fmt.Sprintf("Following %s: %d new lines (%s to stop)", m.paths.show(m.fileState.item.fileName), n, m.keymap.Follow.Help().Key)
+		m.statusMessageType = "info"
+	}
+	return followTick(m.fileToken)
+}
+
+// Render lines added while following plainly, since the file's syntax
+// highlighting was worked out before they were there; lines before from
+// render as usual
+func followHighlight(highlight func(int, string) string, from, digits int) func(int, string) string {
+	return func(i int, line string) string {
+		if i < from {
+			return highlight(i, line)
+		}
+		return fileGutter(i+1, digits, false, false) + expandTabs(line, batTabWidth)
+	}
+}
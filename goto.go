@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func newGotoInput() textinput.Model {
+	gotoInput := textinput.New()
+	gotoInput.Placeholder = "line number"
+	gotoInput.Prompt = "Go to line ❯ "
+	gotoInput.PromptStyle = searchPromptStyle
+	gotoInput.TextStyle = lipgloss.NewStyle().Foreground(highlight)
+	gotoInput.Cursor.Style = lipgloss.NewStyle().Foreground(special)
+	gotoInput.CharLimit = 10
+	return gotoInput
+}
+
+// Start typing a line number to jump to in the open file
+func (m *model) openGoto() tea.Cmd {
+	if m.fileState.doc == nil {
+		return nil
+	}
+	m.fileState.goingTo = true
+	m.fileState.gotoErr = nil
+	m.fileState.gotoInput.SetValue("")
+	m.layoutFile()
+	return m.fileState.gotoInput.Focus()
+}
+
+func (m *model) closeGoto() {
+	m.fileState.goingTo = false
+	m.fileState.gotoInput.Blur()
+	m.layoutFile()
+}
+
+// Handle keys while the line number prompt has focus
+func (m model) updateGoto(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.String() == "ctrl+c":
+		return m, tea.Quit
+
+	case key.Matches(msg, m.keymap.Back):
+		m.closeGoto()
+		return m, nil
+
+	case key.Matches(msg, m.keymap.Enter):
+		line, err := strconv.Atoi(strings.TrimSpace(m.fileState.gotoInput.Value()))
+		if err != nil || line < 1 {
+			m.fileState.gotoErr = fmt.Errorf("not a line number")
+			return m, nil
+		}
+		m.closeGoto()
+		m.gotoLine(line)
+		return m, m.moreOfFile()
+	}
+
+	var cmd tea.Cmd
+	m.fileState.gotoInput, cmd = m.fileState.gotoInput.Update(msg)
+	m.fileState.gotoErr = nil
+	return m, cmd
+}
+
+// Scroll the viewer to a line (1-based) of the file, or its last line if
+// it's past the end
+func (m *model) gotoLine(line int) {
+	m.statusMessage = fmt.Sprintf("Line %d", line)
+	m.statusMessageType = "info"
+	if !m.fileLineLoaded(line) {
+		return
+	}
+	if chunk := m.fileState.chunk; chunk != nil {
+		line = min(line, chunk.last)
+	} else {
+		last := len(m.fileState.doc.lines) - m.fileState.lineOffset
+		line = min(line, max(last, 1))
+	}
+	m.scrollToLine(line - 1 + m.fileState.lineOffset)
+	m.fileState.xOffset = 0
+	m.renderVisibleFile()
+	m.statusMessage = fmt.Sprintf("Line %d", line)
+}
+
+// Scroll the viewer to the top or bottom of the file
+func (m *model) gotoEnd(bottom bool) {
+	if m.fileState.doc == nil {
+		return
+	}
+	if bottom && !m.fileLineLoaded(-1) || !bottom && !m.fileLineLoaded(1) {
+		return
+	}
+	if bottom {
+		m.fileState.viewer.GotoBottom()
+	} else {
+		m.fileState.viewer.GotoTop()
+	}
+	m.renderVisibleFile()
+}
+
+func (m model) gotoView() string {
+	view := m.fileState.gotoInput.View()
+	if m.fileState.gotoErr != nil {
+		view += "  " + resultFilterErrorStyle.Render(m.fileState.gotoErr.Error())
+	} else if chunk := m.fileState.chunk; chunk != nil && !chunk.eof {
+		view += "  " + jumpHintStyle.Render("(of more than "+formatCount(chunk.last)+")")
+	} else if chunk != nil {
+		view += "  " + jumpHintStyle.Render(fmt.Sprintf("(of %d)", chunk.last))
+	} else if m.fileState.doc != nil {
+		view += "  " + jumpHintStyle.Render(fmt.Sprintf("(of %d)", len(m.fileState.doc.lines)-m.fileState.lineOffset))
+	}
+	return resultFilterStyle.Render(view) + "\n"
+}
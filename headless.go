@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Exit codes for -no-tui, as grep's: something was found, nothing was, or
+// the search failed
+const (
+	exitFound   = 0
+	exitNoMatch = 1
+	exitError   = 2
+)
+
+// What -no-tui prints results as: grep-style lines, or any of the export
+// formats by their extension
+const formatText = "text"
+
+func headlessFormat(name string) (exportFormat, bool) {
+	if name == formatText {
+		name = "qf"
+	}
+	for _, format := range exportFormats {
+		if format.ext == name {
+			return format, true
+		}
+	}
+	return exportFormat{}, false
+}
+
+func headlessFormatNames() string {
+	names := []string{formatText}
+	for _, format := range exportFormats {
+		names = append(names, format.ext)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Run the search the command line and config describe without the
+// interface, with the same flags, exclusions, scope and false positives
+// left out, and write the results to w. An imported session is printed
+// as it is, without searching.
+func (m *model) runHeadless(format exportFormat, w io.Writer, search bool) int {
+	if search {
+		if m.searchInput.Value() == "" {
+			fmt.Fprintln(os.Stderr, "error: -no-tui needs a pattern")
+			return exitError
+		}
+		if err := m.searchToEnd(); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return exitError
+		}
+	}
+	if err := format.write(w, exportData{
+		pattern:      m.currentSearchPattern,
+		root:         m.paths.root,
+		linkTemplate: m.linkTemplate,
+		items:        m.results,
+
+		markdownTemplate: m.markdownTemplate,
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitError
+	}
+	if len(m.results) == 0 {
+		return exitNoMatch
+	}
+	return exitFound
+}
+
+// Run the search and collect every result, waiting on the stream the way
+// the interface does between redraws
+func (m *model) searchToEnd() error {
+	msg := m.runSearch()()
+	var stream *searchStream
+	for {
+		switch msg := msg.(type) {
+		case searchStartedMsg:
+			stream = msg.stream
+		case searchResultsMsg:
+			m.appendResults(msg.results)
+		case searchFinishedMsg:
+			return msg.err
+		default:
+			// The stream closed without saying how it went
+			return nil
+		}
+		msg = stream.next()()
+	}
+}
+
+// Run headless and write the results to path rather than stdout. The file
+// is only written once the search has finished, so a search that fails,
+// in a cron job say, leaves the last run's results in place.
+func (m *model) runHeadlessTo(format exportFormat, path string, search bool) int {
+	if path == "-" {
+		return m.runHeadless(format, os.Stdout, search)
+	}
+	var out bytes.Buffer
+	code := m.runHeadless(format, &out, search)
+	if code == exitError {
+		return code
+	}
+	if err := os.WriteFile(path, out.Bytes(), 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitError
+	}
+	return code
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// -no-tui exits as grep does, so scripts can tell found from not found
+// from a failed search
+func TestRunHeadless(t *testing.T) {
+	if _, err := exec.LookPath("rg"); err != nil {
+		t.Skip("rg isn't installed")
+	}
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(path, []byte("one\nfoo two\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	format, ok := headlessFormat(formatText)
+	if !ok {
+		t.Fatalf("no %s format", formatText)
+	}
+	tests := []struct {
+		name    string
+		pattern string
+		want    int
+		output  string
+	}{
+		{name: "found", pattern: "foo", want: exitFound, output: path + ":2:1:foo two\n"},
+		{name: "not found", pattern: "bar", want: exitNoMatch},
+		{name: "bad pattern", pattern: "foo(", want: exitError},
+		{name: "no pattern", pattern: "", want: exitError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := initialModel()
+			m.currentPath = dir
+			m.applyConfig(config{}, test.pattern, dir)
+			var out bytes.Buffer
+			if got := m.runHeadless(format, &out, true); got != test.want {
+				t.Errorf("exit code %d, want %d", got, test.want)
+			}
+			if got := out.String(); got != test.output {
+				t.Errorf("printed %q, want %q", got, test.output)
+			}
+		})
+	}
+}
+
+// A failed search, in a cron job say, leaves the last results in place
+func TestRunHeadlessTo(t *testing.T) {
+	if _, err := exec.LookPath("rg"); err != nil {
+		t.Skip("rg isn't installed")
+	}
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("foo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(t.TempDir(), "results.json")
+	format, ok := headlessFormat("json")
+	if !ok {
+		t.Fatal("no json format")
+	}
+	run := func(pattern string) int {
+		m := initialModel()
+		m.currentPath = dir
+		m.applyConfig(config{}, pattern, dir)
+		return m.runHeadlessTo(format, out, true)
+	}
+
+	if code := run("foo"); code != exitFound {
+		t.Fatalf("exit code %d, want %d", code, exitFound)
+	}
+	last, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(last, []byte(`"foo`)) {
+		t.Errorf("results file doesn't have the match:\n%s", last)
+	}
+
+	if code := run("foo("); code != exitError {
+		t.Fatalf("exit code %d for a bad pattern, want %d", code, exitError)
+	}
+	if got, err := os.ReadFile(out); err != nil || !bytes.Equal(got, last) {
+		t.Errorf("failed search changed the results file to %q (%v)", got, err)
+	}
+
+	if code := run("bar"); code != exitNoMatch {
+		t.Fatalf("exit code %d, want %d", code, exitNoMatch)
+	}
+	if got, err := os.ReadFile(out); err != nil || bytes.Equal(got, last) {
+		t.Errorf("search with nothing found didn't replace the results file (%v)", err)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	helpGroupStyle = lipgloss.NewStyle().
+			Foreground(special).
+			Bold(true).
+			MarginTop(1)
+
+	helpKeyStyle = lipgloss.NewStyle().
+			Foreground(highlight).
+			Bold(true).
+			Width(18).
+			PaddingLeft(2)
+
+	helpDescStyle = lipgloss.NewStyle()
+)
+
+// Everything the help tab keeps while you're on other tabs
+type helpState struct {
+	filter   textinput.Model
+	viewport viewport.Model
+}
+
+// The bindings that are active in one part of the UI
+type keyGroup struct {
+	name     string
+	bindings []key.Binding
+}
+
+// Every binding the app responds to, grouped by where it applies. The help
+// tab is generated from this, so new bindings need to be listed here.
+func (m model) keyGroups() []keyGroup {
+	k := m.keymap
+	return []keyGroup{
+		{"Global", []key.Binding{k.Search, k.Search2, k.Tab, k.Help, k.Clipboard, k.Sessions, k.Pause, k.LowPower, k.Pins, k.Repos, k.AuditLog, k.Lite, k.Peek, k.Quit}},
+		{"Search", []key.Binding{k.Enter, k.Live, k.Scopes, k.Remote, k.ClearExcludes, k.InputNext, k.InputPrev}},
+		{"Results", append([]key.Binding{k.Enter, k.Back, k.Yank, k.YankLoc, k.YankLine, k.YankAll, k.Permalink, k.Suggestion, k.Dismiss, k.Ignore, k.Undismiss, k.Exclude, k.ExcludeDir, k.Replace, k.Quickfix, k.Edit, k.Pager, k.TmuxOpen, k.ExportHTML, k.ExportSession, k.LoadSession, k.Export, k.Webhook, k.Pipe, k.RunOnFiles, k.Stats, k.Languages, k.ShowLine, k.ScrollLeft, k.ScrollRight, k.Expand, k.Minimap, k.MinimapNext, k.MinimapPrev, k.Mark, k.MarkAll, k.Compare, k.Labels, k.JumpFile, k.NextFile, k.PrevFile, k.Pin, k.Paths, k.Narrow, k.Sidebar, k.Preview}, listBindings(m.resultsState.list.keys)...)},
+		{"File Sidebar", []key.Binding{k.Sidebar, withHelp(k.Enter, "jump to file"), k.SidebarSort, withHelp(k.Back, "back to results"), k.Help, k.Quit}},
+		{"Result Filter", []key.Binding{withHelp(k.Enter, "keep filter"), withHelp(k.Back, "clear filter")}},
+		{"File View", append([]key.Binding{k.Back, k.NextHit, k.PrevHit, k.Find, k.GotoLine, k.Top, k.Bottom, k.Wrap, k.Hex, k.Follow, k.Directory, k.Select, k.Reload, k.Blame, k.Definition, k.TagBack, k.SearchWord, k.Edit, k.Pager, k.TmuxOpen, k.FileLeft, k.FileRight}, viewportBindings(m.fileState.viewer.KeyMap)...)},
+		{"Clipboard History", []key.Binding{k.Enter, k.Paste, k.Back}},
+		{"Pins", []key.Binding{withHelp(k.Enter, "open in file view"), k.Unpin, withHelp(k.Back, "close")}},
+		{"Directory", []key.Binding{withHelp(k.Enter, "open the file or directory"), withHelp(k.Directory, "up a directory"), withHelp(k.Back, "close")}},
+		{"Definitions", []key.Binding{withHelp(k.Enter, "go to the definition"), withHelp(k.Back, "close")}},
+		{"Repositories", []key.Binding{withHelp(k.Enter, "show only this repository"), withHelp(k.Back, "close")}},
+		{"Audit Log", []key.Binding{withHelp(k.Back, "close")}},
+		{"Comparison", append([]key.Binding{withHelp(k.Back, "close")}, viewportBindings(m.fileState.viewer.KeyMap)...)},
+		{"Line Selection", append([]key.Binding{withHelp(k.Yank, "copy the lines"), withHelp(k.SearchWord, "search for the line"), withHelp(k.Back, "cancel")}, viewportBindings(m.fileState.viewer.KeyMap)...)},
+		{"Help", []key.Binding{k.Back}},
+	}
+}
+
+// A copy of a binding described differently for a particular context
+func withHelp(binding key.Binding, desc string) key.Binding {
+	binding.SetHelp(binding.Help().Key, desc)
+	return binding
+}
+
+func listBindings(k list.KeyMap) []key.Binding {
+	return []key.Binding{
+		k.CursorUp, k.CursorDown, k.PrevPage, k.NextPage,
+		k.GoToStart, k.GoToEnd, k.Filter, k.ClearFilter, k.AcceptWhileFiltering,
+	}
+}
+
+func viewportBindings(k viewport.KeyMap) []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.PageUp, k.PageDown, k.HalfPageUp, k.HalfPageDown}
+}
+
+func newHelpFilter() textinput.Model {
+	helpFilter := textinput.New()
+	helpFilter.Placeholder = "Filter actions..."
+	helpFilter.Prompt = "❯ "
+	helpFilter.PromptStyle = searchPromptStyle
+	helpFilter.TextStyle = lipgloss.NewStyle().Foreground(highlight)
+	helpFilter.Cursor.Style = lipgloss.NewStyle().Foreground(special)
+	return helpFilter
+}
+
+// Open the help tab, remembering where to go back to
+func (m *model) openHelp() tea.Cmd {
+	if m.activeTab != helpTab {
+		m.previousTab = m.activeTab
+	}
+	m.activeTab = helpTab
+	// Rebuild the content in case bindings have changed, but keep the
+	// scroll position from last time
+	m.helpState.viewport.SetContent(m.renderHelp(m.helpState.filter.Value()))
+	return m.helpState.filter.Focus()
+}
+
+func (m *model) closeHelp() {
+	m.activeTab = m.previousTab
+	m.helpState.filter.Blur()
+	if m.activeTab == searchTab && !m.directoryInput.Focused() {
+		m.searchInput.Focus()
+	}
+}
+
+// Re-render the help content for the current filter
+func (m *model) refreshHelp() {
+	m.helpState.viewport.SetContent(m.renderHelp(m.helpState.filter.Value()))
+	m.helpState.viewport.GotoTop()
+}
+
+// List every binding whose keys, description or group match the filter
+func (m model) renderHelp(filter string) string {
+	filter = strings.ToLower(strings.TrimSpace(filter))
+
+	var b strings.Builder
+	for _, group := range m.keyGroups() {
+		groupMatches := strings.Contains(strings.ToLower(group.name), filter)
+
+		var rows []string
+		for _, binding := range group.bindings {
+			h := binding.Help()
+			if h.Key == "" {
+				continue
+			}
+			text := strings.ToLower(h.Key + " " + h.Desc)
+			if filter != "" && !groupMatches && !strings.Contains(text, filter) {
+				continue
+			}
+			rows = append(rows, helpKeyStyle.Render(h.Key)+helpDescStyle.Render(h.Desc))
+		}
+		if len(rows) == 0 {
+			continue
+		}
+
+		b.WriteString(helpGroupStyle.Render(group.name) + "\n")
+		b.WriteString(strings.Join(rows, "\n") + "\n")
+	}
+
+	if b.Len() == 0 {
+		return fmt.Sprintf("No actions match %q", filter)
+	}
+	return b.String()
+}
+
+func (m model) helpTabView() string {
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		inputStyle.Render(m.helpState.filter.View()),
+		m.helpState.viewport.View(),
+	)
+}
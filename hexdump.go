@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How the hex view lays out a file: bytes per row, and how much of a file
+// it shows at most
+const (
+	hexRowBytes = 16
+	hexLimit    = 16 << 20
+)
+
+// How much of the start of a file is looked at to tell if it's binary
+const binarySniff = 8000
+
+// Whether a file's content looks binary: like git and rg, a NUL byte near
+// the start gives it away
+func looksBinary(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), binarySniff)], 0) >= 0
+}
+
+// Whether the file at path looks binary, from its first few kilobytes
+func sniffBinary(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	head := make([]byte, binarySniff)
+	n, _ := io.ReadFull(file, head)
+	return looksBinary(head[:n])
+}
+
+// Where a result's match is in the file, in bytes: the start of its line
+// plus the match's offset in it
+func matchOffset(content []byte, item Item) (int, int, bool) {
+	if item.lineNum < 1 || len(item.lineMatches) == 0 {
+		return 0, 0, false
+	}
+	start := 0
+	for n := 1; n < item.lineNum; n++ {
+		i := bytes.IndexByte(content[start:], '\n')
+		if i < 0 {
+			return 0, 0, false
+		}
+		start += i + 1
+	}
+	span := item.lineMatches[0]
+	return start + span.start, start + span.end, true
+}
+
+// A hex dump of a binary file for the viewer: an offset, the row's bytes in
+// hex and the same bytes as text, with anything unprintable as a dot. The
+// result's match is highlighted in both, and the viewer opens on its row.
+func hexDocument(content []byte, item Item) fileLoadedMsg {
+	shown := content[:min(len(content), hexLimit)]
+	rows := (len(shown) + hexRowBytes - 1) / hexRowBytes
+	matchStart, matchEnd, found := matchOffset(content, item)
+
+	lines := make([]string, max(rows, 1))
+	if len(content) > len(shown) {
+		lines = append(lines, fmt.Sprintf("  … %s more bytes not shown", formatCount(len(content)-len(shown))))
+	}
+	doc := &fileDocument{lines: lines, gutter: 12}
+	doc.highlight = func(i int, line string) string {
+		if i >= rows {
+			return line
+		}
+		row := shown[i*hexRowBytes : min((i+1)*hexRowBytes, len(shown))]
+		first := i * hexRowBytes
+		in := func(j int) bool { return found && first+j >= matchStart && first+j < matchEnd }
+
+		marker := "  "
+		if found && matchStart/hexRowBytes == i {
+			marker = "→ "
+		}
+		var hex, text strings.Builder
+		for j := 0; j < hexRowBytes; j++ {
+			if j == hexRowBytes/2 {
+				hex.WriteByte(' ')
+			}
+			if j >= len(row) {
+				hex.WriteString("   ")
+				text.WriteByte(' ')
+				continue
+			}
+			cell, char := fmt.Sprintf("%02x", row[j]), "."
+			if row[j] >= 0x20 && row[j] < 0x7f {
+				char = string(row[j])
+			}
+			if in(j) {
+				cell, char = matchStyle.Render(cell), matchStyle.Render(char)
+			}
+			hex.WriteString(cell + " ")
+			text.WriteString(char)
+		}
+		return fmt.Sprintf("%s%08x  %s |%s|", marker, first, hex.String(), text.String())
+	}
+
+	focus := 0
+	if found {
+		focus = matchStart / hexRowBytes
+	}
+	return fileLoadedMsg{doc: doc, focus: focus, binary: true}
+}
+
+// Whether to show a file as hex: going by its content, unless it's been
+// switched with toggleHex
+type hexChoice int
+
+const (
+	hexAuto hexChoice = iota
+	hexOn
+	hexOff
+)
+
+func (m model) hexChoice(path string) hexChoice {
+	switch path {
+	case m.fileState.hexPath:
+		return hexOn
+	case m.fileState.textPath:
+		return hexOff
+	}
+	return hexAuto
+}
+
+func (c hexChoice) hex(path string) bool {
+	return c == hexOn || (c == hexAuto && sniffBinary(path))
+}
+
+// Load a file as a hex dump, if that's how it's to be shown
+func loadHex(item Item, choice hexChoice) (fileLoadedMsg, bool) {
+	if !choice.hex(item.fullPath) {
+		return fileLoadedMsg{}, false
+	}
+	content, err := os.ReadFile(item.fullPath)
+	if err != nil {
+		return fileLoadedMsg{err: err}, true
+	}
+	return hexDocument(content, item), true
+}
+
+// Switch the open file between the hex view and its text, for a binary file
+// that's mostly text or a text file with a stray NUL. The choice sticks
+// for the file until another is switched.
+func (m *model) toggleHex() tea.Cmd {
+	if m.fileState.doc == nil || m.review.stagedFile(m.fileState.item.fullPath) != nil {
+		return nil
+	}
+	path := m.fileState.item.fullPath
+	m.fileState.hexPath, m.fileState.textPath = "", ""
+	if m.fileState.binary {
+		m.fileState.textPath = path
+		m.statusMessage = "Showing the file as text"
+	} else {
+		m.fileState.hexPath = path
+		m.statusMessage = "Showing the file as hex"
+	}
+	m.statusMessageType = "info"
+	return m.openFile(m.fileState.item)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// How the file viewer highlights syntax: built in with chroma (the
+// default), with bat when it's installed, or not at all. The configured
+// previewer command takes over from all of them.
+const (
+	highlighterChroma  = "chroma"
+	highlighterBat     = "bat"
+	highlighterOff     = "off"
+	highlighterCommand = "command"
+)
+
+// The chroma style used unless the config names another
+const defaultTheme = "monokai"
+
+type highlighter struct {
+	backend string
+	theme   string
+	command string // the previewer, for the command backend
+}
+
+// Tokenize content with the lexer for path (or, failing that, one guessed
+// from the content) and return a function that renders a line of it, or
+// nil if the language isn't known. Lexing needs the whole file, but lines
+// are only formatted when they're rendered.
+func chromaLines(path, content, theme string) func(i int) (string, bool) {
+	lexer := lexers.Match(path)
+	if lexer == nil {
+		lexer = lexers.Analyse(content)
+	}
+	if lexer == nil {
+		return nil
+	}
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, content)
+	if err != nil {
+		return nil
+	}
+	lines := chroma.SplitTokensIntoLines(iterator.Tokens())
+	style := styles.Get(theme)
+
+	return func(i int) (string, bool) {
+		if i >= len(lines) {
+			return "", false
+		}
+		// The newline ending the line would end up inside the escapes
+		tokens := make([]chroma.Token, 0, len(lines[i]))
+		for _, token := range lines[i] {
+			token.Value = strings.TrimRight(token.Value, "\r\n")
+			if token.Value != "" {
+				tokens = append(tokens, token)
+			}
+		}
+		var b strings.Builder
+		if err := formatters.TTY256.Format(&b, style, chroma.Literator(tokens...)); err != nil {
+			return "", false
+		}
+		return b.String(), true
+	}
+}
+
+// Whether theme is one of chroma's styles
+func knownTheme(theme string) bool {
+	_, ok := styles.Registry[strings.ToLower(theme)]
+	return ok
+}
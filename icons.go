@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// How results show their file type: a Nerd Font icon, a short ASCII tag
+// for fonts without the icons, or nothing
+const (
+	iconsNerd  = "nerd"
+	iconsASCII = "ascii"
+	iconsOff   = "off"
+)
+
+var languageStyle = lipgloss.NewStyle().
+	Foreground(subtle)
+
+type fileType struct {
+	icon     string // Nerd Font
+	tag      string // ASCII
+	language string
+}
+
+// The generic file icon, for files of a type we don't know
+const fileIcon = "\uf15b"
+
+// Width the ASCII tags are padded to, so paths line up
+const tagWidth = 4
+
+// File types by extension
+var fileTypes = map[string]fileType{
+	".go":    {"\ue627", "go", "Go"},
+	".rs":    {"\ue7a8", "rs", "Rust"},
+	".py":    {"\ue73c", "py", "Python"},
+	".rb":    {"\ue739", "rb", "Ruby"},
+	".js":    {"\ue74e", "js", "JavaScript"},
+	".mjs":   {"\ue74e", "js", "JavaScript"},
+	".cjs":   {"\ue74e", "js", "JavaScript"},
+	".jsx":   {"\ue7ba", "jsx", "JavaScript"},
+	".ts":    {"\ue628", "ts", "TypeScript"},
+	".tsx":   {"\ue7ba", "tsx", "TypeScript"},
+	".java":  {"\ue738", "jav", "Java"},
+	".kt":    {"\ue634", "kt", "Kotlin"},
+	".swift": {"\ue755", "swf", "Swift"},
+	".c":     {"\ue61e", "c", "C"},
+	".h":     {"\ue61e", "h", "C"},
+	".cc":    {"\ue61d", "cpp", "C++"},
+	".cpp":   {"\ue61d", "cpp", "C++"},
+	".hpp":   {"\ue61d", "hpp", "C++"},
+	".cs":    {"\U000f031b", "cs", "C#"},
+	".php":   {"\ue73d", "php", "PHP"},
+	".lua":   {"\ue620", "lua", "Lua"},
+	".sh":    {"\ue795", "sh", "Shell"},
+	".bash":  {"\ue795", "sh", "Shell"},
+	".zsh":   {"\ue795", "sh", "Shell"},
+	".html":  {"\ue736", "htm", "HTML"},
+	".css":   {"\ue749", "css", "CSS"},
+	".scss":  {"\ue749", "css", "SCSS"},
+	".json":  {"\ue60b", "jsn", "JSON"},
+	".yaml":  {"\ue6a8", "yml", "YAML"},
+	".yml":   {"\ue6a8", "yml", "YAML"},
+	".toml":  {"\ue6b2", "tml", "TOML"},
+	".xml":   {"\U000f05c0", "xml", "XML"},
+	".md":    {"\ue73e", "md", "Markdown"},
+	".sql":   {"\ue706", "sql", "SQL"},
+	".proto": {"\ue6a0", "pb", "Protobuf"},
+	".vim":   {"\ue62b", "vim", "Vim script"},
+	".ex":    {"\ue62d", "ex", "Elixir"},
+	".exs":   {"\ue62d", "ex", "Elixir"},
+	".hs":    {"\ue777", "hs", "Haskell"},
+	".scala": {"\ue737", "sc", "Scala"},
+	".zig":   {"\ue6a9", "zig", "Zig"},
+	".tf":    {"\ue69a", "tf", "Terraform"},
+	".txt":   {"\uf15c", "txt", "Text"},
+}
+
+// File types by name, for files without a telling extension
+var fileTypesByName = map[string]fileType{
+	"Makefile":   {"\ue673", "mk", "Makefile"},
+	"Dockerfile": {"\ue7b0", "dkr", "Dockerfile"},
+	"go.mod":     {"\ue627", "go", "Go module"},
+	"go.sum":     {"\ue627", "go", "Go checksums"},
+}
+
+func detectFileType(path string) (fileType, bool) {
+	name := filepath.Base(path)
+	if t, ok := fileTypesByName[name]; ok {
+		return t, true
+	}
+	t, ok := fileTypes[strings.ToLower(filepath.Ext(name))]
+	return t, ok
+}
+
+// The icon or tag shown before a result's path, with the space after it
+func fileIconView(icons, path string) string {
+	t, ok := detectFileType(path)
+	switch icons {
+	case iconsNerd:
+		if !ok {
+			return fileIcon + " "
+		}
+		return t.icon + " "
+	case iconsASCII:
+		return fmt.Sprintf("%-*s", tagWidth, t.tag)
+	}
+	return ""
+}
+
+// The language shown after a result's location, with the space before it
+func languageView(icons, path string) string {
+	if icons == iconsOff {
+		return ""
+	}
+	t, ok := detectFileType(path)
+	if !ok {
+		return ""
+	}
+	return "  " + t.language
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// How far a false positive's line can move (from lines added or removed
+// above it) and still be recognised
+const ignoreDrift = 100
+
+// A line marked as a false positive for one search: whenever the same
+// pattern is searched for in the same directory again, a match on a line
+// with the same content near the same place in the file is left out.
+type ignoreRule struct {
+	Pattern string    `json:"pattern"`
+	Root    string    `json:"root"`
+	Path    string    `json:"path"`
+	Line    int       `json:"line"`
+	Hash    string    `json:"hash"`    // of the line with its whitespace collapsed
+	Content string    `json:"content"` // the line, for reading the file by hand
+	Added   time.Time `json:"added"`
+}
+
+// The hash a line is recognised by. Whitespace is collapsed first, so
+// reindenting or reformatting the line doesn't bring it back.
+func lineHash(content string) string {
+	sum := sha256.Sum256([]byte(strings.Join(strings.Fields(content), " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+func (r ignoreRule) matches(pattern, root string, item Item) bool {
+	return r.Pattern == pattern && r.Root == root && r.Path == absPath(item.fullPath) &&
+		max(r.Line-item.lineNum, item.lineNum-r.Line) <= ignoreDrift && r.Hash == lineHash(item.content)
+}
+
+func ignoreRulesPath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ignored.json"), nil
+}
+
+func loadIgnoreRules() ([]ignoreRule, error) {
+	path, err := ignoreRulesPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var rules []ignoreRule
+	return rules, json.Unmarshal(data, &rules)
+}
+
+// Write the rules out whole, through a temporary file so a crash can't
+// leave them half written
+func saveIgnoreRules(rules []ignoreRule) error {
+	path, err := ignoreRulesPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(rules, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Whether a result is a known false positive of the current search
+func (m model) ignoredResult(item Item) bool {
+	if len(m.ignoreRules) == 0 || item.remote != nil {
+		return false
+	}
+	root := absPath(m.paths.root)
+	for _, rule := range m.ignoreRules {
+		if rule.matches(m.currentSearchPattern, root, item) {
+			return true
+		}
+	}
+	return false
+}
+
+// Mark the selected result's line as a false positive of this search for
+// good: it's dismissed now, and left out whenever the search is run again
+// until the line changes
+func (m *model) ignoreSelected() {
+	item, ok := m.resultsState.list.selected()
+	if !ok || item.remote != nil {
+		return
+	}
+	rule := ignoreRule{
+		Pattern: m.currentSearchPattern,
+		Root:    absPath(m.paths.root),
+		Path:    absPath(item.fullPath),
+		Line:    item.lineNum,
+		Hash:    lineHash(item.content),
+		Content: item.content,
+		Added:   time.Now(),
+	}
+	m.ignoreRules = append(m.ignoreRules, rule)
+	if err := saveIgnoreRules(m.ignoreRules); err != nil {
+		m.ignoreRules = m.ignoreRules[:len(m.ignoreRules)-1]
+		m.statusMessage = fmt.Sprintf("Error saving false positives: %s", err)
+		m.statusMessageType = "error"
+		return
+	}
+
+	m.dismissSelected()
+	// The rule keeps it out from now on
+	delete(m.dismissed, item.dismissKey())
+	if n := len(m.dismissals); n > 0 && m.dismissals[n-1].item.key() == item.key() {
+		m.dismissals[n-1].rule = &rule
+	}
+	m.statusMessage = fmt.Sprintf("%s:%d is a false positive of %q from now on (%s to undo)", m.paths.show(item.fileName), item.lineNum, rule.Pattern, m.keymap.Undismiss.Help().Key)
+	m.statusMessageType = "info"
+}
+
+// Forget a false positive, when its dismissal is undone
+func (m *model) unignore(rule *ignoreRule) {
+	for i, r := range m.ignoreRules {
+		if r == *rule {
+			m.ignoreRules = append(m.ignoreRules[:i], m.ignoreRules[i+1:]...)
+			break
+		}
+	}
+	if err := saveIgnoreRules(m.ignoreRules); err != nil {
+		m.statusMessage = fmt.Sprintf("Error saving false positives: %s", err)
+		m.statusMessageType = "error"
+	}
+}
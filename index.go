@@ -0,0 +1,466 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How the background indexer paces itself. It only works while no search
+// is running and low-power mode is off, a step of a few files at a time.
+const (
+	indexIdleDelay  = 2 * time.Second        // between checks for work
+	indexStepDelay  = 50 * time.Millisecond  // between steps while there's work
+	indexStepBudget = 200 * time.Millisecond // files aren't started after this
+	indexRelist     = 10 * time.Minute       // how often new and removed files are looked for
+)
+
+// What's kept per file: a coarse filter of the trigrams in it and where
+// every indexLineStride-th line starts
+const (
+	indexFilterBytes = 512
+	indexLineStride  = 1024
+)
+
+// Searches that would still cover more files than this run over the
+// directory as usual
+const maxIndexCandidates = 5000
+
+type indexedFile struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	Filter  []byte    `json:"filter"` // a bit per hashed trigram of the lowercased content
+	Lines   []int64   `json:"lines"`  // offsets of lines 1, 1+indexLineStride, 1+2*indexLineStride…
+}
+
+// Whether the file is as it was when it was indexed
+func (f *indexedFile) fresh(info fs.FileInfo) bool {
+	return f.Size == info.Size() && f.ModTime.Equal(info.ModTime())
+}
+
+func trigramBit(a, b, c byte) uint32 {
+	h := (uint32(a)<<16 | uint32(b)<<8 | uint32(c)) * 2654435761
+	return h >> 20 % (indexFilterBytes * 8)
+}
+
+func lowerByte(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+// Whether the file might contain text: false means it can't
+func (f *indexedFile) mayContain(text string) bool {
+	for i := 0; i+3 <= len(text); i++ {
+		bit := trigramBit(lowerByte(text[i]), lowerByte(text[i+1]), lowerByte(text[i+2]))
+		if f.Filter[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Read a file through once for its index entry
+func indexFile(path string) (*indexedFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &indexedFile{Size: info.Size(), ModTime: info.ModTime(), Filter: make([]byte, indexFilterBytes), Lines: []int64{0}}
+	reader := bufio.NewReaderSize(file, 64*1024)
+	var a, b byte
+	var offset int64
+	lines := 1
+	for {
+		c, err := reader.ReadByte()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		offset++
+		if c == '\n' {
+			if lines%indexLineStride == 0 {
+				entry.Lines = append(entry.Lines, offset)
+			}
+			lines++
+		}
+		c = lowerByte(c)
+		if offset >= 3 {
+			bit := trigramBit(a, b, c)
+			entry.Filter[bit/8] |= 1 << (bit % 8)
+		}
+		a, b = b, c
+	}
+	return entry, nil
+}
+
+// An index of one directory that's searched often enough to be worth it,
+// set up with "index" in the config. It's saved in the state directory and
+// kept up to date while lazyrg is idle.
+type corpusIndex struct {
+	Root   string                  `json:"root"`
+	Listed time.Time               `json:"listed"` // when the files were last listed
+	Files  map[string]*indexedFile `json:"files"`  // by absolute path
+
+	mu      sync.RWMutex // searches read Files in the background
+	pending []string     // files that are new or have changed, to index next
+	busy    bool         // a listing or a step is running
+}
+
+func indexPath(root string) (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(root))
+	return filepath.Join(dir, "index", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// The indexes of the configured directories, as they were last saved
+func loadIndexes(roots []string) []*corpusIndex {
+	var indexes []*corpusIndex
+	for _, root := range roots {
+		if rest, ok := strings.CutPrefix(root, "~/"); ok {
+			if home, err := os.UserHomeDir(); err == nil {
+				root = filepath.Join(home, rest)
+			}
+		}
+		root = absPath(root)
+		idx := &corpusIndex{Root: root, Files: map[string]*indexedFile{}}
+		if path, err := indexPath(root); err == nil {
+			if data, err := os.ReadFile(path); err == nil {
+				if err := json.Unmarshal(data, idx); err != nil || idx.Root != root {
+					idx = &corpusIndex{Root: root, Files: map[string]*indexedFile{}}
+				}
+			}
+		}
+		indexes = append(indexes, idx)
+	}
+	return indexes
+}
+
+// Write the index out whole, through a temporary file so a crash can't
+// leave it half written
+func (idx *corpusIndex) save() error {
+	path, err := indexPath(idx.Root)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	idx.mu.RLock()
+	data, err := json.Marshal(idx)
+	idx.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// The index entry for a file, if it's been indexed as it is now
+func (idx *corpusIndex) entry(path string) *indexedFile {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.Files[absPath(path)]
+}
+
+// Whether a path is in the indexed directory
+func (idx *corpusIndex) covers(path string) bool {
+	path = absPath(path)
+	return path == idx.Root || strings.HasPrefix(path, idx.Root+string(filepath.Separator))
+}
+
+type indexTickMsg struct{}
+
+type indexListedMsg struct {
+	idx     *corpusIndex
+	pending []string
+	err     error
+}
+
+type indexSteppedMsg struct {
+	idx   *corpusIndex
+	files map[string]*indexedFile
+	done  int // how many of the pending files the step got through
+}
+
+type indexSavedMsg struct {
+	idx *corpusIndex
+	err error
+}
+
+func indexTick(delay time.Duration) tea.Cmd {
+	return tea.Tick(delay, func(time.Time) tea.Msg { return indexTickMsg{} })
+}
+
+func (m model) watchIndex() tea.Cmd {
+	if len(m.indexes) == 0 {
+		return nil
+	}
+	return indexTick(indexIdleDelay)
+}
+
+// Do the next bit of indexing, if lazyrg is idle and there's any to do
+func (m *model) handleIndexTick() tea.Cmd {
+	if m.search != nil || m.lowPower {
+		return indexTick(indexIdleDelay)
+	}
+	for _, idx := range m.indexes {
+		if idx.busy {
+			return nil
+		}
+		if len(idx.pending) > 0 {
+			idx.busy = true
+			return stepIndex(idx, idx.pending)
+		}
+	}
+	for _, idx := range m.indexes {
+		if time.Since(idx.Listed) > indexRelist {
+			idx.busy = true
+			return listIndex(idx, m.sandbox)
+		}
+	}
+	return indexTick(indexIdleDelay)
+}
+
+// List the files rg would search in the directory, working out which are
+// new or have changed since they were indexed and dropping the ones that
+// have gone
+func listIndex(idx *corpusIndex, sandbox bool) tea.Cmd {
+	return func() tea.Msg {
+		files, err := listFiles(nil, []string{idx.Root}, sandbox)
+		if err != nil {
+			return indexListedMsg{idx: idx, err: err}
+		}
+		present := make(map[string]bool, len(files))
+		var pending []string
+		idx.mu.RLock()
+		for _, file := range files {
+			file = absPath(file)
+			present[file] = true
+			info, err := os.Stat(file)
+			if err != nil {
+				continue
+			}
+			if entry := idx.Files[file]; entry == nil || !entry.fresh(info) {
+				pending = append(pending, file)
+			}
+		}
+		idx.mu.RUnlock()
+
+		idx.mu.Lock()
+		for file := range idx.Files {
+			if !present[file] {
+				delete(idx.Files, file)
+			}
+		}
+		idx.mu.Unlock()
+		return indexListedMsg{idx: idx, pending: pending}
+	}
+}
+
+// Index files from the front of pending until the step's time is up
+func stepIndex(idx *corpusIndex, pending []string) tea.Cmd {
+	return func() tea.Msg {
+		start := time.Now()
+		files := map[string]*indexedFile{}
+		done := 0
+		for _, file := range pending {
+			if time.Since(start) > indexStepBudget {
+				break
+			}
+			if entry, err := indexFile(file); err == nil {
+				files[file] = entry
+			}
+			done++
+		}
+		return indexSteppedMsg{idx: idx, files: files, done: done}
+	}
+}
+
+func saveIndex(idx *corpusIndex) tea.Cmd {
+	return func() tea.Msg {
+		return indexSavedMsg{idx: idx, err: idx.save()}
+	}
+}
+
+func (m *model) handleIndexListed(msg indexListedMsg) tea.Cmd {
+	msg.idx.busy = false
+	msg.idx.Listed = time.Now()
+	if msg.err != nil {
+		m.statusMessage = fmt.Sprintf("Error indexing %s: %s", m.paths.show(msg.idx.Root), msg.err)
+		m.statusMessageType = "error"
+		return indexTick(indexIdleDelay)
+	}
+	msg.idx.pending = msg.pending
+	return indexTick(indexStepDelay)
+}
+
+func (m *model) handleIndexStepped(msg indexSteppedMsg) tea.Cmd {
+	idx := msg.idx
+	idx.busy = false
+	idx.mu.Lock()
+	for file, entry := range msg.files {
+		idx.Files[file] = entry
+	}
+	idx.mu.Unlock()
+	idx.pending = idx.pending[min(msg.done, len(idx.pending)):]
+	if len(idx.pending) == 0 {
+		return tea.Batch(saveIndex(idx), indexTick(indexIdleDelay))
+	}
+	return indexTick(indexStepDelay)
+}
+
+// The index covering everything a search of paths would, if there is one
+func (m model) indexFor(paths []string) *corpusIndex {
+	if len(paths) != 1 {
+		return nil
+	}
+	for _, idx := range m.indexes {
+		if idx.covers(paths[0]) {
+			return idx
+		}
+	}
+	return nil
+}
+
+// The index entry for a file, if one of the indexes has it
+func (m model) indexedFile(path string) *indexedFile {
+	for _, idx := range m.indexes {
+		if idx.covers(path) {
+			return idx.entry(path)
+		}
+	}
+	return nil
+}
+
+// The files rg would search under paths with flags, one per line from
+// rg --files
+func listFiles(flags []string, paths []string, sandbox bool) ([]string, error) {
+	cmd, err := rgCommand(context.Background(), append([]string{"--files"}, flags...), paths, sandbox)
+	if err != nil {
+		return nil, err
+	}
+	output, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		// No files
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSuffix(string(output), "\n"), "\n"), nil
+}
+
+// How an index narrowed a search down
+type indexUse struct {
+	files    int // that the search would have covered
+	searched int // that could match, so were searched
+	stale    int // of those, the ones that were new or had changed since they were indexed
+}
+
+// Search a directory the index covers: only the files that could contain
+// a literal pattern, going by their trigrams, along with any that have
+// changed since they were indexed, are handed to rg. Anything the index
+// can't help with is searched as usual.
+func searchIndexed(idx *corpusIndex, id int, pattern string, flags []string, paths []string, sandbox bool) tea.Cmd {
+	search := executeRipgrep(id, pattern, flags, paths, sandbox)
+	if len(pattern) < 3 || regexp.QuoteMeta(pattern) != pattern {
+		return search
+	}
+	return func() tea.Msg {
+		files, err := listFiles(flags, paths, sandbox)
+		if err != nil {
+			return search()
+		}
+		use := indexUse{files: len(files)}
+		var candidates []string
+		idx.mu.RLock()
+		for _, file := range files {
+			entry := idx.Files[absPath(file)]
+			info, err := os.Stat(file)
+			switch {
+			case entry == nil || err != nil || !entry.fresh(info):
+				use.stale++
+			case !entry.mayContain(pattern):
+				continue
+			}
+			candidates = append(candidates, file)
+		}
+		idx.mu.RUnlock()
+		use.searched = len(candidates)
+
+		if len(candidates) > maxIndexCandidates {
+			return search()
+		}
+		if len(candidates) == 0 {
+			return searchFinishedMsg{id: id, index: &use}
+		}
+		msg := executeRipgrep(id, pattern, flags, candidates, sandbox)()
+		if started, ok := msg.(searchStartedMsg); ok {
+			started.stream.index = &use
+		}
+		return msg
+	}
+}
+
+// A note for the result count on how the index helped, and how much of
+// the search it couldn't vouch for
+func (u indexUse) note() string {
+	note := fmt.Sprintf(" · index: searched %s of %s files", formatCount(u.searched), formatCount(u.files))
+	if u.stale > 0 {
+		note += fmt.Sprintf(", %s new or changed since indexing", formatCount(u.stale))
+	}
+	return note
+}
+
+// What's indexed of the directory being searched, for the Search tab
+func (m model) indexView() string {
+	searchPaths, _ := m.searchPaths()
+	idx := m.indexFor(searchPaths)
+	if idx == nil {
+		return ""
+	}
+	idx.mu.RLock()
+	files := len(idx.Files)
+	idx.mu.RUnlock()
+	switch {
+	case idx.Listed.IsZero():
+		return "🗂  Index: not built yet (it's built while lazyrg is idle)"
+	case len(idx.pending) > 0:
+		return fmt.Sprintf("🗂  Index: %s files, %s to go", formatCount(files), formatCount(len(idx.pending)))
+	}
+	age := formatAge(idx.Listed)
+	if age != "now" {
+		age += " ago"
+	}
+	return fmt.Sprintf("🗂  Index: %s files, checked for changes %s", formatCount(files), age)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var jumpHintStyle = lipgloss.NewStyle().
+	Foreground(subtle)
+
+func newJumpInput() textinput.Model {
+	jumpInput := textinput.New()
+	jumpInput.Placeholder = "file name or path, fuzzy"
+	jumpInput.Prompt = "Jump ❯ "
+	jumpInput.PromptStyle = searchPromptStyle
+	jumpInput.TextStyle = lipgloss.NewStyle().Foreground(highlight)
+	jumpInput.Cursor.Style = lipgloss.NewStyle().Foreground(special)
+	return jumpInput
+}
+
+// How well query fuzzy-matches path, if it does at all. Matches in the file
+// name beat matches spread over the directories, and runs of consecutive
+// characters and matches at the start of a word score higher.
+func fuzzyScore(query, path string) (int, bool) {
+	query = strings.ToLower(query)
+	if score, ok := subsequenceScore(query, strings.ToLower(filepath.Base(path))); ok {
+		return 1000 + score - len(path), true
+	}
+	if score, ok := subsequenceScore(query, strings.ToLower(path)); ok {
+		return score - len(path), true
+	}
+	return 0, false
+}
+
+func subsequenceScore(query, s string) (int, bool) {
+	score, pos, last := 0, 0, -2
+	for _, c := range query {
+		i := strings.IndexRune(s[pos:], c)
+		if i < 0 {
+			return 0, false
+		}
+		i += pos
+		score++
+		if i == last+1 {
+			score += 3
+		}
+		if i == 0 || strings.ContainsRune("/._-", rune(s[i-1])) {
+			score += 2
+		}
+		last = i
+		pos = i + len(string(c))
+	}
+	return score, true
+}
+
+// Open the jump prompt
+func (m *model) openJump() tea.Cmd {
+	s := &m.resultsState
+	s.jumping = true
+	s.jumpFrom = s.list.index()
+	s.jumpMatches = nil
+	s.jumpChoice = 0
+	s.jumpInput.SetValue("")
+	m.layoutResults()
+	return s.jumpInput.Focus()
+}
+
+func (m *model) closeJump() {
+	m.resultsState.jumping = false
+	m.resultsState.jumpInput.Blur()
+	m.layoutResults()
+}
+
+// Rank the matched files against what's been typed and go to the best one
+func (m *model) matchJump() {
+	s := &m.resultsState
+	s.minimap.update(m.results, s.filter)
+	files := s.minimap.files
+	query := s.jumpInput.Value()
+
+	s.jumpMatches = s.jumpMatches[:0]
+	scores := map[int]int{}
+	if query != "" {
+		for i, file := range files {
+			if score, ok := fuzzyScore(query, m.paths.show(file.path)); ok {
+				s.jumpMatches = append(s.jumpMatches, i)
+				scores[i] = score
+			}
+		}
+	}
+	sort.SliceStable(s.jumpMatches, func(a, b int) bool {
+		return scores[s.jumpMatches[a]] > scores[s.jumpMatches[b]]
+	})
+	s.jumpChoice = 0
+	m.jumpToChoice()
+}
+
+func (m *model) jumpToChoice() {
+	s := &m.resultsState
+	if len(s.jumpMatches) == 0 {
+		return
+	}
+	m.selectResult(s.minimap.files[s.jumpMatches[s.jumpChoice]].first)
+}
+
+// Handle keys while the jump prompt has focus. The cursor follows the best
+// match as you type; tab and shift+tab go through the other matches.
+func (m model) updateJump(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := &m.resultsState
+	switch {
+	case msg.String() == "ctrl+c":
+		return m, tea.Quit
+
+	case key.Matches(msg, m.keymap.Enter):
+		m.closeJump()
+		return m, m.updatePreview()
+
+	case key.Matches(msg, m.keymap.Back):
+		m.closeJump()
+		s.list.selectIndex(s.jumpFrom)
+		m.followSidebar()
+		return m, m.updatePreview()
+
+	case key.Matches(msg, m.keymap.InputNext), msg.String() == "down":
+		if n := len(s.jumpMatches); n > 0 {
+			s.jumpChoice = (s.jumpChoice + 1) % n
+			m.jumpToChoice()
+		}
+		return m, m.updatePreview()
+
+	case key.Matches(msg, m.keymap.InputPrev), msg.String() == "up":
+		if n := len(s.jumpMatches); n > 0 {
+			s.jumpChoice = (s.jumpChoice + n - 1) % n
+			m.jumpToChoice()
+		}
+		return m, m.updatePreview()
+	}
+
+	before := s.jumpInput.Value()
+	var cmd tea.Cmd
+	s.jumpInput, cmd = s.jumpInput.Update(msg)
+	if s.jumpInput.Value() != before {
+		m.matchJump()
+	}
+	return m, tea.Batch(cmd, m.updatePreview())
+}
+
+func (m model) jumpView() string {
+	s := m.resultsState
+	view := s.jumpInput.View()
+	switch {
+	case s.jumpInput.Value() == "":
+	case len(s.jumpMatches) == 0:
+		view += "  " + resultFilterErrorStyle.Render("no matching files")
+	default:
+		file := s.minimap.files[s.jumpMatches[s.jumpChoice]]
+		view += fmt.Sprintf("  → %s  %s", m.paths.show(file.path),
+			jumpHintStyle.Render(fmt.Sprintf("(%d of %d, tab for the next)", s.jumpChoice+1, len(s.jumpMatches))))
+	}
+	return resultFilterStyle.Render(view) + "\n"
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Letters the labels are made of, home row first
+const labelAlphabet = "asdfghjklqwertyuiopzxcvbnm"
+
+var (
+	labelStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFFFFF")).
+			Background(lipgloss.Color("#FF5F87")).
+			Bold(true)
+	labelTypedStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFFFFF")).
+			Background(highlight)
+)
+
+// Two-letter labels on the results on screen, shared by the model and the
+// delegate. Typing a label selects its result.
+type resultLabels struct {
+	show  bool
+	typed string
+}
+
+// The label of the result at position i of the count on the page. Only as
+// many letters are used as it takes to label them all, so both letters
+// narrow things down.
+func resultLabel(i, count int) string {
+	n := 1
+	for n*n < count && n < len(labelAlphabet) {
+		n++
+	}
+	return string(labelAlphabet[i/n%n]) + string(labelAlphabet[i%n])
+}
+
+// The label to draw before a result, with the letters typed so far dimmed,
+// or nothing if it no longer fits what's been typed
+func (l *resultLabels) view(i, count int) string {
+	label := resultLabel(i, count)
+	if !l.show || !strings.HasPrefix(label, l.typed) {
+		return ""
+	}
+	return labelTypedStyle.Render(l.typed) + labelStyle.Render(label[len(l.typed):]) + " "
+}
+
+func (m *model) showLabels() {
+	if m.resultsState.list.count() == 0 {
+		return
+	}
+	m.resultsState.labels.show = true
+	m.resultsState.labels.typed = ""
+	m.statusMessage = "Type a label to jump to its result, esc to cancel"
+	m.statusMessageType = "info"
+}
+
+func (m *model) hideLabels() {
+	m.resultsState.labels.show = false
+	m.resultsState.labels.typed = ""
+}
+
+// Handle keys while the labels are up. Any key that isn't part of a label
+// puts them away.
+func (m model) updateLabels(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	labels := m.resultsState.labels
+	typed := msg.String()
+	if len(typed) != 1 || !strings.Contains(labelAlphabet, typed) {
+		m.hideLabels()
+		m.statusMessage = ""
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		return m, nil
+	}
+
+	labels.typed += typed
+	typed = labels.typed
+	l := &m.resultsState.list
+	start, end := l.paginator.GetSliceBounds(l.count())
+	for i := start; i < end; i++ {
+		label := resultLabel(i-start, end-start)
+		if label == typed {
+			m.hideLabels()
+			l.selectIndex(i)
+			m.followSidebar()
+			m.statusMessage = ""
+			return m, m.updatePreview()
+		}
+		if strings.HasPrefix(label, typed) {
+			return m, nil
+		}
+	}
+
+	m.hideLabels()
+	m.statusMessage = fmt.Sprintf("No result is labeled %q", typed)
+	m.statusMessageType = "error"
+	return m, nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Files of a type we don't know are counted together
+const otherLanguage = "Other"
+
+// One language's share of the results
+type languageSummary struct {
+	language string
+	matches  int
+	files    int
+}
+
+func (l languageSummary) Title() string { return fmt.Sprintf("%6d  %s", l.matches, l.language) }
+
+func (l languageSummary) Description() string {
+	if l.files == 1 {
+		return "        1 file"
+	}
+	return fmt.Sprintf("        %d files", l.files)
+}
+
+func (l languageSummary) FilterValue() string { return l.language }
+
+// The language a file is written in, going by its name
+func languageOf(path string) string {
+	if t, ok := detectFileType(path); ok {
+		return t.language
+	}
+	return otherLanguage
+}
+
+func newLanguageList() list.Model {
+	languageList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	languageList.Title = "Languages"
+	languageList.SetShowHelp(false)
+	languageList.SetStatusBarItemName("language", "languages")
+	languageList.Styles.Title = lipgloss.NewStyle().
+		Foreground(special).
+		Bold(true).
+		MarginLeft(2)
+	return languageList
+}
+
+// Open the per-language match and file counts for the current results,
+// most matches first
+func (m *model) openLanguages() tea.Cmd {
+	if len(m.results) == 0 {
+		m.statusMessage = "No results to group"
+		m.statusMessageType = "error"
+		return nil
+	}
+
+	summaries := map[string]*languageSummary{}
+	lastFile, language := "", ""
+	for _, result := range m.results {
+		if result.fullPath != lastFile {
+			language = languageOf(result.fullPath)
+			if summaries[language] == nil {
+				summaries[language] = &languageSummary{language: language}
+			}
+			summaries[language].files++
+			lastFile = result.fullPath
+		}
+		summaries[language].matches++
+	}
+
+	items := make([]list.Item, 0, len(summaries))
+	for _, summary := range summaries {
+		items = append(items, *summary)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i].(languageSummary), items[j].(languageSummary)
+		if a.matches != b.matches {
+			return a.matches > b.matches
+		}
+		return a.language < b.language
+	})
+	m.showLanguages = true
+	return m.languageList.SetItems(items)
+}
+
+// Narrow the results to one language, with the result filter so it shows
+// and clears like any other
+func (m *model) drillIntoLanguage(language string) tea.Cmd {
+	pattern := languageFilterPrefix + language
+	filter, err := parseResultFilter(pattern)
+	if err != nil {
+		return nil
+	}
+	m.resultsState.filterInput.SetValue(pattern)
+	m.resultsState.filter = filter
+	m.resultsState.filterErr = nil
+	m.activeTab = resultsTab
+	m.layoutResults()
+	m.refreshResults()
+	m.reportResultCount()
+	return m.updatePreview()
+}
+
+// Handle keys while the language counts are open
+func (m model) updateLanguages(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.languageList.SettingFilter() {
+		switch {
+		case key.Matches(msg, m.keymap.Quit):
+			return m, tea.Quit
+
+		case key.Matches(msg, m.keymap.Back) || key.Matches(msg, m.keymap.Languages):
+			m.showLanguages = false
+			return m, nil
+
+		case key.Matches(msg, m.keymap.Enter):
+			language, ok := m.languageList.SelectedItem().(languageSummary)
+			if !ok {
+				return m, nil
+			}
+			m.showLanguages = false
+			return m, m.drillIntoLanguage(language.language)
+		}
+	}
+
+	var cmd tea.Cmd
+	m.languageList, cmd = m.languageList.Update(msg)
+	return m, cmd
+}
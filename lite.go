@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Frame write times that switch lite rendering on and back off again
+const (
+	slowFrameThreshold = 40 * time.Millisecond
+	fastFrameThreshold = 10 * time.Millisecond
+	latencyCheckPeriod = 2 * time.Second
+)
+
+// Styles used in lite mode: no borders and plain tabs, so each frame is
+// cheaper to draw over a slow link
+var (
+	liteDocStyle = lipgloss.NewStyle().
+			Margin(1, 2).
+			Padding(1, 2)
+
+	liteActiveTabStyle = lipgloss.NewStyle().
+				Foreground(highlight).
+				Bold(true).
+				Underline(true).
+				MarginRight(4)
+
+	liteInactiveTabStyle = lipgloss.NewStyle().
+				Foreground(subtle).
+				MarginRight(4)
+
+	liteFileViewerStyle = lipgloss.NewStyle().
+				Padding(0, 1)
+)
+
+// The program's output, with a moving average of how long each write takes.
+// The renderer writes a frame per call, so a terminal that can't keep up
+// (often one at the far end of an SSH connection) shows up as slow writes.
+type renderMonitor struct {
+	*os.File
+	mu  sync.Mutex
+	avg time.Duration
+}
+
+func newRenderMonitor(out *os.File) *renderMonitor {
+	return &renderMonitor{File: out}
+}
+
+func (r *renderMonitor) Write(p []byte) (int, error) {
+	start := time.Now()
+	n, err := r.File.Write(p)
+	elapsed := time.Since(start)
+
+	r.mu.Lock()
+	if r.avg == 0 {
+		r.avg = elapsed
+	} else {
+		r.avg = (r.avg*7 + elapsed) / 8
+	}
+	r.mu.Unlock()
+	return n, err
+}
+
+func (r *renderMonitor) frameTime() time.Duration {
+	if r == nil {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.avg
+}
+
+type latencyCheckMsg struct{}
+
+func checkLatency() tea.Cmd {
+	return tea.Tick(latencyCheckPeriod, func(time.Time) tea.Msg {
+		return latencyCheckMsg{}
+	})
+}
+
+// Switch lite rendering on or off automatically based on measured frame
+// times, unless it has been toggled by hand
+func (m *model) adjustForLatency() tea.Cmd {
+	if m.liteOverride {
+		return nil
+	}
+	frame := m.renderMonitor.frameTime()
+	switch {
+	case !m.lite && frame > slowFrameThreshold:
+		m.statusMessage = fmt.Sprintf("Slow terminal detected (%s per frame): using lite rendering, ctrl+l to toggle", frame.Round(time.Millisecond))
+		m.statusMessageType = "info"
+		return m.setLite(true)
+	case m.lite && frame < fastFrameThreshold:
+		m.statusMessage = "Terminal has caught up: back to full rendering"
+		m.statusMessageType = "info"
+		return m.setLite(false)
+	}
+	return nil
+}
+
+// Toggle lite rendering by hand, which also stops it being switched
+// automatically for the rest of the session
+func (m *model) toggleLite() tea.Cmd {
+	m.liteOverride = true
+	cmd := m.setLite(!m.lite)
+	if m.lite {
+		m.statusMessage = "Lite rendering on"
+	} else {
+		m.statusMessage = "Lite rendering off"
+	}
+	m.statusMessageType = "info"
+	return cmd
+}
+
+// Lite rendering drops borders and stops anything that redraws on a timer
+// (cursor blinking, spinners)
+func (m *model) setLite(lite bool) tea.Cmd {
+	m.lite = lite
+	m.fileState.viewer.Style = fileViewerStyle
+	if lite {
+		m.fileState.viewer.Style = liteFileViewerStyle
+	}
+	return m.setCursorBlink()
+}
+
+// Blink the cursors unless nothing should redraw on a timer
+func (m *model) setCursorBlink() tea.Cmd {
+	mode := cursor.CursorBlink
+	if !m.animated() {
+		mode = cursor.CursorStatic
+	}
+
+	var cmds []tea.Cmd
+	for _, c := range []*cursor.Model{&m.searchInput.Cursor, &m.directoryInput.Cursor, &m.helpState.filter.Cursor, &m.resultsState.filterInput.Cursor, &m.resultsState.list.filterInput.Cursor} {
+		cmds = append(cmds, c.SetMode(mode))
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m model) docStyle() lipgloss.Style {
+	if m.lite {
+		return liteDocStyle
+	}
+	return docStyle
+}
+
+// Hide a style's border in lite mode, keeping the space it takes up so the
+// layout doesn't shift
+func (m model) bordered(style lipgloss.Style) lipgloss.Style {
+	if m.lite {
+		return style.BorderStyle(lipgloss.HiddenBorder())
+	}
+	return style
+}
+
+func (m model) tabStyles() (active, inactive lipgloss.Style) {
+	if m.lite {
+		return liteActiveTabStyle, liteInactiveTabStyle
+	}
+	return activeTabStyle, inactiveTabStyle
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How long typing has to pause before a live search runs
+const liveSearchDelay = 300 * time.Millisecond
+
+// Typing paused after the edit with this id
+type liveSearchMsg struct {
+	id int
+}
+
+// Wait for typing to pause before searching. Each edit supersedes the
+// last, so only the final pause runs a search.
+func (m *model) debounceLiveSearch() tea.Cmd {
+	if !m.live || m.lowPower {
+		return nil
+	}
+	m.liveID++
+	id := m.liveID
+	return tea.Tick(liveSearchDelay, func(time.Time) tea.Msg {
+		return liveSearchMsg{id: id}
+	})
+}
+
+// Search for what's been typed without leaving the search tab. The old
+// results stay up until the new search has some, so the list doesn't
+// flash empty on every pause.
+func (m *model) startLiveSearch() tea.Cmd {
+	if m.searchInput.Value() == "" {
+		if m.search != nil {
+			m.search.stop()
+			m.search = nil
+		}
+		// Anything still coming is from the pattern that was cleared
+		m.searchID++
+		return nil
+	}
+	cmd := m.runSearch()
+	m.staleResults = true
+	return cmd
+}
+
+func (m *model) toggleLive() {
+	m.live = !m.live
+	m.liveID++
+	m.statusMessage = "Live search off: press Enter to search"
+	if m.live {
+		m.statusMessage = "Live search on: results update as you type"
+	}
+	m.statusMessageType = "info"
+}
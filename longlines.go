@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// How many cells a horizontal scroll moves the selected result's line
+const hscrollStep = 10
+
+var lineViewStyle = lipgloss.NewStyle().
+	BorderStyle(lipgloss.RoundedBorder()).
+	BorderForeground(highlight).
+	Padding(1, 2)
+
+// How far the selected result's line has been scrolled sideways. Moving to
+// another result starts it back where it was.
+type horizontalScroll struct {
+	key    resultKey
+	offset int
+}
+
+// The part of a line of width cells that fits in width cells, as styled
+// text. A line too long to fit is shown around its first match, shifted
+// by scroll, with ellipses where it's been cut.
+func lineWindow(styled, plain string, spans []matchSpan, width, scroll int) string {
+	total := ansi.StringWidth(plain)
+	if total <= width || width <= 2 {
+		return ansi.Truncate(styled, width, "…")
+	}
+
+	left := 0
+	if len(spans) > 0 {
+		start := ansi.StringWidth(plain[:min(spans[0].start, len(plain))])
+		end := ansi.StringWidth(plain[:min(spans[0].end, len(plain))])
+		if end > width-1 {
+			// Center the match, or as much of it as fits
+			left = start - max(width-(end-start), 0)/2
+		}
+	}
+	left = min(max(left+scroll, 0), total-width+1)
+	if left == 0 {
+		return ansi.Truncate(styled, width, "…")
+	}
+	return ansi.Truncate("…"+ansi.Cut(styled, left+1, total), width, "…")
+}
+
+// Scroll the selected result's line left (dir < 0) or right
+func (m *model) scrollLine(dir int) {
+	item, ok := m.resultsState.list.selected()
+	if !ok {
+		return
+	}
+	s := m.resultsState.hscroll
+	if s.key != item.key() {
+		s.key = item.key()
+		s.offset = 0
+	}
+	s.offset += dir * hscrollStep
+}
+
+// Show the selected result's whole line in a popup
+func (m *model) openLine() {
+	if _, ok := m.resultsState.list.selected(); ok {
+		m.showLine = true
+	}
+}
+
+// Any key closes the popup, except quitting
+func (m model) updateLine(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "ctrl+c" {
+		return m, tea.Quit
+	}
+	if key.Matches(msg, m.keymap.Back) || key.Matches(msg, m.keymap.ShowLine) || key.Matches(msg, m.keymap.Enter) {
+		m.showLine = false
+	}
+	return m, nil
+}
+
+func (m model) lineView() string {
+	item, ok := m.resultsState.list.selected()
+	if !ok {
+		return ""
+	}
+	width := m.resultsWidth() - lineViewStyle.GetHorizontalFrameSize()
+	header := searchPromptStyle.Render(fmt.Sprintf("%s:%d:%d", m.paths.show(item.fileName), item.lineNum, item.column))
+	// Minified files can have lines far longer than the screen
+	content, spans := m.resultsState.list.delegate.clean.line(item)
+	line := lipgloss.NewStyle().Width(width).MaxHeight(max(m.listHeight-6, 1)).Render(highlightSpans(content, spans, lipgloss.NewStyle(), matchStyle))
+	hint := jumpHintStyle.Render(fmt.Sprintf("%s to close", m.keymap.Back.Help().Key))
+	return lineViewStyle.Width(width + lineViewStyle.GetHorizontalPadding()).Render(lipgloss.JoinVertical(lipgloss.Left, header, "", line, "", hint))
+}
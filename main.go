@@ -1,16 +1,20 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
-	"os/exec"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
@@ -30,6 +34,18 @@ var (
 			Padding(1, 2).
 			Bold(true)
 
+	readOnlyStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFFFFF")).
+			Background(lipgloss.Color("#FF5F87")).
+			Padding(0, 1).
+			Bold(true)
+
+	sandboxStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFFFFF")).
+			Background(lipgloss.Color("#43BF6D")).
+			Padding(0, 1).
+			Bold(true)
+
 	statusBarStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#FFFFFF")).
 			Background(subtle).
@@ -66,6 +82,11 @@ var (
 			Foreground(highlight).
 			Bold(true)
 
+	matchStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.AdaptiveColor{Light: "#FFFFFF", Dark: "#1A1A1A"}).
+			Background(special).
+			Bold(true)
+
 	inputBoxStyle = lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
 			Padding(0, 1).
@@ -95,31 +116,127 @@ var (
 	dirIconStyle = lipgloss.NewStyle().
 			Foreground(special).
 			Bold(true)
+
+	fileViewerStyle = lipgloss.NewStyle().
+			BorderStyle(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#25A065")).
+			Padding(0, 1)
 )
 
 // Custom item for search results
 type Item struct {
-	fileName string
-	lineNum  string
-	content  string
-	fullPath string
+	fileName    string
+	lineNum     int
+	content     string
+	indent      string // the whitespace trimmed from the start of the line
+	fullPath    string
+	column      int         // 1-based byte column of the first match
+	matches     []matchSpan // byte offsets into content
+	lineMatches []matchSpan // byte offsets into the untrimmed line on disk
+	remote      *remoteHit  // set for results from remote code search
 }
 
-func (i Item) Title() string       { return i.fileName + ":" + i.lineNum }
+// A matched region as half-open byte offsets
+type matchSpan struct {
+	start int
+	end   int
+}
+
+func (i Item) Title() string {
+	return i.fileName + ":" + strconv.Itoa(i.lineNum) + ":" + strconv.Itoa(i.column)
+}
 func (i Item) Description() string { return i.content }
 func (i Item) FilterValue() string { return i.fileName + i.content }
 
 // Key mappings
 type keyMap struct {
-	Search    key.Binding
-	Search2   key.Binding
-	Enter     key.Binding
-	Back      key.Binding
-	Quit      key.Binding
-	Help      key.Binding
-	Tab       key.Binding
-	InputNext key.Binding
-	InputPrev key.Binding
+	Search        key.Binding
+	Search2       key.Binding
+	Enter         key.Binding
+	Back          key.Binding
+	Quit          key.Binding
+	Help          key.Binding
+	Tab           key.Binding
+	InputNext     key.Binding
+	InputPrev     key.Binding
+	Yank          key.Binding
+	YankLoc       key.Binding
+	YankLine      key.Binding
+	YankAll       key.Binding
+	Permalink     key.Binding
+	Webhook       key.Binding
+	Clipboard     key.Binding
+	Paste         key.Binding
+	Narrow        key.Binding
+	Lite          key.Binding
+	Mark          key.Binding
+	MarkAll       key.Binding
+	Sidebar       key.Binding
+	SidebarSort   key.Binding
+	Preview       key.Binding
+	Compare       key.Binding
+	AuditLog      key.Binding
+	NextFile      key.Binding
+	PrevFile      key.Binding
+	Pin           key.Binding
+	Pins          key.Binding
+	Unpin         key.Binding
+	Paths         key.Binding
+	Repos         key.Binding
+	Languages     key.Binding
+	Remote        key.Binding
+	Exclude       key.Binding
+	ExcludeDir    key.Binding
+	ClearExcludes key.Binding
+	Suggestion    key.Binding
+	ExportHTML    key.Binding
+	ExportSession key.Binding
+	LoadSession   key.Binding
+	Export        key.Binding
+	Pipe          key.Binding
+	RunOnFiles    key.Binding
+	Quickfix      key.Binding
+	Edit          key.Binding
+	TmuxOpen      key.Binding
+	Pager         key.Binding
+	Replace       key.Binding
+	Stats         key.Binding
+	Live          key.Binding
+	Minimap       key.Binding
+	MinimapNext   key.Binding
+	MinimapPrev   key.Binding
+	Expand        key.Binding
+	JumpFile      key.Binding
+	Labels        key.Binding
+	Peek          key.Binding
+	ScrollLeft    key.Binding
+	ScrollRight   key.Binding
+	FileLeft      key.Binding
+	Hex           key.Binding
+	Follow        key.Binding
+	Directory     key.Binding
+	Select        key.Binding
+	Reload        key.Binding
+	Blame         key.Binding
+	Definition    key.Binding
+	TagBack       key.Binding
+	SearchWord    key.Binding
+	FileRight     key.Binding
+	ShowLine      key.Binding
+	Scopes        key.Binding
+	Dismiss       key.Binding
+	Undismiss     key.Binding
+	Ignore        key.Binding
+	Sessions      key.Binding
+	Pause         key.Binding
+	LowPower      key.Binding
+	NextHit       key.Binding
+	PrevHit       key.Binding
+	Find          key.Binding
+	Wrap          key.Binding
+	GotoLine      key.Binding
+	Top           key.Binding
+	Bottom        key.Binding
 }
 
 func (k keyMap) ShortHelp() []key.Binding {
@@ -131,6 +248,7 @@ func (k keyMap) FullHelp() [][]key.Binding {
 		{k.Search, k.Search2, k.Enter},
 		{k.Back, k.Tab, k.Quit},
 		{k.InputNext, k.InputPrev},
+		{k.Yank, k.Clipboard},
 	}
 }
 
@@ -172,6 +290,318 @@ var keys = keyMap{
 		key.WithKeys("shift+tab"),
 		key.WithHelp("shift+tab", "previous input"),
 	),
+	Yank: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "copy path"),
+	),
+	YankLoc: key.NewBinding(
+		key.WithKeys("Y"),
+		key.WithHelp("Y", "copy path:line"),
+	),
+	YankLine: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "copy line"),
+	),
+	YankAll: key.NewBinding(
+		key.WithKeys("alt+y"),
+		key.WithHelp("alt+y", "copy all results"),
+	),
+	Permalink: key.NewBinding(
+		key.WithKeys("alt+g"),
+		key.WithHelp("alt+g", "copy permalink"),
+	),
+	Webhook: key.NewBinding(
+		key.WithKeys("alt+w"),
+		key.WithHelp("alt+w", "send to webhook"),
+	),
+	Clipboard: key.NewBinding(
+		key.WithKeys("ctrl+y"),
+		key.WithHelp("ctrl+y", "clipboard history"),
+	),
+	Paste: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "paste into search"),
+	),
+	Narrow: key.NewBinding(
+		key.WithKeys("&"),
+		key.WithHelp("&", "filter results by regex"),
+	),
+	Lite: key.NewBinding(
+		key.WithKeys("ctrl+l"),
+		key.WithHelp("ctrl+l", "toggle lite rendering"),
+	),
+	Mark: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "mark result"),
+	),
+	MarkAll: key.NewBinding(
+		key.WithKeys("ctrl+a"),
+		key.WithHelp("ctrl+a", "mark all / clear marks"),
+	),
+	SidebarSort: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "sort files"),
+	),
+	Sidebar: key.NewBinding(
+		key.WithKeys("ctrl+b"),
+		key.WithHelp("ctrl+b", "show / focus / hide file sidebar"),
+	),
+	Compare: key.NewBinding(
+		key.WithKeys("="),
+		key.WithHelp("=", "compare with marked"),
+	),
+	Preview: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "toggle preview pane"),
+	),
+	AuditLog: key.NewBinding(
+		key.WithKeys("ctrl+o"),
+		key.WithHelp("ctrl+o", "audit log"),
+	),
+	NextFile: key.NewBinding(
+		key.WithKeys("]"),
+		key.WithHelp("]", "next file"),
+	),
+	PrevFile: key.NewBinding(
+		key.WithKeys("["),
+		key.WithHelp("[", "previous file"),
+	),
+	Pin: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "pin / unpin result"),
+	),
+	Pins: key.NewBinding(
+		key.WithKeys("ctrl+p"),
+		key.WithHelp("ctrl+p", "pinned results"),
+	),
+	Unpin: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "unpin"),
+	),
+	Paths: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "relative / absolute paths"),
+	),
+	Languages: key.NewBinding(
+		key.WithKeys("L"),
+		key.WithHelp("L", "matches per language"),
+	),
+	Repos: key.NewBinding(
+		key.WithKeys("ctrl+g"),
+		key.WithHelp("ctrl+g", "matches per repository"),
+	),
+	Remote: key.NewBinding(
+		key.WithKeys("ctrl+r"),
+		key.WithHelp("ctrl+r", "search remotely"),
+	),
+	Exclude: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "exclude file"),
+	),
+	ExcludeDir: key.NewBinding(
+		key.WithKeys("X"),
+		key.WithHelp("X", "exclude directory"),
+	),
+	ClearExcludes: key.NewBinding(
+		key.WithKeys("ctrl+x"),
+		key.WithHelp("ctrl+x", "clear exclusions and flags"),
+	),
+	Suggestion: key.NewBinding(
+		key.WithKeys("!"),
+		key.WithHelp("!", "take the suggestion"),
+	),
+	Quickfix: key.NewBinding(
+		key.WithKeys("Q"),
+		key.WithHelp("Q", "open all in editor"),
+	),
+	Edit: key.NewBinding(
+		key.WithKeys("ctrl+e"),
+		key.WithHelp("ctrl+e", "open in editor"),
+	),
+	Pager: key.NewBinding(
+		key.WithKeys("alt+v"),
+		key.WithHelp("alt+v", "open in $PAGER"),
+	),
+	TmuxOpen: key.NewBinding(
+		key.WithKeys("alt+o"),
+		key.WithHelp("alt+o", "open in a tmux pane"),
+	),
+	ExportSession: key.NewBinding(
+		key.WithKeys("alt+e"),
+		key.WithHelp("alt+e", "export session"),
+	),
+	LoadSession: key.NewBinding(
+		key.WithKeys("alt+i"),
+		key.WithHelp("alt+i", "load session"),
+	),
+	Export: key.NewBinding(
+		key.WithKeys("W"),
+		key.WithHelp("W", "export as…"),
+	),
+	Pipe: key.NewBinding(
+		key.WithKeys("|"),
+		key.WithHelp("|", "pipe through a command"),
+	),
+	RunOnFiles: key.NewBinding(
+		key.WithKeys("alt+x"),
+		key.WithHelp("alt+x", "run a command on files"),
+	),
+	ExportHTML: key.NewBinding(
+		key.WithKeys("E"),
+		key.WithHelp("E", "export HTML"),
+	),
+	Replace: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "replace"),
+	),
+	Stats: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "search statistics"),
+	),
+	Live: key.NewBinding(
+		key.WithKeys("alt+l"),
+		key.WithHelp("alt+l", "toggle live search"),
+	),
+	Minimap: key.NewBinding(
+		key.WithKeys("M"),
+		key.WithHelp("M", "toggle minimap"),
+	),
+	MinimapNext: key.NewBinding(
+		key.WithKeys("}"),
+		key.WithHelp("}", "next minimap row"),
+	),
+	MinimapPrev: key.NewBinding(
+		key.WithKeys("{"),
+		key.WithHelp("{", "previous minimap row"),
+	),
+	Expand: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "one result per match"),
+	),
+	Labels: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "jump to a result by label"),
+	),
+	JumpFile: key.NewBinding(
+		key.WithKeys("@"),
+		key.WithHelp("@", "jump to file"),
+	),
+	Peek: key.NewBinding(
+		key.WithKeys("alt+t"),
+		key.WithHelp("alt+t", "peek at terminal"),
+	),
+	ScrollLeft: key.NewBinding(
+		key.WithKeys("<"),
+		key.WithHelp("<", "scroll line left"),
+	),
+	ScrollRight: key.NewBinding(
+		key.WithKeys(">"),
+		key.WithHelp(">", "scroll line right"),
+	),
+	Hex: key.NewBinding(
+		key.WithKeys("H"),
+		key.WithHelp("H", "hex/text view"),
+	),
+	Follow: key.NewBinding(
+		key.WithKeys("T"),
+		key.WithHelp("T", "follow the file (tail -f)"),
+	),
+	Directory: key.NewBinding(
+		key.WithKeys("-"),
+		key.WithHelp("-", "list the file's directory"),
+	),
+	Select: key.NewBinding(
+		key.WithKeys("V"),
+		key.WithHelp("V", "select lines to copy"),
+	),
+	Reload: key.NewBinding(
+		key.WithKeys("alt+r"),
+		key.WithHelp("alt+r", "reload the file"),
+	),
+	Blame: key.NewBinding(
+		key.WithKeys("B"),
+		key.WithHelp("B", "toggle git blame"),
+	),
+	Definition: key.NewBinding(
+		key.WithKeys("ctrl+]"),
+		key.WithHelp("ctrl+]", "go to definition (ctags)"),
+	),
+	TagBack: key.NewBinding(
+		key.WithKeys("backspace"),
+		key.WithHelp("backspace", "back from definition"),
+	),
+	SearchWord: key.NewBinding(
+		key.WithKeys("*"),
+		key.WithHelp("*", "search for the word at the match"),
+	),
+	FileLeft: key.NewBinding(
+		key.WithKeys("h", "left"),
+		key.WithHelp("h/←", "scroll left"),
+	),
+	FileRight: key.NewBinding(
+		key.WithKeys("l", "right"),
+		key.WithHelp("l/→", "scroll right"),
+	),
+	ShowLine: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "show whole line"),
+	),
+	Scopes: key.NewBinding(
+		key.WithKeys("alt+s"),
+		key.WithHelp("alt+s", "search scope"),
+	),
+	Dismiss: key.NewBinding(
+		key.WithKeys("D", "delete"),
+		key.WithHelp("D", "dismiss result"),
+	),
+	Undismiss: key.NewBinding(
+		key.WithKeys("U"),
+		key.WithHelp("U", "undo dismiss"),
+	),
+	Ignore: key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "false positive"),
+	),
+	NextHit: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "next match"),
+	),
+	PrevHit: key.NewBinding(
+		key.WithKeys("N"),
+		key.WithHelp("N", "previous match"),
+	),
+	Find: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "find in file"),
+	),
+	Wrap: key.NewBinding(
+		key.WithKeys("w"),
+		key.WithHelp("w", "wrap lines"),
+	),
+	GotoLine: key.NewBinding(
+		key.WithKeys(":"),
+		key.WithHelp(":", "go to line"),
+	),
+	Top: key.NewBinding(
+		key.WithKeys("g"),
+		key.WithHelp("gg", "go to top"),
+	),
+	Bottom: key.NewBinding(
+		key.WithKeys("G"),
+		key.WithHelp("G", "go to bottom"),
+	),
+	LowPower: key.NewBinding(
+		key.WithKeys("alt+b"),
+		key.WithHelp("alt+b", "toggle low-power mode"),
+	),
+	Pause: key.NewBinding(
+		key.WithKeys("alt+p"),
+		key.WithHelp("alt+p", "pause/resume search"),
+	),
+	Sessions: key.NewBinding(
+		key.WithKeys("alt+f"),
+		key.WithHelp("alt+f", "search all searches"),
+	),
 }
 
 // The tabs available in the UI
@@ -181,6 +611,7 @@ const (
 	searchTab tab = iota
 	resultsTab
 	fileTab
+	helpTab
 )
 
 // Main application model
@@ -189,8 +620,9 @@ type model struct {
 	activeTab            tab
 	searchInput          textinput.Model
 	directoryInput       textinput.Model
-	searchResults        list.Model
-	fileViewer           viewport.Model
+	resultsState         resultsState
+	fileState            fileState
+	helpState            helpState
 	statusMessage        string
 	statusMessageType    string // "info", "error"
 	width                int
@@ -199,8 +631,92 @@ type model struct {
 	ready                bool
 	help                 help.Model
 	currentPath          string
+	pre                  string // rg --pre command
+	highlighter          highlighter
+	scrollOffset         *int // lines above the match when a file opens, or nil to center it
 	currentSearchPattern string
 	keymap               keyMap
+	searchID             int
+	search               *searchStream
+	clipboardHistory     list.Model
+	showClipboard        bool
+	auditLog             list.Model
+	showAudit            bool
+	pinList              list.Model
+	showPins             bool
+	pinned               map[resultKey]bool
+	paths                *pathDisplay
+	stdinFiles           []string // the files piped in to search, if any
+	printFormat          string   // with -print-selection, what enter on a result prints
+	picked               string   // what to print on the way out
+	repos                []string
+	repoList             list.Model
+	showRepos            bool
+	languageList         list.Model
+	showLanguages        bool
+	remote               remoteConfig
+	webhook              webhookConfig
+	confirm              *confirmation
+	excludes             []string                  // paths left out of the results and later searches
+	flags                []string                  // rg flags taken from suggestions, for every search
+	suggestion           *suggestion               // for the last search, if there's one worth making
+	importedContext      map[resultKey]sessionSnip // lines around each result of an imported session
+	loadedFrom           string                    // the session file the results were loaded from, if they were
+	loadInput            textinput.Model
+	showLoad             bool
+	loadErr              error
+	linkTemplate         string
+	markdownTemplate     string
+	editor               editorConfig // what ctrl+e opens results with
+	review               *replaceReview
+	stats                *searchStats // of the last search rg finished
+	showStats            bool
+	live                 bool        // search as the pattern is typed
+	liveID               int         // the latest edit, so earlier pauses are ignored
+	staleResults         bool        // the results are from before a live search, and go once it has some
+	expandMatches        bool        // one result per match, rather than per matched line
+	showLine             bool        // the selected result's whole line, in a popup
+	comparison           *comparison // two results side by side, once they've loaded
+	scopes               []scopeTemplate
+	scope                *scopeTemplate // narrowing every search, if set
+	scopeList            list.Model
+	showScopes           bool
+	tagList              list.Model
+	showTags             bool
+	exportList           list.Model
+	showExport           bool
+	pipe                 pipeState
+	tagStack             []tagReturn // where jumps to definitions were made from
+	dirList              list.Model
+	showDir              bool
+	dir                  string              // the directory being listed
+	dismissed            map[dismissKey]bool // results left out of this and later searches
+	dismissals           []dismissal         // in order, for undoing
+	ignoreRules          []ignoreRule        // false positives, kept across runs
+	indexes              []*corpusIndex      // of the directories set up to be indexed
+	sessions             []*searchSession    // earlier searches of this run, oldest first
+	sessionInput         textinput.Model
+	sessionList          list.Model
+	sessionErr           error
+	showSessions         bool
+	previousTab          tab
+	fileToken            int
+	fileLoading          bool
+	fileSpinner          spinner.Model
+	cancelRender         context.CancelFunc
+	results              []Item
+	listHeight           int
+	renderMonitor        *renderMonitor
+	lite                 bool
+	lowPower             bool
+	powerMode            string // lowPowerAuto, lowPowerOn or lowPowerOff
+	liteOverride         bool
+	marked               map[resultKey]bool
+	session              session
+	searchStarted        time.Time
+	startup              tea.Cmd // run by Init, e.g. the search given on the command line
+	readOnly             bool
+	sandbox              bool
 }
 
 func initialModel() model {
@@ -236,306 +752,844 @@ func initialModel() model {
 		BorderStyle(lipgloss.RoundedBorder()).
 		Padding(0, 1)
 
-	resultsList := list.New([]list.Item{}, delegate, 0, 0)
-	resultsList.Title = "Search Results"
-	resultsList.SetShowHelp(false)
-	resultsList.Styles.Title = lipgloss.NewStyle().
-		Foreground(special).
-		Bold(true).
-		MarginLeft(2)
-	resultsList.Styles.FilterPrompt = lipgloss.NewStyle().
-		Foreground(special)
-	resultsList.Styles.FilterCursor = lipgloss.NewStyle().
-		Foreground(highlight)
+	marked := map[resultKey]bool{}
+	pinned := map[resultKey]bool{}
+	paths := newPathDisplay()
+	hscroll := &horizontalScroll{}
+	labels := &resultLabels{}
+	resultsList := newResultList("Search Results", resultDelegate{DefaultDelegate: delegate, marked: marked, pinned: pinned, paths: paths, icons: iconsASCII, clean: sanitizeConfig{}.options(), scroll: hscroll, labels: labels})
 
 	fileViewer := viewport.New(0, 0)
-	fileViewer.Style = lipgloss.NewStyle().
-		BorderStyle(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("#25A065")).
-		Padding(0, 1)
+	fileViewer.Style = fileViewerStyle
+
+	fileSpinner := spinner.New()
+	fileSpinner.Spinner = spinner.Dot
+	fileSpinner.Style = lipgloss.NewStyle().Foreground(special)
 
 	help := help.New()
 
 	return model{
-		tabs:              []string{"Search", "Results", "File View"},
-		activeTab:         searchTab,
-		searchInput:       searchInput,
-		directoryInput:    directoryInput,
-		searchResults:     resultsList,
-		fileViewer:        fileViewer,
+		tabs:           []string{"Search", "Results", "File View", "Help"},
+		activeTab:      searchTab,
+		searchInput:    searchInput,
+		directoryInput: directoryInput,
+		resultsState: resultsState{
+			list:        resultsList,
+			limit:       resultPageSize,
+			filterInput: newResultFilter(),
+			sidebar:     newFileSidebar(),
+			sidebarSort: sortByFound,
+			minimap:     &minimap{},
+			hscroll:     hscroll,
+			labels:      labels,
+			jumpInput:   newJumpInput(),
+		},
+		fileState: fileState{
+			viewer:    fileViewer,
+			findInput: newFindInput(),
+			gotoInput: newGotoInput(),
+		},
+		helpState: helpState{
+			filter:   newHelpFilter(),
+			viewport: viewport.New(0, 0),
+		},
+		fileSpinner:       fileSpinner,
+		marked:            marked,
+		session:           detectSession(),
 		statusMessage:     "Welcome to LazyRG! Press Ctrl+F to search",
 		statusMessageType: "info",
 		showStatusBar:     true,
 		help:              help,
 		currentPath:       currentPath,
 		keymap:            keys,
+		clipboardHistory:  newClipboardList(),
+		auditLog:          newAuditList(),
+		pinList:           newPinList(),
+		pinned:            pinned,
+		paths:             paths,
+		repoList:          newRepoList(),
+		languageList:      newLanguageList(),
+		highlighter:       highlighter{backend: highlighterChroma, theme: defaultTheme},
+		scopeList:         newScopeList(),
+		dirList:           newDirList(),
+		tagList:           newTagList(),
+		exportList:        newExportList(),
+		pipe:              pipeState{input: newPipeInput(), output: viewport.New(0, 0)},
+		loadInput:         newLoadInput(),
+		scopes:            builtinScopes,
+		dismissed:         map[dismissKey]bool{},
+		sessionInput:      newSessionInput(),
+		sessionList:       newSessionList(),
 	}
 }
 
 func (m model) Init() tea.Cmd {
-	return textinput.Blink
+	return tea.Batch(textinput.Blink, checkLatency(), m.watchPower(), m.watchIndex(), m.startup)
 }
 
-// Message types
-type searchFinishedMsg struct {
-	results []Item
-	err     error
-}
-
-type fileLoadedMsg struct {
-	content string
-	err     error
-}
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmds []tea.Cmd
 
-// Run ripgrep
-func executeRipgrep(pattern string, path string) tea.Cmd {
-	return func() tea.Msg {
-		if pattern == "" {
-			return searchFinishedMsg{
-				results: []Item{},
-				err:     fmt.Errorf("empty search pattern"),
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.confirm != nil {
+			return m.updateConfirm(msg)
+		}
+		if m.review != nil && !m.review.staged {
+			return m.updateReview(msg)
+		}
+		if m.showStats {
+			if key.Matches(msg, m.keymap.Back) || key.Matches(msg, m.keymap.Stats) {
+				m.showStats = false
+			} else if key.Matches(msg, m.keymap.Quit) {
+				return m, tea.Quit
 			}
+			return m, nil
 		}
-
-		cmd := exec.Command("rg", "--line-number", "--color", "never", "--no-heading", "--with-filename", pattern, path)
-		output, err := cmd.CombinedOutput()
-		if err != nil {
-			if strings.Contains(string(output), "No such file or directory") {
-				return searchFinishedMsg{
-					results: []Item{},
-					err:     fmt.Errorf("directory not found: %s", path),
-				}
+		if m.showLine {
+			return m.updateLine(msg)
+		}
+		if m.comparison != nil {
+			return m.updateCompare(msg)
+		}
+		if m.showClipboard {
+			return m.updateClipboard(msg)
+		}
+		if m.showAudit {
+			return m.updateAuditLog(msg)
+		}
+		if m.showPins {
+			return m.updatePins(msg)
+		}
+		if m.showDir {
+			return m.updateDir(msg)
+		}
+		if m.pipe.show {
+			return m.updatePipe(msg)
+		}
+		if m.showLoad {
+			return m.updateLoad(msg)
+		}
+		if m.showExport {
+			return m.updateExport(msg)
+		}
+		if m.showTags {
+			return m.updateTags(msg)
+		}
+		if m.showRepos {
+			return m.updateRepos(msg)
+		}
+		if m.showLanguages {
+			return m.updateLanguages(msg)
+		}
+		if m.showScopes {
+			return m.updateScopes(msg)
+		}
+		if m.showSessions {
+			return m.updateSessions(msg)
+		}
+		if m.resultsState.labels.show && m.activeTab == resultsTab {
+			return m.updateLabels(msg)
+		}
+		if m.resultsState.jumping && m.activeTab == resultsTab {
+			return m.updateJump(msg)
+		}
+		if m.resultsState.editingFilter && m.activeTab == resultsTab {
+			return m.updateResultFilter(msg)
+		}
+		if m.fileState.finding && m.activeTab == fileTab {
+			return m.updateFind(msg)
+		}
+		if m.fileState.goingTo && m.activeTab == fileTab {
+			return m.updateGoto(msg)
+		}
+		if m.fileState.selecting && m.activeTab == fileTab {
+			return m.updateSelection(msg)
+		}
+		if m.fileState.pendingG && m.activeTab == fileTab {
+			m.fileState.pendingG = false
+			if key.Matches(msg, m.keymap.Top) {
+				m.gotoEnd(false)
+				return m, m.moreOfFile()
 			}
-			// rg returns exit code 1 when no matches were found, which is not an error for us
-			if strings.TrimSpace(string(output)) == "" {
-				return searchFinishedMsg{
-					results: []Item{},
-					err:     nil,
-				}
+		}
+		if m.resultsState.sidebarFocused && m.activeTab == resultsTab {
+			return m.updateSidebar(msg)
+		}
+		if m.activeTab == resultsTab && m.resultsState.list.settingFilter() && msg.String() != "ctrl+c" {
+			filter := m.resultsState.list.filterValue()
+			var cmd tea.Cmd
+			m.resultsState.list, cmd = m.resultsState.list.Update(msg)
+			if m.resultsState.list.filterValue() != filter {
+				m.syncSidebar()
 			}
+			return m, tea.Batch(cmd, m.updatePreview())
 		}
+		if m.activeTab == helpTab && msg.Type == tea.KeyRunes {
+			return m.updateHelp(msg)
+		}
+
+		switch {
+		case key.Matches(msg, m.keymap.Quit):
+			return m, tea.Quit
 
-		results := []Item{}
-		lines := strings.Split(string(output), "\n")
+		case key.Matches(msg, m.keymap.Help):
+			return m, m.openHelp()
 
-		for _, line := range lines {
-			if strings.TrimSpace(line) == "" {
-				continue
+		case key.Matches(msg, m.keymap.Tab):
+			next := (m.activeTab + 1) % tab(len(m.tabs))
+			if next == helpTab {
+				return m, m.openHelp()
 			}
-
-			parts := strings.SplitN(line, ":", 3)
-			if len(parts) < 3 {
-				continue
+			m.activeTab = next
+			if m.activeTab == searchTab {
+				m.helpState.filter.Blur()
+				m.searchInput.Focus()
 			}
+			return m, nil
 
-			results = append(results, Item{
-				fileName: strings.TrimSpace(parts[0]),
-				lineNum:  strings.TrimSpace(parts[1]),
-				content:  strings.TrimSpace(parts[2]),
-				fullPath: strings.TrimSpace(parts[0]),
-			})
-		}
+		case key.Matches(msg, m.keymap.Lite):
+			return m, m.toggleLite()
 
-		return searchFinishedMsg{
-			results: results,
-			err:     nil,
-		}
-	}
-}
+		case key.Matches(msg, m.keymap.Scopes) && m.activeTab == searchTab:
+			m.openScopes()
+			return m, nil
 
-// Load file content for viewing
-func loadFile(filepath string, lineNum string) tea.Cmd {
-	return func() tea.Msg {
-		// Try using bat with line highlighting
-		lineNumInt := 0
-		if _, err := fmt.Sscanf(lineNum, "%d", &lineNumInt); err != nil {
-			return fileLoadedMsg{err: fmt.Errorf("invalid line number: %s", lineNum)}
-		}
+		case key.Matches(msg, m.keymap.Sessions):
+			return m, m.openSessions()
 
-		cmd := exec.Command("bat", "--color=always", "--style=full", "--highlight-line", lineNum, filepath)
-		output, err := cmd.CombinedOutput()
+		case key.Matches(msg, m.keymap.Pause):
+			return m, m.togglePause()
 
-		// Fallback to regular cat if bat is not installed
-		if err != nil && strings.Contains(err.Error(), "executable file not found") {
-			file, err := os.Open(filepath)
-			if err != nil {
-				return fileLoadedMsg{err: err}
-			}
-			defer file.Close()
+		case key.Matches(msg, m.keymap.LowPower):
+			return m, m.toggleLowPower()
 
-			content, err := io.ReadAll(file)
-			if err != nil {
-				return fileLoadedMsg{err: err}
-			}
+		case key.Matches(msg, m.keymap.Peek):
+			return m, m.peekTerminal()
 
-			lines := strings.Split(string(content), "\n")
+		case key.Matches(msg, m.keymap.Clipboard):
+			m.showClipboard = true
+			return m, nil
 
-			// Simple highlighting
-			highlightedContent := ""
-			for i, line := range lines {
-				lineNumberStr := fmt.Sprintf("%4d | ", i+1)
-				if i+1 == lineNumInt {
-					highlightedContent += "→ " + lineNumberStr + highlightStyle.Render(line) + "\n"
-				} else {
-					highlightedContent += "  " + lineNumberStr + line + "\n"
-				}
-			}
+		case key.Matches(msg, m.keymap.AuditLog):
+			return m, m.openAuditLog()
 
-			return fileLoadedMsg{content: highlightedContent}
-		}
+		case key.Matches(msg, m.keymap.Pins):
+			m.showPins = true
+			return m, nil
 
-		// If bat was successful, return its output
-		if err == nil {
-			return fileLoadedMsg{content: string(output)}
-		}
+		case key.Matches(msg, m.keymap.Repos):
+			return m, m.openRepos()
 
-		// If bat failed for any other reason, try without line highlighting
-		cmd = exec.Command("bat", "--color=always", "--style=full", filepath)
-		output, err = cmd.CombinedOutput()
-		if err != nil {
-			return fileLoadedMsg{err: err}
-		}
+		case key.Matches(msg, m.keymap.Languages) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			return m, m.openLanguages()
 
-		return fileLoadedMsg{content: string(output)}
-	}
-}
+		case key.Matches(msg, m.keymap.Stats) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.showStats = true
+			return m, nil
 
-func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var cmds []tea.Cmd
+		case key.Matches(msg, m.keymap.Replace) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			return m, m.openReplace()
 
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch {
-		case key.Matches(msg, m.keymap.Quit):
-			return m, tea.Quit
+		case key.Matches(msg, m.keymap.Quickfix) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			return m, m.openQuickfix()
 
-		case key.Matches(msg, m.keymap.Help):
-			m.help.ShowAll = !m.help.ShowAll
+		case key.Matches(msg, m.keymap.Edit) && (m.activeTab == fileTab || m.activeTab == resultsTab && !m.resultsState.list.settingFilter()):
+			return m, m.openInEditor()
+
+		case key.Matches(msg, m.keymap.Pager) && (m.activeTab == fileTab || m.activeTab == resultsTab && !m.resultsState.list.settingFilter()):
+			return m, m.openInPager()
+
+		case key.Matches(msg, m.keymap.TmuxOpen) && (m.activeTab == fileTab || m.activeTab == resultsTab && !m.resultsState.list.settingFilter()):
+			return m, m.openInTmux()
+
+		case key.Matches(msg, m.keymap.ExportHTML) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.exportHTML()
 			return m, nil
 
-		case key.Matches(msg, m.keymap.Tab):
-			m.activeTab = (m.activeTab + 1) % 3
-			switch m.activeTab {
-			case searchTab:
-				m.searchInput.Focus()
-			case resultsTab:
-				if m.searchResults.Items() != nil && len(m.searchResults.Items()) > 0 {
-					cmds = append(cmds, m.searchResults.StartSpinner())
-				}
-			}
-			return m, tea.Batch(cmds...)
+		case key.Matches(msg, m.keymap.ExportSession) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.exportSession()
+			return m, nil
 
-		case key.Matches(msg, m.keymap.Search) || key.Matches(msg, m.keymap.Search2):
-			if m.activeTab != searchTab {
-				m.activeTab = searchTab
-				m.searchInput.Focus()
+		case key.Matches(msg, m.keymap.LoadSession) && (m.activeTab == searchTab || m.activeTab == resultsTab && !m.resultsState.list.settingFilter()):
+			return m, m.openLoadSession()
+
+		case key.Matches(msg, m.keymap.Export) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.openExport()
+			return m, nil
+
+		case key.Matches(msg, m.keymap.Pipe) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			return m, m.openPipe()
+
+		case key.Matches(msg, m.keymap.RunOnFiles) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			return m, m.openRunOnFiles()
+
+		case key.Matches(msg, m.keymap.Exclude) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.excludeSelected(false)
+			return m, m.updatePreview()
+
+		case key.Matches(msg, m.keymap.ExcludeDir) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.excludeSelected(true)
+			return m, m.updatePreview()
+
+		case key.Matches(msg, m.keymap.ClearExcludes) && m.activeTab == searchTab:
+			m.excludes = nil
+			m.flags = nil
+			return m, nil
+
+		case key.Matches(msg, m.keymap.Suggestion) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			if m.takeSuggestion() {
+				return m, m.startSearch()
 			}
 			return m, nil
 
-		case key.Matches(msg, m.keymap.Back):
-			switch m.activeTab {
-			case fileTab:
-				m.activeTab = resultsTab
-			case resultsTab:
-				m.activeTab = searchTab
-				m.searchInput.Focus()
+		case key.Matches(msg, m.keymap.Remote) && m.activeTab == searchTab:
+			if m.searchInput.Value() != "" {
+				return m, m.startRemoteSearch()
 			}
 			return m, nil
 
-		case key.Matches(msg, m.keymap.Enter):
-			switch m.activeTab {
-			case searchTab:
-				if m.searchInput.Value() != "" {
-					m.currentSearchPattern = m.searchInput.Value()
-					searchPath := m.currentPath
-					if m.directoryInput.Value() != "" {
-						searchPath = m.directoryInput.Value()
-					}
-					m.activeTab = resultsTab
-					m.statusMessage = fmt.Sprintf("Searching for: %s in %s", m.currentSearchPattern, searchPath)
-					m.statusMessageType = "info"
-					return m, executeRipgrep(m.currentSearchPattern, searchPath)
-				}
-			case resultsTab:
-				if len(m.searchResults.Items()) > 0 {
-					item, ok := m.searchResults.SelectedItem().(Item)
-					if ok {
-						m.activeTab = fileTab
-						m.statusMessage = fmt.Sprintf("Viewing file: %s", item.fullPath)
-						m.statusMessageType = "info"
-						return m, loadFile(item.fullPath, item.lineNum)
-					}
-				}
+		case key.Matches(msg, m.keymap.Pin) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.togglePin()
+			return m, nil
+
+		case key.Matches(msg, m.keymap.Paths) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.togglePaths()
+			return m, nil
+
+		case key.Matches(msg, m.keymap.Yank) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			if paths := uniquePaths(m.selectedResults()); len(paths) == 1 {
+				m.reportCopy(paths[0], m.copyToClipboard("path", paths[0]))
+			} else if len(paths) > 1 {
+				m.reportCopy(fmt.Sprintf("%d paths", len(paths)), m.copyToClipboard("paths", strings.Join(paths, "\n")))
 			}
-		}
+			return m, nil
 
-	case searchFinishedMsg:
-		if msg.err != nil {
-			m.statusMessage = fmt.Sprintf("Error: %s", msg.err)
-			m.statusMessageType = "error"
+		case key.Matches(msg, m.keymap.YankLoc) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.copyLocations()
 			return m, nil
-		}
 
-		items := []list.Item{}
-		for _, result := range msg.results {
-			items = append(items, result)
-		}
+		case key.Matches(msg, m.keymap.Permalink) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.copyPermalinks()
+			return m, nil
 
-		if len(items) == 0 {
-			m.statusMessage = "No results found"
-			m.statusMessageType = "info"
-		} else {
-			m.statusMessage = fmt.Sprintf("Found %d results", len(items))
-			m.statusMessageType = "info"
-		}
+		case key.Matches(msg, m.keymap.Webhook) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.sendToWebhook()
+			return m, nil
 
-		m.searchResults.SetItems(items)
-		return m, nil
+		case key.Matches(msg, m.keymap.YankLine) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.copyLines()
+			return m, nil
 
-	case fileLoadedMsg:
-		if msg.err != nil {
-			m.statusMessage = fmt.Sprintf("Error loading file: %s", msg.err)
-			m.statusMessageType = "error"
-			m.activeTab = resultsTab
+		case key.Matches(msg, m.keymap.YankAll) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.copyAll()
 			return m, nil
-		}
 
-		m.fileViewer.SetContent(msg.content)
-		// Reset viewport to top when loading new file
-		m.fileViewer.GotoTop()
-		return m, nil
+		case key.Matches(msg, m.keymap.Compare) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			return m, m.openCompare()
 
-	case tea.WindowSizeMsg:
-		m.width, m.height = msg.Width, msg.Height
-		m.ready = true
+		case key.Matches(msg, m.keymap.Mark) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.toggleMark()
+			return m, m.updatePreview()
 
-		topHeight := 6  // For title and tabs with margins
-		helpHeight := 2 // For help view at the bottom
-		statusHeight := 1
-		borderHeight := 2 // Account for top and bottom borders
+		case key.Matches(msg, m.keymap.MarkAll) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.toggleMarkAll()
+			return m, nil
 
-		availableHeight := m.height - topHeight - helpHeight - statusHeight - borderHeight
+		case key.Matches(msg, m.keymap.Narrow) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			return m, m.openResultFilter()
 
-		// Make input boxes fit within the container while accounting for padding and borders
-		m.searchInput.Width = msg.Width - 30
-		m.directoryInput.Width = msg.Width - 30
+		case key.Matches(msg, m.keymap.Sidebar) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.toggleSidebar()
+			return m, nil
 
-		h := availableHeight
-		if m.help.ShowAll {
-			h -= 4 // Make room for help
-		}
+		case key.Matches(msg, m.keymap.Dismiss) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.dismissSelected()
+			return m, m.updatePreview()
 
-		m.searchResults.SetSize(msg.Width-4, h)
-		m.fileViewer.Width = msg.Width - 8 // Account for left/right borders and padding
-		m.fileViewer.Height = h
+		case key.Matches(msg, m.keymap.Ignore) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.ignoreSelected()
+			return m, m.updatePreview()
 
-		// Set viewport to start at the top
-		m.fileViewer.GotoTop()
+		case key.Matches(msg, m.keymap.Undismiss) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.undismiss()
+			return m, m.updatePreview()
 
-		m.help.Width = msg.Width
+		case key.Matches(msg, m.keymap.ScrollLeft) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.scrollLine(-1)
+			return m, nil
 
-		return m, nil
-	}
+		case key.Matches(msg, m.keymap.ScrollRight) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.scrollLine(1)
+			return m, nil
+
+		case key.Matches(msg, m.keymap.ShowLine) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.openLine()
+			return m, nil
+
+		case key.Matches(msg, m.keymap.Labels) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.showLabels()
+			return m, nil
+
+		case key.Matches(msg, m.keymap.JumpFile) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			return m, m.openJump()
+
+		case key.Matches(msg, m.keymap.Expand) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.toggleExpandMatches()
+			return m, m.updatePreview()
+
+		case key.Matches(msg, m.keymap.Minimap) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			return m, m.toggleMinimap()
+
+		case key.Matches(msg, m.keymap.MinimapNext) && m.resultsState.minimap.show && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.stepMinimap(1)
+			return m, m.updatePreview()
+
+		case key.Matches(msg, m.keymap.MinimapPrev) && m.resultsState.minimap.show && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.stepMinimap(-1)
+			return m, m.updatePreview()
+
+		case key.Matches(msg, m.keymap.Preview) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			return m, m.togglePreview()
+
+		case key.Matches(msg, m.keymap.NextFile) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.jumpFile(1)
+			return m, m.updatePreview()
+
+		case key.Matches(msg, m.keymap.PrevFile) && m.activeTab == resultsTab && !m.resultsState.list.settingFilter():
+			m.jumpFile(-1)
+			return m, m.updatePreview()
+
+		case key.Matches(msg, m.keymap.NextHit) && m.activeTab == fileTab:
+			m.stepHit(1)
+			return m, m.moreOfFile()
+
+		case key.Matches(msg, m.keymap.PrevHit) && m.activeTab == fileTab:
+			m.stepHit(-1)
+			return m, m.moreOfFile()
+
+		case key.Matches(msg, m.keymap.Find) && m.activeTab == fileTab:
+			return m, m.openFind()
+
+		case key.Matches(msg, m.keymap.Wrap) && m.activeTab == fileTab:
+			m.toggleWrap()
+			return m, nil
+
+		case key.Matches(msg, m.keymap.Hex) && m.activeTab == fileTab:
+			return m, m.toggleHex()
+
+		case key.Matches(msg, m.keymap.Follow) && m.activeTab == fileTab:
+			return m, m.toggleFollow()
+
+		case key.Matches(msg, m.keymap.Directory) && m.activeTab == fileTab:
+			m.openDir()
+			return m, nil
+
+		case key.Matches(msg, m.keymap.Select) && m.activeTab == fileTab:
+			m.startSelection()
+			return m, nil
+
+		case key.Matches(msg, m.keymap.Reload) && m.activeTab == fileTab:
+			return m, m.reloadFile()
+
+		case key.Matches(msg, m.keymap.Blame) && m.activeTab == fileTab:
+			return m, m.toggleBlame()
+
+		case key.Matches(msg, m.keymap.Definition) && m.activeTab == fileTab:
+			return m, m.jumpToDefinition()
+
+		case key.Matches(msg, m.keymap.TagBack) && m.activeTab == fileTab && len(m.tagStack) > 0:
+			return m, m.popTag()
+
+		case key.Matches(msg, m.keymap.SearchWord) && m.activeTab == fileTab:
+			return m, m.searchWord()
+
+		case key.Matches(msg, m.keymap.FileLeft) && m.activeTab == fileTab:
+			m.scrollFile(-1)
+			return m, nil
+
+		case key.Matches(msg, m.keymap.FileRight) && m.activeTab == fileTab:
+			m.scrollFile(1)
+			return m, nil
+
+		case key.Matches(msg, m.keymap.GotoLine) && m.activeTab == fileTab:
+			return m, m.openGoto()
+
+		case key.Matches(msg, m.keymap.Top) && m.activeTab == fileTab:
+			m.fileState.pendingG = true
+			return m, nil
+
+		case key.Matches(msg, m.keymap.Bottom) && m.activeTab == fileTab:
+			m.gotoEnd(true)
+			return m, m.moreOfFile()
+
+		case key.Matches(msg, m.keymap.Live):
+			m.toggleLive()
+			return m, nil
+
+		case key.Matches(msg, m.keymap.Search) || key.Matches(msg, m.keymap.Search2):
+			if m.activeTab != searchTab {
+				m.activeTab = searchTab
+				m.helpState.filter.Blur()
+				m.searchInput.Focus()
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keymap.Back) && m.search != nil && (m.activeTab == resultsTab || m.activeTab == searchTab) && !m.resultsState.list.settingFilter():
+			m.cancelSearch()
+			return m, nil
+
+		case key.Matches(msg, m.keymap.Back):
+			switch m.activeTab {
+			case fileTab:
+				if m.fileState.find != nil {
+					m.clearFind()
+					return m, nil
+				}
+				m.activeTab = resultsTab
+				m.returnToOpened()
+				return m, m.updatePreview()
+			case resultsTab:
+				if m.resultsState.list.filterState() == list.FilterApplied {
+					m.resultsState.list.resetFilter()
+					m.syncSidebar()
+					return m, m.updatePreview()
+				}
+				m.activeTab = searchTab
+				m.searchInput.Focus()
+			case helpTab:
+				m.closeHelp()
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keymap.Enter):
+			switch m.activeTab {
+			case searchTab:
+				if m.searchInput.Value() != "" {
+					return m, m.checkedSearch()
+				}
+			case resultsTab:
+				if m.printFormat != "" {
+					return m, m.pickResults()
+				}
+				if item, ok := m.resultsState.list.selected(); ok && item.remote != nil {
+					return m, m.openRemote(item)
+				} else if ok {
+					m.activeTab = fileTab
+					m.resultsState.opened = item.key()
+					m.statusMessage = fmt.Sprintf("Viewing file: %s", item.fullPath)
+					m.statusMessageType = "info"
+					return m, m.openFile(item)
+				}
+			}
+		}
+
+	case searchStartedMsg:
+		if msg.stream.id != m.searchID {
+			msg.stream.stop()
+			return m, nil
+		}
+		m.search = msg.stream
+		return m, m.search.next()
+
+	case searchResultsMsg:
+		if msg.id != m.searchID {
+			return m, nil
+		}
+
+		if m.staleResults {
+			m.resetResults()
+		}
+		m.appendResults(msg.results)
+
+		m.statusMessage = fmt.Sprintf("Searching for: %s (%s results so far)", m.currentSearchPattern, formatCount(len(m.results)))
+		if !m.allLoaded() {
+			m.statusMessage = fmt.Sprintf("Searching for: %s (%s of %s results loaded)", m.currentSearchPattern, formatCount(m.resultsState.scanned), formatCount(len(m.results)))
+		}
+		m.statusMessageType = "info"
+		if m.search.paused {
+			m.search.held = true
+			m.statusMessage = fmt.Sprintf("Paused search for: %s (%s results so far)", m.currentSearchPattern, formatCount(len(m.results)))
+			return m, m.updatePreview()
+		}
+		return m, tea.Batch(m.search.next(), m.updatePreview())
+
+	case searchFinishedMsg:
+		if msg.id != m.searchID {
+			return m, nil
+		}
+		m.search = nil
+		if m.staleResults {
+			// Nothing matched
+			m.resetResults()
+		}
+		m.recordStats(msg.stats)
+
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %s", msg.err)
+			m.statusMessageType = "error"
+			return m, m.notifyLongSearch()
+		}
+
+		m.reportResultCount()
+		if msg.index != nil {
+			m.statusMessage += msg.index.note()
+		}
+		m.statusMessage += m.loadedDifference()
+		m.offerSuggestion(msg.stats)
+		return m, m.notifyLongSearch()
+
+	case indexTickMsg:
+		return m, m.handleIndexTick()
+
+	case indexListedMsg:
+		return m, m.handleIndexListed(msg)
+
+	case indexSteppedMsg:
+		return m, m.handleIndexStepped(msg)
+
+	case indexSavedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error saving the index of %s: %s", m.paths.show(msg.idx.Root), msg.err)
+			m.statusMessageType = "error"
+		}
+		return m, nil
+
+	case tea.MouseMsg:
+		if m.activeTab == resultsTab && m.resultsState.minimap.show && m.clickMinimap(msg) {
+			return m, m.updatePreview()
+		}
+		return m, nil
+
+	case quickfixDoneMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error running the editor: %s", msg.err)
+			m.statusMessageType = "error"
+		} else {
+			m.statusMessage = fmt.Sprintf("Opened %d results in the editor", msg.count)
+			m.statusMessageType = "info"
+		}
+		return m, nil
+
+	case pipeDoneMsg:
+		m.handlePipeDone(msg)
+		return m, nil
+
+	case webhookSentMsg:
+		m.handleWebhookSent(msg)
+		return m, nil
+
+	case editorDoneMsg:
+		return m, m.handleEditorDone(msg)
+
+	case pagerDoneMsg:
+		m.handlePagerDone(msg)
+		return m, nil
+
+	case peekDoneMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error returning from the terminal: %s", msg.err)
+			m.statusMessageType = "error"
+		}
+		return m, nil
+
+	case estimateMsg:
+		return m, m.handleEstimate(msg)
+
+	case liveSearchMsg:
+		if msg.id != m.liveID {
+			return m, nil
+		}
+		return m, m.startLiveSearch()
+
+	case remoteResultsMsg:
+		if msg.id != m.searchID {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error: %s", msg.err)
+			m.statusMessageType = "error"
+			return m, nil
+		}
+		m.appendResults(msg.results)
+		m.reportResultCount()
+		return m, m.updatePreview()
+
+	case clonedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Error cloning: %s", msg.err)
+			m.statusMessageType = "error"
+			return m, nil
+		}
+		m.activeTab = fileTab
+		m.statusMessage = fmt.Sprintf("Viewing file: %s", msg.item.fullPath)
+		m.statusMessageType = "info"
+		return m, m.openFile(msg.item)
+
+	case fileLoadedMsg:
+		// Drop renders for files we've moved on from, and the plain preview
+		// if the highlighted version beat it here
+		if msg.token != m.fileToken || (!msg.final && !m.fileLoading) {
+			return m, nil
+		}
+		if msg.final {
+			m.fileLoading = false
+			m.cancelRender = nil
+		}
+
+		if msg.err != nil {
+			if !msg.final {
+				return m, nil
+			}
+			m.statusMessage = fmt.Sprintf("Error loading file: %s", msg.err)
+			m.statusMessageType = "error"
+			m.activeTab = resultsTab
+			return m, nil
+		}
+
+		// Keep the scroll position when the highlighted version replaces
+		// the preview, if it's been scrolled since
+		replacing := m.fileState.doc != nil
+		yOffset := m.fileState.viewer.YOffset
+		m.setFileDocument(msg.doc)
+		m.fileState.binary = msg.binary
+		m.fileState.chunk = msg.chunk
+		if msg.binary {
+			// n and N go by lines, which a hex dump doesn't have
+			m.fileState.hits = nil
+		} else if msg.chunk != nil {
+			m.fileState.lineOffset = msg.chunk.lineOffset()
+		} else if msg.top == 0 && m.fileState.item.lineNum > 0 {
+			m.fileState.lineOffset = max(msg.focus-(m.fileState.item.lineNum-1), 0)
+		}
+		pos := m.fileState.resume
+		if msg.final {
+			m.fileState.resume = nil
+		}
+		if pos != nil && (!replacing || yOffset == m.fileState.autoTop) && !msg.binary && (msg.chunk == nil || msg.chunk.loaded(pos.line)) {
+			// Back where it was left last time
+			m.fileState.viewer.SetYOffset(pos.line - 1 + m.fileState.lineOffset)
+			m.fileState.xOffset = pos.xOffset
+			m.fileState.autoTop = m.fileState.viewer.YOffset
+		} else if replacing && yOffset != m.fileState.autoTop {
+			m.fileState.viewer.SetYOffset(yOffset)
+		} else {
+			if !replacing {
+				m.revealColumn(msg.matchCol)
+			}
+			m.fileState.viewer.GotoTop()
+			if msg.top > 0 {
+				m.fileState.viewer.SetYOffset(msg.top)
+			} else {
+				m.scrollToLine(msg.focus)
+			}
+			m.fileState.autoTop = m.fileState.viewer.YOffset
+		}
+		m.fileState.followFrom, m.fileState.followPartial = msg.followFrom, msg.followPartial
+		if m.fileState.follow && msg.final {
+			m.fileState.viewer.GotoBottom()
+			m.renderVisibleFile()
+			return m, followTick(m.fileToken)
+		}
+		m.renderVisibleFile()
+		if msg.final {
+			return m, tea.Batch(m.moreOfFile(), m.watchFile(), m.blameFile())
+		}
+		return m, m.moreOfFile()
+
+	case tagsFoundMsg:
+		return m, m.handleTagsFound(msg)
+
+	case blameLoadedMsg:
+		m.handleBlameLoaded(msg)
+		return m, nil
+
+	case fileWatchMsg:
+		return m, m.handleFileWatch(msg)
+
+	case fileStatMsg:
+		return m, m.handleFileStat(msg)
+
+	case chunkLoadedMsg:
+		return m, m.handleChunkLoaded(msg)
+
+	case followTickMsg:
+		return m, m.handleFollowTick(msg)
+
+	case followReadMsg:
+		return m, m.handleFollowRead(msg)
+
+	case compareLoadedMsg:
+		m.comparison = msg.comparison
+		left, right := msg.comparison.left.item, msg.comparison.right.item
+		m.statusMessage = fmt.Sprintf("Comparing %s:%d with %s:%d (%s to close)", m.paths.show(left.fileName), left.lineNum, m.paths.show(right.fileName), right.lineNum, m.keymap.Back.Help().Key)
+		m.statusMessageType = "info"
+		return m, nil
+
+	case previewLoadedMsg:
+		if msg.preview.item.key() == m.resultsState.previewing {
+			m.resultsState.preview = msg.preview
+		}
+		return m, nil
+
+	case powerCheckMsg:
+		return m, m.handlePowerCheck(msg)
+
+	case latencyCheckMsg:
+		return m, tea.Batch(m.adjustForLatency(), checkLatency())
+
+	case spinner.TickMsg:
+		if m.fileLoading && m.animated() {
+			var cmd tea.Cmd
+			m.fileSpinner, cmd = m.fileSpinner.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.ready = true
+
+		topHeight := 6  // For title and tabs with margins
+		helpHeight := 2 // For help view at the bottom
+		statusHeight := 1
+		borderHeight := 2 // Account for top and bottom borders
+
+		availableHeight := m.height - topHeight - helpHeight - statusHeight - borderHeight
+
+		// Make input boxes fit within the container while accounting for padding and borders
+		m.searchInput.Width = msg.Width - 30
+		m.directoryInput.Width = msg.Width - 30
+
+		h := availableHeight
+
+		m.listHeight = h
+		m.layoutResults()
+		m.clipboardHistory.SetSize(msg.Width-4, h)
+		m.auditLog.SetSize(msg.Width-4, h)
+		m.pinList.SetSize(msg.Width-4, h)
+		m.repoList.SetSize(msg.Width-4, h)
+		m.languageList.SetSize(msg.Width-4, h)
+		m.scopeList.SetSize(msg.Width-4, h)
+		m.dirList.SetSize(msg.Width-4, h)
+		m.tagList.SetSize(msg.Width-4, h)
+		m.exportList.SetSize(msg.Width-4, h)
+		m.sessionList.SetSize(msg.Width-4, h-2)
+		m.pipe.output.Width = msg.Width - 8
+		m.pipe.output.Height = max(h-4, 1)
+		m.fileState.viewer.Width = msg.Width - 8 // Account for left/right borders and padding
+		m.layoutFile()
+
+		m.helpState.viewport.Width = msg.Width - 8
+		m.helpState.viewport.Height = h - 2 // Leave room for the filter input
+
+		// Keep the file's scroll position, clamped to the new height
+		m.fileState.viewer.SetYOffset(m.fileState.viewer.YOffset)
+		m.renderVisibleFile()
+
+		m.help.Width = msg.Width
+
+		return m, nil
+	}
 
 	// Handle updates for different components based on active tab
 	switch m.activeTab {
@@ -564,33 +1618,99 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		var cmd tea.Cmd
+		pattern, dir := m.searchInput.Value(), m.directoryInput.Value()
 		if m.searchInput.Focused() {
 			m.searchInput, cmd = m.searchInput.Update(msg)
 		} else {
 			m.directoryInput, cmd = m.directoryInput.Update(msg)
 		}
 		cmds = append(cmds, cmd)
+		if m.searchInput.Value() != pattern || m.directoryInput.Value() != dir {
+			cmds = append(cmds, m.debounceLiveSearch())
+		}
 	case resultsTab:
 		var cmd tea.Cmd
-		m.searchResults, cmd = m.searchResults.Update(msg)
+		m.resultsState.list, cmd = m.resultsState.list.Update(msg)
 		cmds = append(cmds, cmd)
+		m.loadMoreResults()
+		m.followSidebar()
+		cmds = append(cmds, m.updatePreview())
 	case fileTab:
 		var cmd tea.Cmd
-		m.fileViewer, cmd = m.fileViewer.Update(msg)
+		m.fileState.viewer, cmd = m.fileState.viewer.Update(msg)
+		cmds = append(cmds, cmd)
+		m.renderVisibleFile()
+		cmds = append(cmds, m.moreOfFile())
+	case helpTab:
+		filter := m.helpState.filter.Value()
+		var cmd tea.Cmd
+		m.helpState.filter, cmd = m.helpState.filter.Update(msg)
+		cmds = append(cmds, cmd)
+		if m.helpState.filter.Value() != filter {
+			m.refreshHelp()
+		}
+		m.helpState.viewport, cmd = m.helpState.viewport.Update(msg)
 		cmds = append(cmds, cmd)
 	}
 
 	return m, tea.Batch(cmds...)
 }
 
-func (m model) View() string {
-	if !m.ready {
-		return "Initializing..."
+// Handle typed characters while the help tab is active
+func (m model) updateHelp(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if key.Matches(msg, m.keymap.Help) {
+		m.closeHelp()
+		return m, nil
 	}
 
-	var content string
+	var cmd tea.Cmd
+	m.helpState.filter, cmd = m.helpState.filter.Update(msg)
+	m.refreshHelp()
+	return m, cmd
+}
+
+// Handle keys while the clipboard history overlay is open
+func (m model) updateClipboard(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.clipboardHistory.SettingFilter() {
+		var cmd tea.Cmd
+		m.clipboardHistory, cmd = m.clipboardHistory.Update(msg)
+		return m, cmd
+	}
+
+	switch {
+	case key.Matches(msg, m.keymap.Quit):
+		return m, tea.Quit
+
+	case key.Matches(msg, m.keymap.Back) || key.Matches(msg, m.keymap.Clipboard):
+		m.showClipboard = false
+		return m, nil
+
+	case key.Matches(msg, m.keymap.Enter):
+		if entry, ok := m.clipboardHistory.SelectedItem().(clipboardEntry); ok {
+			m.reportCopy(entry.kind, m.copyToClipboard(entry.kind, entry.text))
+			m.showClipboard = false
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keymap.Paste):
+		if entry, ok := m.clipboardHistory.SelectedItem().(clipboardEntry); ok {
+			m.showClipboard = false
+			m.activeTab = searchTab
+			m.directoryInput.Blur()
+			m.searchInput.Focus()
+			m.searchInput.SetValue(entry.text)
+			m.searchInput.CursorEnd()
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.clipboardHistory, cmd = m.clipboardHistory.Update(msg)
+	return m, cmd
+}
 
-	// Render tabs
+func (m model) tabsView() string {
+	activeTabStyle, inactiveTabStyle := m.tabStyles()
 	var renderedTabs []string
 	for i, t := range m.tabs {
 		if tab(i) == m.activeTab {
@@ -599,27 +1719,143 @@ func (m model) View() string {
 			renderedTabs = append(renderedTabs, inactiveTabStyle.Render(t))
 		}
 	}
-	tabsView := lipgloss.JoinHorizontal(lipgloss.Center, renderedTabs...)
+	return lipgloss.JoinHorizontal(lipgloss.Center, renderedTabs...)
+}
+
+func (m model) View() string {
+	if !m.ready {
+		return "Initializing..."
+	}
+
+	var content string
+	tabsView := m.tabsView()
 
 	// Status bar
 	var statusBar string
 	if m.showStatusBar {
 		statusMsg := statusMessageStyle(m.statusMessage)
+		if m.fileLoading && m.fileState.doc != nil {
+			statusMsg = m.fileSpinner.View() + statusMsg
+		}
+		if m.confirm != nil {
+			statusMsg = m.confirmView()
+		}
+		statusMsg = m.lowPowerView() + statusMsg
 		statusBar = statusBarStyle.Width(m.width - 2).Render(statusMsg)
 	}
 
 	// Different content based on the active tab
-	switch m.activeTab {
-	case searchTab:
-		searchBox := inputBoxStyle.Render(
+	switch {
+	case m.review != nil && !m.review.staged:
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			tabsView,
+			lipgloss.NewStyle().Padding(1, 2).Render(m.reviewView()),
+		)
+	case m.showLine:
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			tabsView,
+			lipgloss.NewStyle().Padding(1, 2).Render(m.lineView()),
+		)
+	case m.comparison != nil:
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			tabsView,
+			m.compareView(),
+		)
+	case m.showStats:
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			tabsView,
+			lipgloss.NewStyle().Padding(1, 2).Render(m.statsView()),
+		)
+	case m.showClipboard:
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			tabsView,
+			m.clipboardHistory.View(),
+		)
+	case m.showAudit:
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			tabsView,
+			m.auditLog.View(),
+		)
+	case m.showPins:
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			tabsView,
+			m.pinList.View(),
+		)
+	case m.showSessions:
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			tabsView,
+			m.sessionsView(),
+		)
+	case m.pipe.show:
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			tabsView,
+			m.pipeView(),
+		)
+	case m.showLoad:
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			tabsView,
+			m.loadView(),
+		)
+	case m.showExport:
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			tabsView,
+			m.exportList.View(),
+		)
+	case m.showTags:
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			tabsView,
+			m.tagList.View(),
+		)
+	case m.showDir:
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			tabsView,
+			m.dirList.View(),
+		)
+	case m.showScopes:
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			tabsView,
+			m.scopeList.View(),
+		)
+	case m.showRepos:
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			tabsView,
+			m.repoList.View(),
+		)
+	case m.showLanguages:
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			tabsView,
+			m.languageList.View(),
+		)
+	case m.activeTab == searchTab:
+		patternLabel := "Search Pattern"
+		if m.live {
+			patternLabel += " (live)"
+		}
+		searchBox := m.bordered(inputBoxStyle).Render(
 			lipgloss.JoinVertical(
 				lipgloss.Center,
-				"Search Pattern",
+				patternLabel,
 				inputStyle.Render(m.searchInput.View()),
 			),
 		)
 
-		directoryBox := inputBoxStyle.Render(
+		directoryBox := m.bordered(inputBoxStyle).Render(
 			lipgloss.JoinVertical(
 				lipgloss.Center,
 				"Directory Path",
@@ -627,12 +1863,27 @@ func (m model) View() string {
 			),
 		)
 
-		currentDirInfo := currentDirStyle.Render(
+		currentDirInfo := m.bordered(currentDirStyle).Render(
 			fmt.Sprintf("%s %s",
 				dirIconStyle.Render("📂"),
 				m.currentPath,
 			),
 		)
+		if len(m.excludes) > 0 {
+			currentDirInfo = lipgloss.JoinVertical(lipgloss.Center, currentDirInfo, m.excludesView())
+		}
+		if len(m.flags) > 0 {
+			currentDirInfo = lipgloss.JoinVertical(lipgloss.Center, currentDirInfo, m.flagsView())
+		}
+		if scope := m.scopeView(); scope != "" {
+			currentDirInfo = lipgloss.JoinVertical(lipgloss.Center, currentDirInfo, scope)
+		}
+		if index := m.indexView(); index != "" {
+			currentDirInfo = lipgloss.JoinVertical(lipgloss.Center, currentDirInfo, index)
+		}
+		if len(m.stdinFiles) > 0 && m.directoryInput.Value() == "" {
+			currentDirInfo = lipgloss.JoinVertical(lipgloss.Center, currentDirInfo, m.stdinFilesView())
+		}
 
 		content = containerStyle.Width(m.width - 4).Render(
 			lipgloss.JoinVertical(
@@ -643,47 +1894,297 @@ func (m model) View() string {
 				currentDirInfo,
 			),
 		)
-	case resultsTab:
+	case m.activeTab == resultsTab:
+		var filterBar string
+		if m.resultsState.jumping {
+			filterBar = m.jumpView()
+		} else if m.showResultFilter() {
+			filterBar = m.resultFilterView()
+		}
+		results := m.resultsState.list.View()
+		if m.resultsState.minimap.show {
+			results = lipgloss.JoinHorizontal(lipgloss.Top, lipgloss.NewStyle().Width(m.resultsState.list.width).Render(results), m.minimapView())
+		}
+		if m.resultsState.showSidebar {
+			results = lipgloss.JoinHorizontal(lipgloss.Top, m.sidebarView(), results)
+		}
+		if m.previewShown() {
+			results = lipgloss.JoinHorizontal(lipgloss.Top, results, m.previewView())
+		}
 		content = lipgloss.JoinVertical(
 			lipgloss.Left,
 			tabsView,
-			m.searchResults.View(),
+			filterBar+results,
 		)
-	case fileTab:
+	case m.activeTab == fileTab:
+		var fileBar string
+		if m.fileState.goingTo {
+			fileBar = m.gotoView()
+		} else if m.showFindBar() {
+			fileBar = m.findView()
+		}
 		content = lipgloss.JoinVertical(
 			lipgloss.Left,
 			tabsView,
-			m.fileViewer.View(),
+			fileBar+m.fileView(),
+		)
+	case m.activeTab == helpTab:
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			tabsView,
+			m.helpTabView(),
 		)
 	}
 
 	// Help view
 	helpView := m.help.View(m.keymap)
 
+	title := "LazyRG - Interactive Ripgrep TUI"
+	if m.readOnly {
+		title += "  " + readOnlyStyle.Render("READ-ONLY")
+	}
+	if m.sandbox {
+		title += "  " + sandboxStyle.Render("SANDBOX")
+	}
+
 	return fmt.Sprintf(
 		"%s\n%s\n%s\n%s",
-		titleStyle.Width(m.width-2).Render("LazyRG - Interactive Ripgrep TUI"),
+		titleStyle.Width(m.width-2).Render(title),
 
-		docStyle.Width(m.width-4).Height(m.height-7).Render(content),
+		m.docStyle().Width(m.width-4).Height(m.height-7).Render(content),
 		statusBar,
 		helpView,
 	)
 }
 
 func main() {
-	logFile, err := os.OpenFile("lazyrg.log", os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	configPath := flag.String("config", defaultConfigPath(), "path to the config file")
+	focus := flag.String("focus", "", "what has focus at startup: pattern, directory or results")
+	useGitRoot := flag.Bool("git-root", false, "search from the root of the git repository")
+	readOnly := flag.Bool("read-only", false, "disable everything that changes files")
+	relativePaths := flag.Bool("relative-paths", false, "show paths relative to the search directory")
+	truncateMiddle := flag.Bool("truncate-middle", false, "truncate long paths from the middle")
+	repos := flag.String("repos", "", "comma-separated git repositories, or directories of clones, to search together")
+	live := flag.Bool("live", false, "search as you type")
+	icons := flag.String("icons", "", "file type icons in results: nerd, ascii or off")
+	highlight := flag.String("highlighter", "", "syntax highlighting in the file viewer: chroma, bat or off")
+	lowPower := flag.String("low-power", "", "low-power mode: auto (on battery), on or off")
+	sandbox := flag.Bool("sandbox", false, "run rg with no network or home directory access (needs bwrap or firejail)")
+	importPath := flag.String("import", "", "open a session file exported with alt+e, read-only")
+	printSelection := flag.Bool("print-selection", false, "pick results: enter quits and prints the selected (or marked) results")
+	printFormat := flag.String("print-format", printLocation, "what -print-selection prints for each result: location (path:line) or path")
+	quickfixPath := flag.String("quickfix", "", "on quitting, write the results as a quickfix list (file:line:col:text) to this file, or - for stdout")
+	noTUI := flag.Bool("no-tui", false, "search without the interface and print the results; exits 0 if any were found, 1 if none, 2 on errors")
+	format := flag.String("format", formatText, "what -no-tui prints: "+headlessFormatNames())
+	patternFlag := flag.String("pattern", "", "the pattern to search for, instead of giving it as an argument")
+	pathFlag := flag.String("path", "", "the directory to search, instead of giving it as an argument")
+	types := flag.String("type", "", "comma-separated rg file types to search, like go or go,proto")
+	jsonPath := flag.String("json", "", "search without the interface and write the results as JSON to this file, or - for stdout")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: lazyrg [flags] [pattern [path]]\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	pattern, path := flag.Arg(0), flag.Arg(1)
+	if *patternFlag != "" {
+		if flag.NArg() > 0 {
+			fmt.Fprintf(os.Stderr, "error: give the pattern with -pattern or as an argument, not both\n")
+			os.Exit(2)
+		}
+		pattern = *patternFlag
+	}
+	if *pathFlag != "" {
+		if flag.NArg() > 1 {
+			fmt.Fprintf(os.Stderr, "error: give the path with -path or as an argument, not both\n")
+			os.Exit(2)
+		}
+		path = *pathFlag
+	}
+	if *jsonPath != "" {
+		// Batch mode: -no-tui with the JSON going to a file
+		if *format != formatText && *format != "json" {
+			fmt.Fprintf(os.Stderr, "error: -json writes JSON, so it can't be combined with -format %s\n", *format)
+			os.Exit(2)
+		}
+		*noTUI = true
+		*format = "json"
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	projectDir := path
+	if projectDir == "" {
+		projectDir = "."
+	}
+	project, err := findProjectConfig(projectDir)
+	if err == nil && project != nil {
+		err = project.apply(&cfg)
+	}
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "error opening log file: %v\n", err)
+		fmt.Fprintf(os.Stderr, "error loading project config: %v\n", err)
 		os.Exit(1)
 	}
-	defer logFile.Close()
+	if *focus != "" {
+		cfg.Focus = *focus
+	}
+	if *useGitRoot {
+		cfg.GitRoot = true
+	}
+	if *readOnly {
+		cfg.ReadOnly = true
+	}
+	if *relativePaths {
+		cfg.RelativePaths = true
+	}
+	if *truncateMiddle {
+		cfg.TruncateMiddle = true
+	}
+	if *sandbox {
+		cfg.Sandbox = true
+	}
+	if *live {
+		cfg.Live = true
+	}
+	if *icons != "" {
+		cfg.Icons = *icons
+	}
+	if *highlight != "" {
+		cfg.Highlighter = *highlight
+	}
+	if *lowPower != "" {
+		cfg.LowPower = *lowPower
+	}
+	if *repos != "" {
+		cfg.Repos = strings.Split(*repos, ",")
+	}
+	if *importPath != "" && pattern != "" {
+		fmt.Fprintf(os.Stderr, "error: -import can't be combined with a pattern\n")
+		os.Exit(2)
+	}
+	if *printFormat != printLocation && *printFormat != printPath {
+		fmt.Fprintf(os.Stderr, "error: -print-format must be %q or %q, not %q\n", printLocation, printPath, *printFormat)
+		os.Exit(2)
+	}
+	headless, ok := headlessFormat(*format)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "error: -format must be one of %s, not %q\n", headlessFormatNames(), *format)
+		os.Exit(2)
+	}
+	if *noTUI && (*printSelection || *quickfixPath != "") {
+		fmt.Fprintf(os.Stderr, "error: -no-tui and -json print the results themselves, so they can't be combined with -print-selection or -quickfix\n")
+		os.Exit(2)
+	}
+	if err := cfg.validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+	if cfg.Repos, err = findRepos(cfg.Repos); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+
+	// The log goes in the current directory, which read-only mode
+	// shouldn't write to either
+	if cfg.ReadOnly {
+		log.SetOutput(io.Discard)
+	} else {
+		logFile, err := os.OpenFile("lazyrg.log", os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error opening log file: %v\n", err)
+			os.Exit(1)
+		}
+		defer logFile.Close()
 
-	log.SetOutput(logFile)
+		log.SetOutput(logFile)
+	}
 	log.Println("Starting LazyRG")
 
-	p := tea.NewProgram(initialModel(), tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	// A list of files piped in, like git diff --name-only's, is what gets
+	// searched, and the keyboard is read from the terminal instead
+	var stdinFiles []string
+	var programOptions []tea.ProgramOption
+	if stdinPiped() && *importPath == "" {
+		if stdinFiles, err = readFileList(os.Stdin); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(2)
+		}
+		if !*noTUI {
+			programOptions = append(programOptions, tea.WithInputTTY())
+		}
+	}
+
+	m := initialModel()
+	m.stdinFiles = stdinFiles
+	if *printSelection {
+		m.printFormat = *printFormat
+	}
+	if *types != "" {
+		// Before applyConfig, which starts the first search
+		m.scope = typesScope(*types)
+	}
+	m.applyConfig(cfg, pattern, path)
+	if project != nil && project.needsTrust() {
+		if *noTUI {
+			fmt.Fprintf(os.Stderr, "warning: %s isn't trusted yet, so its commands are left out\n", project.path)
+		} else {
+			m.askTrust(project)
+		}
+	}
+	if err := m.restorePins(); err != nil {
+		log.Printf("Error loading pins: %v", err)
+	}
+	if m.ignoreRules, err = loadIgnoreRules(); err != nil {
+		log.Printf("Error loading false positives: %v", err)
+	}
+	if *importPath != "" {
+		if err := m.importSession(*importPath); err != nil {
+			fmt.Fprintf(os.Stderr, "error importing session: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *jsonPath != "" {
+		os.Exit(m.runHeadlessTo(headless, *jsonPath, *importPath == ""))
+	}
+	if *noTUI {
+		os.Exit(m.runHeadless(headless, os.Stdout, *importPath == ""))
+	}
+	// With the quickfix list or the picked results going to stdout, the
+	// interface is drawn on the terminal instead, so they can be piped on
+	out := os.Stdout
+	if *quickfixPath == "-" || *printSelection {
+		if tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0); err == nil {
+			defer tty.Close()
+			out = tty
+		}
+	}
+	m.renderMonitor = newRenderMonitor(out)
+
+	p := tea.NewProgram(m, append(programOptions, tea.WithAltScreen(), tea.WithOutput(m.renderMonitor))...)
+	final, err := p.Run()
+	if err != nil {
 		log.Fatalf("Error running program: %v", err)
 		os.Exit(1)
 	}
+	last := final.(model)
+	if last.search != nil {
+		// Don't leave rg running, or stopped for good if it was paused
+		last.search.stop()
+	}
+	if *quickfixPath != "" {
+		if err := last.writeQuickfixOnExit(*quickfixPath); err != nil {
+			fmt.Fprintf(os.Stderr, "error writing the quickfix list: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *printSelection {
+		if last.picked == "" {
+			// Nothing was picked, so a script can tell
+			os.Exit(1)
+		}
+		fmt.Println(last.picked)
+	}
 }
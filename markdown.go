@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+// How many lines either side of a match the Markdown report shows
+const markdownContext = 2
+
+// What a Markdown report template is given
+type markdownReport struct {
+	Pattern   string
+	Root      string
+	Generated string
+	Count     int // results
+	Files     []markdownFile
+}
+
+type markdownFile struct {
+	Path     string // relative to Root
+	Language string // for the code fences, or empty
+	Link     string // from linkTemplate, to the first result
+	Count    int
+	Snippets []markdownSnippet
+}
+
+// A run of lines with one or more matches in it, and the lines around them
+type markdownSnippet struct {
+	First, Last int
+	Lines       []int // the matched lines
+	Link        string
+	Code        string
+	Fence       string // ``` or longer, to fence in Code
+}
+
+// Results grouped by file, in the order they were found
+func groupByFile(items []Item) [][]Item {
+	var files [][]Item
+	index := map[string]int{}
+	for _, item := range items {
+		i, ok := index[item.fullPath]
+		if !ok {
+			i = len(files)
+			index[item.fullPath] = i
+			files = append(files, nil)
+		}
+		files[i] = append(files[i], item)
+	}
+	return files
+}
+
+// The file's results as snippets with context, those close enough to
+// overlap run together
+func markdownSnippets(items []Item, root, linkTemplate string) []markdownSnippet {
+	lines := readAllLines(items[0].fullPath)
+	var snippets []markdownSnippet
+	for _, item := range items {
+		if len(lines) == 0 || item.lineNum < 1 || item.lineNum > len(lines) {
+			// Remote results, and files that have changed since, get just
+			// the line as it was found
+			snippets = append(snippets, markdownSnippet{
+				First: item.lineNum, Last: item.lineNum, Lines: []int{item.lineNum},
+				Link: resultLink(linkTemplate, root, item),
+				Code: item.indent + item.content,
+			})
+			continue
+		}
+		first, last := max(item.lineNum-markdownContext, 1), min(item.lineNum+markdownContext, len(lines))
+		if n := len(snippets); n > 0 && snippets[n-1].Code == "" && first <= snippets[n-1].Last+1 {
+			snippets[n-1].Last = max(snippets[n-1].Last, last)
+			snippets[n-1].Lines = append(snippets[n-1].Lines, item.lineNum)
+			continue
+		}
+		snippets = append(snippets, markdownSnippet{
+			First: first, Last: last, Lines: []int{item.lineNum},
+			Link: resultLink(linkTemplate, root, item),
+		})
+	}
+	for i := range snippets {
+		if snippets[i].Code == "" {
+			snippets[i].Code = strings.Join(lines[snippets[i].First-1:snippets[i].Last], "\n")
+		}
+		snippets[i].Fence = codeFence(snippets[i].Code)
+	}
+	return snippets
+}
+
+// Every line of a file, or none if it can't be read
+func readAllLines(path string) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	return lines
+}
+
+// A fence longer than any run of backticks in code
+func codeFence(code string) string {
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fence
+}
+
+// The name Markdown renderers know a file's language by, for its fences
+func fenceLanguage(path string) string {
+	if lexer := lexers.Match(filepath.Base(path)); lexer != nil {
+		if aliases := lexer.Config().Aliases; len(aliases) > 0 {
+			return aliases[0]
+		}
+	}
+	return ""
+}
+
+var defaultMarkdownTemplate = template.Must(template.New("markdown").Parse(`# Results for ` + "`{{.Pattern}}`" + `
+
+{{.Count}} results in {{len .Files}} files in ` + "`{{.Root}}`" + ` · {{.Generated}}
+{{range $file := .Files}}
+## {{if .Link}}[{{.Path}}]({{.Link}}){{else}}{{.Path}}{{end}}
+{{range .Snippets}}
+{{if eq .First .Last}}Line {{.First}}{{else}}Lines {{.First}}–{{.Last}}{{end}}{{if .Link}} ([view]({{.Link}})){{end}}
+
+{{.Fence}}{{$file.Language}}
+{{.Code}}
+{{.Fence}}
+{{end}}{{end}}`))
+
+// A Markdown report of the results for a PR or an issue: a section for
+// each file, with the matches in code blocks along with the lines around
+// them. The config's markdownTemplate, a Go text/template file, can lay it
+// out differently.
+func writeMarkdown(w io.Writer, data exportData) error {
+	tmpl := defaultMarkdownTemplate
+	if data.markdownTemplate != "" {
+		path := data.markdownTemplate
+		if rest, ok := strings.CutPrefix(path, "~/"); ok {
+			if home, err := os.UserHomeDir(); err == nil {
+				path = filepath.Join(home, rest)
+			}
+		}
+		text, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if tmpl, err = template.New(filepath.Base(path)).Parse(string(text)); err != nil {
+			return fmt.Errorf("markdownTemplate: %w", err)
+		}
+	}
+
+	report := markdownReport{
+		Pattern:   data.pattern,
+		Root:      data.root,
+		Generated: time.Now().Format("2006-01-02 15:04"),
+		Count:     len(data.items),
+	}
+	for _, items := range groupByFile(data.items) {
+		report.Files = append(report.Files, markdownFile{
+			Path:     exportPath(data.root, items[0].fullPath),
+			Language: fenceLanguage(items[0].fullPath),
+			Link:     resultLink(data.linkTemplate, data.root, items[0]),
+			Count:    len(items),
+			Snippets: markdownSnippets(items, data.root, data.linkTemplate),
+		})
+	}
+	return tmpl.Execute(w, report)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Columns the minimap takes beside the results list, including its margin
+const minimapWidth = 3
+
+// From no results to the most in any row
+var minimapShades = []string{"  ", "░░", "▒▒", "▓▓", "██"}
+
+var (
+	minimapStyle = lipgloss.NewStyle().
+			Foreground(subtle).
+			MarginLeft(1)
+
+	minimapCurrentStyle = lipgloss.NewStyle().
+				Foreground(special)
+)
+
+// A file in the minimap: where its results start, and how many there are
+type minimapFile struct {
+	path  string
+	first int // index in the result set
+	count int
+}
+
+// An overview of the whole result set, loaded or not, in file order. Each
+// row covers an equal share of the files and is shaded by how many results
+// they have. Counting is cached, and extended as results stream in.
+type minimap struct {
+	show    bool
+	scanned int
+	files   []minimapFile
+	index   map[string]int // path to position in files
+}
+
+func (mm *minimap) reset() {
+	mm.scanned = 0
+	mm.files = nil
+	mm.index = map[string]int{}
+}
+
+// Count the results that get through the result filter, from where the
+// last count stopped
+func (mm *minimap) update(results []Item, filter *resultFilter) {
+	if mm.index == nil || mm.scanned > len(results) {
+		mm.reset()
+	}
+	for ; mm.scanned < len(results); mm.scanned++ {
+		result := results[mm.scanned]
+		if !filter.keep(result) {
+			continue
+		}
+		i, ok := mm.index[result.fullPath]
+		if !ok {
+			i = len(mm.files)
+			mm.index[result.fullPath] = i
+			mm.files = append(mm.files, minimapFile{path: result.fullPath, first: mm.scanned})
+		}
+		mm.files[i].count++
+	}
+}
+
+// How many rows the files are spread over, at most height
+func (mm *minimap) rows(height int) int {
+	return min(height, len(mm.files))
+}
+
+// The files in row r of rows
+func (mm *minimap) span(r, rows int) (int, int) {
+	n := len(mm.files)
+	return r * n / rows, (r + 1) * n / rows
+}
+
+// The row of rows the file at index i is in
+func (mm *minimap) row(i, rows int) int {
+	return ((i+1)*rows - 1) / len(mm.files)
+}
+
+func (m model) minimapWidth() int {
+	if !m.resultsState.minimap.show {
+		return 0
+	}
+	return minimapWidth
+}
+
+// Show or hide the minimap. Clicking it needs the mouse, which is only
+// captured while it's showing so the terminal's own selection still works
+// the rest of the time.
+func (m *model) toggleMinimap() tea.Cmd {
+	mm := m.resultsState.minimap
+	mm.show = !mm.show
+	m.layoutResults()
+	if mm.show {
+		return tea.EnableMouseCellMotion
+	}
+	return tea.DisableMouse
+}
+
+// The minimap row the selected result is in
+func (m *model) minimapRow(rows int) (int, bool) {
+	mm := m.resultsState.minimap
+	item, ok := m.resultsState.list.selected()
+	if !ok || rows == 0 {
+		return 0, false
+	}
+	i, ok := mm.index[item.fullPath]
+	if !ok {
+		return 0, false
+	}
+	return mm.row(i, rows), true
+}
+
+// Move to the first result of the next (dir > 0) or previous minimap row
+func (m *model) stepMinimap(dir int) {
+	mm := m.resultsState.minimap
+	mm.update(m.results, m.resultsState.filter)
+	rows := mm.rows(m.resultsState.list.height)
+	r, ok := m.minimapRow(rows)
+	if !ok {
+		return
+	}
+	m.jumpMinimap(min(max(r+dir, 0), rows-1))
+}
+
+// Select the first result in minimap row r, loading results up to it if
+// it's past the loaded pages
+func (m *model) jumpMinimap(r int) {
+	mm := m.resultsState.minimap
+	rows := mm.rows(m.resultsState.list.height)
+	if r < 0 || r >= rows {
+		return
+	}
+	lo, _ := mm.span(r, rows)
+	m.selectResult(mm.files[lo].first)
+}
+
+// Jump to the row of a click on the minimap
+func (m *model) clickMinimap(msg tea.MouseMsg) bool {
+	if msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft {
+		return false
+	}
+	doc := m.docStyle()
+	top := lipgloss.Height(titleStyle.Render("")) + doc.GetMarginTop() + doc.GetBorderTopSize() + doc.GetPaddingTop() + lipgloss.Height(m.tabsView())
+	if m.showResultsBar() {
+		top += 2
+	}
+	left := doc.GetMarginLeft() + doc.GetBorderLeftSize() + doc.GetPaddingLeft() + m.sidebarWidth() + m.resultsState.list.width + minimapStyle.GetMarginLeft()
+	if msg.X < left || msg.X >= left+minimapWidth || msg.Y < top {
+		return false
+	}
+	m.jumpMinimap(msg.Y - top)
+	return true
+}
+
+func (m model) minimapView() string {
+	mm := m.resultsState.minimap
+	mm.update(m.results, m.resultsState.filter)
+
+	height := m.resultsState.list.height
+	rows := mm.rows(height)
+	counts := make([]int, rows)
+	most := 0
+	for r := range counts {
+		lo, hi := mm.span(r, rows)
+		for _, file := range mm.files[lo:hi] {
+			counts[r] += file.count
+		}
+		most = max(most, counts[r])
+	}
+	current, hasCurrent := m.minimapRow(rows)
+
+	lines := make([]string, height)
+	for r := range lines {
+		shade := minimapShades[0]
+		if r < rows && counts[r] > 0 {
+			// Any results at all get at least the lightest shade
+			shade = minimapShades[max(1, counts[r]*(len(minimapShades)-1)/most)]
+		}
+		if hasCurrent && r == current {
+			shade = minimapCurrentStyle.Render(shade)
+		}
+		lines[r] = shade
+	}
+	return minimapStyle.Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type pagerDoneMsg struct {
+	path string
+	err  error
+}
+
+// The pager from $PAGER, or less
+func pagerCommand() []string {
+	if fields := strings.Fields(os.Getenv("PAGER")); len(fields) > 0 {
+		return fields
+	}
+	return []string{"less"}
+}
+
+// The pager's command line for opening path at line. less puts the line in
+// the middle of the screen, more and most start at it, and other pagers
+// just get the file.
+func pagerArgs(pager []string, path string, line int) []string {
+	args := append([]string{}, pager...)
+	if line > 0 {
+		switch editorName(args[0]) {
+		case "less":
+			args = append(args, "-j.5", fmt.Sprintf("+%dg", line))
+		case "more", "most":
+			args = append(args, fmt.Sprintf("+%d", line))
+		}
+	}
+	return append(args, path)
+}
+
+// Hand the terminal to the pager for the selected result's file, or the
+// one in the file view, until it quits
+func (m *model) openInPager() tea.Cmd {
+	path, line, _, ok := m.editorTarget()
+	if !ok {
+		m.statusMessage = "No file on disk to page through"
+		m.statusMessageType = "error"
+		return nil
+	}
+	args := pagerArgs(pagerCommand(), path, line)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = m.terminalFile()
+	if m.readOnly {
+		// less can open an editor, save the file elsewhere and run shell
+		// commands, none of which it does with LESSSECURE set
+		cmd.Env = append(os.Environ(), "LESSSECURE=1")
+	}
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return pagerDoneMsg{path: path, err: err}
+	})
+}
+
+func (m *model) handlePagerDone(msg pagerDoneMsg) {
+	if msg.err != nil {
+		m.statusMessage = fmt.Sprintf("Error running the pager: %s", msg.err)
+		m.statusMessageType = "error"
+		return
+	}
+	m.statusMessage = fmt.Sprintf("Back from paging through %s", m.paths.show(msg.path))
+	m.statusMessageType = "info"
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// How result paths are shown. Shared between the model and the results
+// delegate, like the marked set, so toggling takes effect on the next draw.
+type pathDisplay struct {
+	relative bool   // relative to root, with the home directory as ~ outside it
+	middle   bool   // truncate long paths from the middle, keeping the file name
+	links    bool   // make paths terminal hyperlinks to their files
+	root     string // the directory that was searched
+	home     string
+	host     string // for file:// links, so a terminal can tell a remote file from a local one
+}
+
+func newPathDisplay() *pathDisplay {
+	home, _ := os.UserHomeDir()
+	host, _ := os.Hostname()
+	return &pathDisplay{home: home, host: host}
+}
+
+// The path as it should be shown
+func (d *pathDisplay) show(path string) string {
+	if !d.relative {
+		return path
+	}
+	if rel, err := filepath.Rel(d.root, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return rel
+	}
+	if d.home != "" && strings.HasPrefix(path, d.home+string(filepath.Separator)) {
+		return "~" + path[len(d.home):]
+	}
+	return path
+}
+
+// Text made into an OSC 8 hyperlink to a line of a file, which terminals
+// like iTerm2, WezTerm and kitty open with their handler for file://
+// URLs. The line goes in the fragment, where kitty's open-actions (and
+// other handlers set up for it) can pick it up.
+func (d *pathDisplay) link(path string, line int, text string) string {
+	if !d.links || text == "" || !filepath.IsAbs(path) {
+		return text
+	}
+	uri := url.URL{Scheme: "file", Host: d.host, Path: filepath.ToSlash(path)}
+	if line > 0 {
+		uri.Fragment = strconv.Itoa(line)
+	}
+	return ansi.SetHyperlink(uri.String()) + text + ansi.ResetHyperlink()
+}
+
+// Fit a path into width, cutting it from the end or, with middle on, from
+// the middle so the file name stays visible
+func (d *pathDisplay) truncate(path string, width int) string {
+	if !d.middle || ansi.StringWidth(path) <= width || width < 3 {
+		return ansi.Truncate(path, width, "…")
+	}
+	head := (width - 1) / 2
+	tail := width - 1 - head
+	return ansi.Truncate(path, head, "") + "…" + ansi.TruncateLeft(path, ansi.StringWidth(path)-tail, "")
+}
+
+// Switch between absolute paths and paths relative to the search directory
+func (m *model) togglePaths() {
+	m.paths.relative = !m.paths.relative
+	m.syncSidebar()
+	if m.paths.relative {
+		m.statusMessage = "Showing paths relative to the search directory"
+	} else {
+		m.statusMessage = "Showing absolute paths"
+	}
+	m.statusMessageType = "info"
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Pause the running search, or resume it. A paused search's results stop
+// being read, so rg blocks once the pipe fills, and where the platform
+// allows rg is stopped outright so it doesn't use any CPU either.
+func (m *model) togglePause() tea.Cmd {
+	s := m.search
+	if s == nil {
+		m.statusMessage = "No search running"
+		m.statusMessageType = "error"
+		return nil
+	}
+
+	if !s.paused {
+		s.paused = true
+		if err := suspendProcess(s.cmd.Process); err != nil {
+			log.Printf("Error stopping rg: %v", err)
+		}
+		m.statusMessage = fmt.Sprintf("Paused search for: %s (%s results so far)", m.currentSearchPattern, formatCount(len(m.results)))
+		m.statusMessageType = "info"
+		return nil
+	}
+
+	s.paused = false
+	if err := resumeProcess(s.cmd.Process); err != nil {
+		log.Printf("Error continuing rg: %v", err)
+	}
+	m.statusMessage = fmt.Sprintf("Searching for: %s (%s results so far)", m.currentSearchPattern, formatCount(len(m.results)))
+	m.statusMessageType = "info"
+	if s.held {
+		s.held = false
+		return s.next()
+	}
+	return nil
+}
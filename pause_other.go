@@ -0,0 +1,11 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// Without job control signals a paused search is only held back by its
+// output not being read
+func suspendProcess(*os.Process) error { return nil }
+
+func resumeProcess(*os.Process) error { return nil }
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// What permalinks to a repository's files are built from: where it's
+// hosted and the commit checked out
+type gitRepo struct {
+	root   string // the top level of the checkout
+	web    string // https://host/owner/name
+	gitlab bool
+	commit string
+}
+
+// Run git in dir and return what it printed, or what it complained about
+func gitOutput(dir string, args ...string) (string, error) {
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+	if err != nil {
+		if exit, ok := err.(*exec.ExitError); ok && len(exit.Stderr) > 0 {
+			err = fmt.Errorf("%s", strings.TrimSpace(string(exit.Stderr)))
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// The repository dir is in, with the web address of its remote: the one
+// the current branch tracks, or origin
+func loadGitRepo(dir string) (*gitRepo, error) {
+	root, err := gitRoot(dir)
+	if err != nil {
+		return nil, fmt.Errorf("not in a git repository")
+	}
+	remote := "origin"
+	if branch, err := gitOutput(root, "symbolic-ref", "--short", "-q", "HEAD"); err == nil && branch != "" {
+		if tracked, err := gitOutput(root, "config", "--get", "branch."+branch+".remote"); err == nil && tracked != "" && tracked != "." {
+			remote = tracked
+		}
+	}
+	remoteURL, err := gitOutput(root, "remote", "get-url", remote)
+	if err != nil {
+		return nil, fmt.Errorf("no %s remote", remote)
+	}
+	web, gitlab, err := remoteWebURL(remoteURL)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := gitOutput(root, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("no commits yet")
+	}
+	return &gitRepo{root: root, web: web, gitlab: gitlab, commit: commit}, nil
+}
+
+// The https address of a GitHub or GitLab repository from the URL it's
+// cloned from: git@host:owner/name.git, ssh://git@host:22/owner/name.git,
+// https://host/owner/name and the like
+func remoteWebURL(remote string) (string, bool, error) {
+	host, path := "", ""
+	if u, err := url.Parse(remote); err == nil && u.Scheme != "" && u.Host != "" {
+		host, path = u.Hostname(), u.Path
+	} else if at, rest, ok := strings.Cut(remote, ":"); ok && !strings.Contains(at, "/") {
+		// scp-style, with an optional user
+		_, host, _ = strings.Cut(at, "@")
+		if host == "" {
+			host = at
+		}
+		path = rest
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if host == "" || !strings.Contains(path, "/") {
+		return "", false, fmt.Errorf("can't link to %s", remote)
+	}
+	switch {
+	case strings.Contains(host, "github"):
+		return "https://" + host + "/" + path, false, nil
+	case strings.Contains(host, "gitlab"):
+		return "https://" + host + "/" + path, true, nil
+	}
+	return "", false, fmt.Errorf("%s isn't GitHub or GitLab, so there's no permalink for it", host)
+}
+
+// A link to the line at the checked-out commit
+func (r *gitRepo) permalink(path string, line int) (string, error) {
+	rel, err := filepath.Rel(r.root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("%s is outside %s", path, r.root)
+	}
+	rel = (&url.URL{Path: filepath.ToSlash(rel)}).EscapedPath()
+	blob := "/blob/"
+	if r.gitlab {
+		blob = "/-/blob/"
+	}
+	return fmt.Sprintf("%s%s%s/%s#L%d", r.web, blob, r.commit, rel, line), nil
+}
+
+// Copy permalinks to the selected results, one per line. A file that
+// isn't committed has none, and one with uncommitted changes gets a
+// warning, as its lines may not match the commit's.
+func (m *model) copyPermalinks() {
+	items := m.selectedResults()
+	if len(items) == 0 {
+		return
+	}
+	repos := map[string]*gitRepo{}
+	var links []string
+	changed := 0
+	for _, item := range items {
+		if item.remote != nil {
+			m.statusMessage = "Remote results have no permalink"
+			m.statusMessageType = "error"
+			return
+		}
+		dir := filepath.Dir(item.fullPath)
+		repo, ok := repos[dir]
+		if !ok {
+			var err error
+			if repo, err = loadGitRepo(dir); err != nil {
+				m.statusMessage = fmt.Sprintf("No permalink for %s: %s", m.paths.show(item.fullPath), err)
+				m.statusMessageType = "error"
+				return
+			}
+			repos[dir] = repo
+		}
+		status, err := gitOutput(repo.root, "status", "--porcelain", "--", item.fullPath)
+		if err == nil && strings.HasPrefix(status, "??") {
+			m.statusMessage = fmt.Sprintf("No permalink for %s: it isn't committed", m.paths.show(item.fullPath))
+			m.statusMessageType = "error"
+			return
+		}
+		if status != "" {
+			changed++
+		}
+		link, err := repo.permalink(item.fullPath, item.lineNum)
+		if err != nil {
+			m.statusMessage = fmt.Sprintf("No permalink: %s", err)
+			m.statusMessageType = "error"
+			return
+		}
+		links = append(links, link)
+	}
+	what := "permalink"
+	if len(links) > 1 {
+		what = fmt.Sprintf("%d permalinks", len(links))
+	}
+	m.reportCopy(what, m.copyToClipboard("permalink", strings.Join(links, "\n")))
+	if changed > 0 && m.statusMessageType == "info" {
+		m.statusMessage += " (with uncommitted changes, so lines may be off)"
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const pinMarker = "📌 "
+
+// A result pinned for later, kept across searches and sessions
+type pin struct {
+	Path    string    `json:"path"`
+	Line    int       `json:"line"`
+	Column  int       `json:"column"`
+	Content string    `json:"content"`
+	Pattern string    `json:"pattern"` // the search that found it
+	Pinned  time.Time `json:"pinned"`
+
+	imported bool // from an imported session, so not saved with your own
+}
+
+func (p pin) Title() string {
+	return fmt.Sprintf("%s:%d:%d", p.Path, p.Line, p.Column)
+}
+
+func (p pin) Description() string {
+	desc := fmt.Sprintf("%s · %q · %s", p.Content, p.Pattern, p.Pinned.Format("2006-01-02 15:04"))
+	if p.imported {
+		desc += " · imported"
+	}
+	return desc
+}
+
+func (p pin) FilterValue() string { return p.Path + " " + p.Content }
+
+func (p pin) key() resultKey {
+	return resultKey{path: p.Path, line: p.Line, column: p.Column}
+}
+
+func (p pin) item() Item {
+	return Item{fileName: p.Path, fullPath: p.Path, lineNum: p.Line, column: p.Column, content: p.Content}
+}
+
+func pinsPath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "pins.json"), nil
+}
+
+func loadPins() ([]pin, error) {
+	path, err := pinsPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var pins []pin
+	return pins, json.Unmarshal(data, &pins)
+}
+
+// Write the pins out whole, through a temporary file so a crash can't
+// leave them half written
+func savePins(pins []pin) error {
+	path, err := pinsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(pins, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func newPinList() list.Model {
+	pinList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	pinList.Title = "Pins"
+	pinList.SetShowHelp(false)
+	pinList.SetStatusBarItemName("pin", "pins")
+	pinList.Styles.Title = lipgloss.NewStyle().
+		Foreground(special).
+		Bold(true).
+		MarginLeft(2)
+	return pinList
+}
+
+// Load the saved pins into the panel
+func (m *model) restorePins() error {
+	pins, err := loadPins()
+	if err != nil {
+		return err
+	}
+	items := make([]list.Item, len(pins))
+	for i, p := range pins {
+		items[i] = p
+		m.pinned[p.key()] = true
+	}
+	m.pinList.SetItems(items)
+	return nil
+}
+
+// Pin the selected result, or unpin it if it's pinned already
+func (m *model) togglePin() {
+	item, ok := m.resultsState.list.selected()
+	if !ok {
+		return
+	}
+
+	if m.pinned[item.key()] {
+		m.removePin(item.key())
+		m.statusMessage = fmt.Sprintf("Unpinned %s", item.Title())
+	} else {
+		p := pin{
+			Path:    item.fullPath,
+			Line:    item.lineNum,
+			Column:  item.column,
+			Content: item.content,
+			Pattern: m.currentSearchPattern,
+			Pinned:  time.Now(),
+		}
+		m.pinned[p.key()] = true
+		m.pinList.InsertItem(0, p)
+		m.statusMessage = fmt.Sprintf("Pinned %s", item.Title())
+	}
+	m.statusMessageType = "info"
+	m.persistPins()
+}
+
+func (m *model) removePin(k resultKey) {
+	delete(m.pinned, k)
+	for i, listItem := range m.pinList.Items() {
+		if listItem.(pin).key() == k {
+			m.pinList.RemoveItem(i)
+			break
+		}
+	}
+}
+
+func (m *model) persistPins() {
+	pins := make([]pin, 0, len(m.pinList.Items()))
+	for _, listItem := range m.pinList.Items() {
+		if p := listItem.(pin); !p.imported {
+			pins = append(pins, p)
+		}
+	}
+	if err := savePins(pins); err != nil {
+		m.statusMessage = fmt.Sprintf("Error saving pins: %s", err)
+		m.statusMessageType = "error"
+	}
+}
+
+// Handle keys while the pins panel is open
+func (m model) updatePins(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.pinList.SettingFilter() {
+		var cmd tea.Cmd
+		m.pinList, cmd = m.pinList.Update(msg)
+		return m, cmd
+	}
+
+	switch {
+	case key.Matches(msg, m.keymap.Quit):
+		return m, tea.Quit
+
+	case key.Matches(msg, m.keymap.Back) || key.Matches(msg, m.keymap.Pins):
+		m.showPins = false
+		return m, nil
+
+	case key.Matches(msg, m.keymap.Enter):
+		if p, ok := m.pinList.SelectedItem().(pin); ok {
+			m.showPins = false
+			m.activeTab = fileTab
+			m.statusMessage = fmt.Sprintf("Viewing file: %s", p.Path)
+			m.statusMessageType = "info"
+			return m, m.openFile(p.item())
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keymap.Unpin):
+		if p, ok := m.pinList.SelectedItem().(pin); ok {
+			m.removePin(p.key())
+			m.persistPins()
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.pinList, cmd = m.pinList.Update(msg)
+	return m, cmd
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// How much of a command's output the pipe pane keeps
+const pipeOutputLimit = 1 << 20
+
+var pipeOutputStyle = lipgloss.NewStyle().Padding(0, 2)
+
+const pipePlaceholder = "shell command, like sort | uniq -c or cut -d: -f1 | sort -u | xargs wc -l"
+
+// The results piped through a shell command, or a command run over the
+// marked results' files, and what it printed
+type pipeState struct {
+	show    bool
+	input   textinput.Model
+	output  viewport.Model
+	files   []string // the files to run the command on, or nil to pipe the results
+	command string   // the one that ran, or is running
+	count   int      // results (or files) it was given
+	running bool
+	cancel  context.CancelFunc
+	id      int
+}
+
+type pipeDoneMsg struct {
+	id       int
+	output   string
+	err      error
+	auditErr error // from logging the command to the audit log
+}
+
+func newPipeInput() textinput.Model {
+	input := textinput.New()
+	input.Placeholder = pipePlaceholder
+	input.Prompt = "Pipe results to ❯ "
+	input.PromptStyle = searchPromptStyle
+	input.TextStyle = lipgloss.NewStyle().Foreground(highlight)
+	input.Cursor.Style = lipgloss.NewStyle().Foreground(special)
+	return input
+}
+
+// Open the prompt for a command to pipe the results through
+func (m *model) openPipe() tea.Cmd {
+	if m.blockedByReadOnly("run commands on results") {
+		return nil
+	}
+	if len(m.targetResults()) == 0 {
+		m.statusMessage = "No results to pipe"
+		m.statusMessageType = "error"
+		return nil
+	}
+	m.pipe.show = true
+	m.pipe.files = nil
+	m.pipe.input.Prompt = "Pipe results to ❯ "
+	m.pipe.input.Placeholder = pipePlaceholder
+	return m.pipe.input.Focus()
+}
+
+// Run the command with sh (cmd on Windows), the results on its stdin as
+// file:line:col:text lines, and collect what it prints
+func (m *model) runPipe() tea.Cmd {
+	command := strings.TrimSpace(m.pipe.input.Value())
+	if command == "" {
+		return nil
+	}
+	if m.pipe.files != nil {
+		return m.runOnFiles(command)
+	}
+	items := m.targetResults()
+	var stdin bytes.Buffer
+	writeQuickfix(&stdin, items) //nolint: errcheck
+
+	if m.pipe.cancel != nil {
+		m.pipe.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.pipe.cancel = cancel
+	m.pipe.id++
+	m.pipe.command = command
+	m.pipe.count = len(items)
+	m.pipe.running = true
+	m.pipe.output.SetContent("")
+
+	shell := []string{"sh", "-c", command}
+	if runtime.GOOS == "windows" {
+		shell = []string{"cmd", "/C", command}
+	}
+	cmd := exec.CommandContext(ctx, shell[0], shell[1:]...)
+	cmd.Dir = m.paths.root
+	id := m.pipe.id
+	files := uniquePaths(items)
+	return func() tea.Msg {
+		cmd.Stdin = &stdin
+		var out limitedBuffer
+		out.limit = pipeOutputLimit
+		cmd.Stdout = &out
+		cmd.Stderr = &out
+		err := cmd.Run()
+		cancel()
+		output := out.String()
+		if out.truncated {
+			output += fmt.Sprintf("\n⋯ output cut off at %s", formatBytes(pipeOutputLimit))
+		}
+		// The command could do anything with the results, so it's logged
+		// like anything else that might change files
+		auditErr := recordAudit(auditEntry{Action: fmt.Sprintf("pipe %d results", len(items)), Files: files, Command: command})
+		return pipeDoneMsg{id: id, output: output, err: err, auditErr: auditErr}
+	}
+}
+
+// Collects output up to a limit, and throws the rest away so the command
+// doesn't block on a full pipe
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); len(p) > room {
+		b.truncated = true
+		b.Buffer.Write(p[:max(room, 0)])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+func (m *model) handlePipeDone(msg pipeDoneMsg) {
+	if msg.id != m.pipe.id {
+		return
+	}
+	m.pipe.running = false
+	m.pipe.cancel = nil
+	m.pipe.output.SetContent(sanitizeOutput(msg.output))
+	m.pipe.output.GotoTop()
+	switch {
+	case msg.err != nil:
+		m.statusMessage = fmt.Sprintf("%s: %s", m.pipe.command, msg.err)
+		m.statusMessageType = "error"
+	case m.pipe.files != nil:
+		m.statusMessage = fmt.Sprintf("Ran %s on %d files", m.pipe.command, m.pipe.count)
+		m.statusMessageType = "info"
+	default:
+		m.statusMessage = fmt.Sprintf("Piped %d results through %s", m.pipe.count, m.pipe.command)
+		m.statusMessageType = "info"
+	}
+	if msg.auditErr != nil {
+		m.statusMessage += fmt.Sprintf("; error writing audit log: %s", msg.auditErr)
+		m.statusMessageType = "error"
+	}
+}
+
+// A command's output with tabs expanded and other control characters
+// dropped, so it can't move the cursor or change colors
+func sanitizeOutput(output string) string {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.Map(func(r rune) rune {
+			if r < 0x20 || r == 0x7f {
+				return -1
+			}
+			return r
+		}, strings.ReplaceAll(line, "\t", "    "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m *model) closePipe() {
+	if m.pipe.cancel != nil {
+		m.pipe.cancel()
+		m.pipe.cancel = nil
+	}
+	m.pipe.id++ // what it printed isn't wanted now
+	m.pipe.running = false
+	m.pipe.show = false
+	m.pipe.input.Blur()
+}
+
+// Keys go to the command, except for scrolling the output
+func (m model) updatePipe(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.String() == "ctrl+c":
+		return m, tea.Quit
+
+	case key.Matches(msg, m.keymap.Back):
+		m.closePipe()
+		return m, nil
+
+	case key.Matches(msg, m.keymap.Enter):
+		return m, m.runPipe()
+
+	case msg.String() == "up", msg.String() == "down", msg.String() == "pgup", msg.String() == "pgdown":
+		var cmd tea.Cmd
+		m.pipe.output, cmd = m.pipe.output.Update(msg)
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	m.pipe.input, cmd = m.pipe.input.Update(msg)
+	return m, cmd
+}
+
+func (m model) pipeView() string {
+	status := ""
+	switch {
+	case m.pipe.running && m.pipe.files != nil:
+		status = fmt.Sprintf("Running %s on %d files…", m.pipe.command, m.pipe.count)
+	case m.pipe.running:
+		status = fmt.Sprintf("Running %s on %d results…", m.pipe.command, m.pipe.count)
+	case m.pipe.command != "" && m.pipe.files != nil:
+		status = fmt.Sprintf("%s · %d files", m.pipe.command, m.pipe.count)
+	case m.pipe.command != "":
+		status = fmt.Sprintf("%s · %d results in · %d lines out", m.pipe.command, m.pipe.count, m.pipe.output.TotalLineCount())
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		resultFilterStyle.Render(m.pipe.input.View()),
+		pipeOutputStyle.Foreground(subtle).Render(status),
+		pipeOutputStyle.Render(m.pipe.output.View()),
+	)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Piping runs a command that could change anything, so it's logged like
+// the other mutating actions
+func TestRunPipe(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh isn't installed")
+	}
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	m := initialModel()
+	m.paths.root = t.TempDir()
+	m.resetResults()
+	m.appendResults([]Item{
+		{fullPath: "/src/a.go", lineNum: 1, column: 1, content: "foo"},
+		{fullPath: "/src/a.go", lineNum: 2, column: 1, content: "foo"},
+		{fullPath: "/src/b.go", lineNum: 5, column: 3, content: "a foo"},
+	})
+	m.pipe.input.SetValue("cut -d: -f1 | uniq")
+	msg, ok := m.runPipe()().(pipeDoneMsg)
+	if !ok {
+		t.Fatal("running the pipe didn't finish with a pipeDoneMsg")
+	}
+	m.handlePipeDone(msg)
+
+	if want := "Piped 3 results through cut -d: -f1 | uniq"; m.statusMessage != want {
+		t.Errorf("status %q, want %q", m.statusMessage, want)
+	}
+	if want := "/src/a.go\n/src/b.go\n"; msg.output != want {
+		t.Errorf("output %q, want %q", msg.output, want)
+	}
+	entries, err := readAuditLog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d audit log entries, want 1", len(entries))
+	}
+	entry := entries[0]
+	if !strings.HasPrefix(entry.Action, "pipe") || entry.Command != "cut -d: -f1 | uniq" {
+		t.Errorf("audit log entry %+v", entry)
+	}
+	if want := []string{"/src/a.go", "/src/b.go"}; !reflect.DeepEqual(entry.Files, want) {
+		t.Errorf("audit log files %v, want %v", entry.Files, want)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// When low-power mode is on: "auto" follows the battery, "on" and "off"
+// hold it there
+const (
+	lowPowerAuto = "auto"
+	lowPowerOn   = "on"
+	lowPowerOff  = "off"
+)
+
+// How often the battery is checked, and how many threads rg gets in
+// low-power mode
+const (
+	powerCheckPeriod = 30 * time.Second
+	lowPowerThreads  = 2
+)
+
+var lowPowerStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("#1A1A1A")).
+	Background(lipgloss.Color("#F2C94C")).
+	Padding(0, 1).
+	Bold(true)
+
+// Whether the machine is running on battery. False wherever it can't be
+// told.
+func onBattery() bool {
+	switch runtime.GOOS {
+	case "linux":
+		supplies, _ := filepath.Glob("/sys/class/power_supply/*")
+		for _, supply := range supplies {
+			kind, err := os.ReadFile(filepath.Join(supply, "type"))
+			if err != nil || strings.TrimSpace(string(kind)) != "Battery" {
+				continue
+			}
+			status, err := os.ReadFile(filepath.Join(supply, "status"))
+			if err == nil && strings.TrimSpace(string(status)) == "Discharging" {
+				return true
+			}
+		}
+	case "darwin":
+		out, err := exec.Command("pmset", "-g", "batt").Output()
+		return err == nil && strings.Contains(string(out), "'Battery Power'")
+	}
+	return false
+}
+
+type powerCheckMsg struct {
+	battery bool
+}
+
+func (m model) watchPower() tea.Cmd {
+	if m.powerMode != lowPowerAuto {
+		return nil
+	}
+	return checkPower()
+}
+
+func checkPower() tea.Cmd {
+	return tea.Tick(powerCheckPeriod, func(time.Time) tea.Msg {
+		return powerCheckMsg{battery: onBattery()}
+	})
+}
+
+// Follow the battery, until low-power mode is set by hand
+func (m *model) handlePowerCheck(msg powerCheckMsg) tea.Cmd {
+	if m.powerMode != lowPowerAuto {
+		return nil
+	}
+	if msg.battery != m.lowPower {
+		if msg.battery {
+			m.statusMessage = "On battery: low-power mode on, alt+b to turn it off"
+		} else {
+			m.statusMessage = "Plugged in: low-power mode off"
+		}
+		m.statusMessageType = "info"
+		return tea.Batch(m.setLowPower(msg.battery), checkPower())
+	}
+	return checkPower()
+}
+
+// Toggle low-power mode by hand, which stops it following the battery for
+// the rest of the session
+func (m *model) toggleLowPower() tea.Cmd {
+	if m.lowPower {
+		m.powerMode = lowPowerOff
+		m.statusMessage = "Low-power mode off"
+	} else {
+		m.powerMode = lowPowerOn
+		m.statusMessage = "Low-power mode on: fewer rg threads, no live search or preview, no animations"
+	}
+	m.statusMessageType = "info"
+	return m.setLowPower(!m.lowPower)
+}
+
+// Low-power mode gives rg fewer threads, stops live search and the preview
+// pane from doing work on their own, and stops redrawing on a timer
+func (m *model) setLowPower(on bool) tea.Cmd {
+	m.lowPower = on
+	m.layoutResults()
+	return tea.Batch(m.setCursorBlink(), m.updatePreview())
+}
+
+// Whether anything should redraw on a timer: not in lite rendering or
+// low-power mode
+func (m model) animated() bool {
+	return !m.lite && !m.lowPower
+}
+
+func (m model) lowPowerView() string {
+	if !m.lowPower {
+		return ""
+	}
+	return lowPowerStyle.Render("LOW POWER") + " "
+}
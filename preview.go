@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// How many lines either side of the match the preview reads, which is
+// enough to fill the pane on any reasonable terminal without rereading the
+// file when it's resized
+const previewContext = 100
+
+var (
+	previewStyle = lipgloss.NewStyle().
+			BorderStyle(lipgloss.NormalBorder()).
+			BorderLeft(true).
+			BorderForeground(subtle).
+			PaddingLeft(1)
+
+	previewGutterStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.AdaptiveColor{Light: "#A49FA5", Dark: "#777777"})
+
+	previewMatchGutterStyle = lipgloss.NewStyle().
+				Foreground(special).
+				Bold(true)
+)
+
+// The lines around a result, as shown in the preview pane
+type preview struct {
+	item  Item
+	first int // line number of lines[0]
+	lines []string
+	err   error
+}
+
+type previewLoadedMsg struct {
+	preview *preview
+}
+
+// Read the lines around a result. With an index entry for the file as it
+// is now, reading starts from the nearest indexed line before them rather
+// than the top of the file.
+func loadPreview(item Item, entry *indexedFile) tea.Cmd {
+	return func() tea.Msg {
+		p := &preview{item: item, first: max(item.lineNum-previewContext, 1)}
+
+		file, err := os.Open(item.fullPath)
+		if err != nil {
+			p.err = err
+			return previewLoadedMsg{p}
+		}
+		defer file.Close()
+
+		n := 1
+		if info, err := file.Stat(); err == nil && entry != nil && entry.fresh(info) {
+			checkpoint := min((p.first-1)/indexLineStride, len(entry.Lines)-1)
+			if _, err := file.Seek(entry.Lines[checkpoint], io.SeekStart); err == nil {
+				n = checkpoint*indexLineStride + 1
+			}
+		}
+		reader := bufio.NewReader(file)
+		for ; n <= item.lineNum+previewContext; n++ {
+			line, err := reader.ReadString('\n')
+			if n >= p.first && (line != "" || err == nil) {
+				p.lines = append(p.lines, strings.TrimRight(line, "\r\n"))
+			}
+			if err != nil {
+				break
+			}
+		}
+		return previewLoadedMsg{p}
+	}
+}
+
+// Show or hide the preview pane
+func (m *model) togglePreview() tea.Cmd {
+	m.resultsState.showPreview = !m.resultsState.showPreview
+	m.layoutResults()
+	return m.updatePreview()
+}
+
+// Load the preview for the selected result if it isn't showing already
+func (m *model) updatePreview() tea.Cmd {
+	if !m.previewShown() {
+		return nil
+	}
+	item, ok := m.resultsState.list.selected()
+	if !ok {
+		m.resultsState.preview = nil
+		m.resultsState.previewing = resultKey{}
+		return nil
+	}
+	if item.key() == m.resultsState.previewing {
+		return nil
+	}
+	m.resultsState.previewing = item.key()
+	return loadPreview(item, m.indexedFile(item.fullPath))
+}
+
+// The preview pane is put away in low-power mode, since it reads a file
+// every time the selection moves
+func (m model) previewShown() bool {
+	return m.resultsState.showPreview && !m.lowPower
+}
+
+func (m model) previewWidth() int {
+	if !m.previewShown() {
+		return 0
+	}
+	return (m.resultsWidth() - m.sidebarWidth()) / 2
+}
+
+// The lines around the match, with the match line centered and its
+// matched text highlighted
+func (m model) previewView() string {
+	width := m.previewWidth() - previewStyle.GetHorizontalFrameSize()
+	height := m.listHeight
+	if m.showResultFilter() {
+		height -= 2
+	}
+	// lipgloss widths include padding but not borders
+	style := m.bordered(previewStyle).Width(width + previewStyle.GetHorizontalPadding()).Height(height)
+
+	p := m.resultsState.preview
+	switch {
+	case p == nil:
+		return style.Render("")
+	case p.err != nil:
+		return style.Render(resultFilterErrorStyle.Render(fmt.Sprintf("Can't preview %s: %s", p.item.fileName, p.err)))
+	}
+
+	return style.Render(m.previewLines(p, width, height, 0))
+}
+
+// A preview's lines for a pane width by height, under a header with the
+// result's location: the match line centered, moved by scroll, and its
+// matched text highlighted
+func (m model) previewLines(p *preview, width, height, scroll int) string {
+	location := fmt.Sprintf(":%d:%d", p.item.lineNum, p.item.column)
+	header := searchPromptStyle.Render(m.paths.truncate(m.paths.show(p.item.fileName), width-len(location)) + location)
+	height--
+
+	matchIndex := p.item.lineNum - p.first
+	start := max(min(matchIndex-height/2+scroll, len(p.lines)-height), 0)
+	end := min(start+height, len(p.lines))
+	digits := len(fmt.Sprint(p.first + end))
+
+	rows := []string{header}
+	for i := start; i < end; i++ {
+		gutter := previewGutterStyle.Render(fmt.Sprintf("%*d │ ", digits, p.first+i))
+		line := p.lines[i]
+		if i == matchIndex {
+			gutter = previewMatchGutterStyle.Render(fmt.Sprintf("%*d │ ", digits, p.first+i))
+			line = highlightSpans(line, p.item.lineMatches, lipgloss.NewStyle(), matchStyle)
+		}
+		rows = append(rows, ansi.Truncate(gutter+expandTabs(line, batTabWidth), width, ""))
+	}
+	return strings.Join(rows, "\n")
+}
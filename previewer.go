@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Show files in the viewer with a previewer command, if one's configured
+func (m *model) usePreviewer(command string) {
+	if command != "" {
+		m.highlighter.backend = highlighterCommand
+		m.highlighter.command = command
+	}
+}
+
+// Render a file with the configured previewer command: its words, with
+// {path}, {line} and {column} filled in for the result, run without a
+// shell so paths need no quoting. Its output is shown as it is, taken to
+// have a line for each of the file's lines. Not ok if the command isn't
+// installed.
+func renderWithCommand(ctx context.Context, item Item, template string) (fileLoadedMsg, bool) {
+	replacer := strings.NewReplacer(
+		"{path}", item.fullPath,
+		"{line}", strconv.Itoa(max(item.lineNum, 1)),
+		"{column}", strconv.Itoa(max(item.column, 1)),
+	)
+	var args []string
+	for _, field := range strings.Fields(template) {
+		args = append(args, replacer.Replace(field))
+	}
+	if len(args) == 0 {
+		return fileLoadedMsg{}, false
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return fileLoadedMsg{}, false
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%s: %s", args[0], msg)
+		}
+		return fileLoadedMsg{err: err}, true
+	}
+	content := strings.TrimSuffix(string(output), "\n")
+	return fileLoadedMsg{doc: newRenderedDocument(content, 0), focus: max(item.lineNum-1, 0)}, true
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// What -print-selection prints for each picked result
+const (
+	printLocation = "location" // path:line
+	printPath     = "path"
+)
+
+// Quit with the selected (or marked) results to print, for using lazyrg as
+// a picker from the shell
+func (m *model) pickResults() tea.Cmd {
+	items := m.selectedResults()
+	if len(items) == 0 {
+		return nil
+	}
+	var picked []string
+	if m.printFormat == printPath {
+		picked = uniquePaths(items)
+	} else {
+		for _, item := range items {
+			picked = append(picked, fmt.Sprintf("%s:%d", item.fullPath, item.lineNum))
+		}
+	}
+	m.picked = strings.Join(picked, "\n")
+	return tea.Quit
+}
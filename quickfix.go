@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Editors that load a quickfix list with -q
+var quickfixEditors = map[string]bool{
+	"vi":   true,
+	"vim":  true,
+	"nvim": true,
+	"gvim": true,
+	"mvim": true,
+}
+
+// Write results one per line as file:line:col:text, the format vim's
+// default errorformat and most editors' grep modes read
+func writeQuickfix(w io.Writer, items []Item) error {
+	bw := bufio.NewWriter(w)
+	for _, item := range items {
+		// Remote results have no file on disk to jump to
+		if item.lineNum == 0 {
+			continue
+		}
+		fmt.Fprintf(bw, "%s:%d:%d:%s\n", item.fullPath, item.lineNum, item.column, strings.TrimRight(item.content, "\r\n")) //nolint: errcheck
+	}
+	return bw.Flush()
+}
+
+func writeQuickfixExport(w io.Writer, data exportData) error {
+	return writeQuickfix(w, data.items)
+}
+
+// Write the results as a quickfix list to path, or to stdout for "-", as
+// lazyrg exits
+func (m *model) writeQuickfixOnExit(path string) error {
+	items := m.targetResults()
+	if path == "-" {
+		return writeQuickfix(os.Stdout, items)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeQuickfix(file, items); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// The user's editor with its arguments, from $VISUAL or $EDITOR
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(env)); len(fields) > 0 {
+			return fields
+		}
+	}
+	return []string{"vi"}
+}
+
+// Write the results to a temporary file and open it in the editor, as a
+// quickfix list for vim and neovim and as a plain file for anything else.
+// A GUI editor is left running, as with ctrl+e.
+func (m *model) openQuickfix() tea.Cmd {
+	if m.blockedByReadOnly("open the editor") {
+		return nil
+	}
+	items := m.targetResults()
+	if len(items) == 0 {
+		m.statusMessage = "No results to open"
+		m.statusMessageType = "error"
+		return nil
+	}
+
+	file, err := os.CreateTemp("", "lazyrg-*.qf")
+	if err == nil {
+		err = writeQuickfix(file, items)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		m.statusMessage = fmt.Sprintf("Error writing the quickfix list: %s", err)
+		m.statusMessageType = "error"
+		return nil
+	}
+
+	editor := m.editor.command()
+	args := editor[1:]
+	if quickfixEditors[editorName(editor[0])] {
+		args = append(args, "-q")
+	}
+	cmd := exec.Command(editor[0], append(args, file.Name())...)
+	if m.editor.isGUI(editor) {
+		// It reads the list after starting, so the list is left for the
+		// system to clean up
+		return func() tea.Msg {
+			err := cmd.Start()
+			if err == nil {
+				go cmd.Wait() //nolint: errcheck
+			}
+			return quickfixDoneMsg{count: len(items), err: err}
+		}
+	}
+	cmd.Stdout = m.terminalFile()
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		os.Remove(file.Name()) //nolint: errcheck
+		return quickfixDoneMsg{count: len(items), err: err}
+	})
+}
+
+type quickfixDoneMsg struct {
+	count int
+	err   error
+}
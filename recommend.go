@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Directories of other people's code that tend to swamp a search
+var vendoredDirs = []string{"vendor", "node_modules", "third_party", "bower_components", ".venv", "target", "dist"}
+
+// A search slower than this, over files larger than bigFileBytes on average,
+// gets a suggestion to skip large files
+const (
+	slowSearch   = 5 * time.Second
+	bigFileBytes = 1 << 20
+)
+
+var wordPattern = regexp.MustCompile(`^\w+$`)
+
+// A change to the search that looks worth trying, going by how the last
+// one went, offered in the status line with a key to try it
+type suggestion struct {
+	why   string // what about the search prompted it
+	label string // what it does, in rg's terms
+	apply func(m *model)
+}
+
+// Add rg flags to every search from now on
+func addFlags(flags ...string) func(m *model) {
+	return func(m *model) {
+		m.flags = append(m.flags, flags...)
+	}
+}
+
+// The suggestion for a finished search, if anything stands out: nothing
+// found (perhaps for case, or because of files rg skips by default), far
+// too much found (from vendored code, or a word matched inside longer
+// ones), or a long time spent on large files
+func (m model) suggestFlags(stats *searchStats) *suggestion {
+	pattern := m.currentSearchPattern
+	has := func(flag string) bool { return slices.Contains(m.flags, flag) }
+
+	if len(m.results) == 0 {
+		switch {
+		case strings.IndexFunc(pattern, unicode.IsUpper) >= 0 && !strings.Contains(pattern, "(?i)") && !has("-i"):
+			return &suggestion{why: "nothing matched with this case", label: "add -i (ignore case)", apply: addFlags("-i")}
+		case !has("--hidden"):
+			return &suggestion{why: "hidden files and directories weren't searched", label: "add --hidden", apply: addFlags("--hidden")}
+		case !has("--no-ignore"):
+			return &suggestion{why: "files in .gitignore weren't searched", label: "add --no-ignore", apply: addFlags("--no-ignore")}
+		case !has("--text"):
+			return &suggestion{why: "binary files were skipped", label: "add --text (search binary files)", apply: addFlags("--text")}
+		}
+		return nil
+	}
+
+	if stats == nil {
+		return nil
+	}
+	if stats.matchedLines >= broadSearchLines {
+		if dir, n := m.vendoredShare(); n*2 >= len(m.results) {
+			return &suggestion{
+				why:   fmt.Sprintf("%s of the results are in %s", formatCount(n), m.paths.show(dir)),
+				label: "exclude " + filepath.Base(dir),
+				apply: func(m *model) {
+					if !slices.Contains(m.excludes, dir) {
+						m.excludes = append(m.excludes, dir)
+					}
+				},
+			}
+		}
+		if wordPattern.MatchString(pattern) && !has("-w") {
+			return &suggestion{why: "that's a lot of matches", label: "add -w (whole words only)", apply: addFlags("-w")}
+		}
+	}
+	if stats.elapsed >= slowSearch && stats.searches > 0 && stats.bytesSearched/int64(stats.searches) >= bigFileBytes && !has("--max-filesize") {
+		return &suggestion{why: "most of the time went on large files", label: "add --max-filesize 1M", apply: addFlags("--max-filesize", "1M")}
+	}
+	return nil
+}
+
+// The vendored directory directly under the search directory with the most
+// results, and how many it has
+func (m model) vendoredShare() (string, int) {
+	counts := map[string]int{}
+	for _, result := range m.results {
+		rel, err := filepath.Rel(m.paths.root, result.fullPath)
+		if err != nil {
+			continue
+		}
+		top, _, ok := strings.Cut(filepath.ToSlash(rel), "/")
+		if ok && slices.Contains(vendoredDirs, top) {
+			counts[top]++
+		}
+	}
+	best, n := "", 0
+	for dir, count := range counts {
+		if count > n || (count == n && dir < best) {
+			best, n = dir, count
+		}
+	}
+	if n == 0 {
+		return "", 0
+	}
+	return filepath.Join(m.paths.root, best), n
+}
+
+// Offer a suggestion in the status line, after the result count
+func (m *model) offerSuggestion(stats *searchStats) {
+	m.suggestion = m.suggestFlags(stats)
+	if m.suggestion != nil {
+		m.statusMessage += fmt.Sprintf(" · 💡 %s: %s to %s", m.suggestion.why, m.keymap.Suggestion.Help().Key, m.suggestion.label)
+	}
+}
+
+// Take the suggestion and search again
+func (m *model) takeSuggestion() bool {
+	if m.suggestion == nil {
+		return false
+	}
+	m.suggestion.apply(m)
+	m.suggestion = nil
+	return true
+}
+
+// The flags added from suggestions, as shown in the search tab
+func (m model) flagsView() string {
+	return fmt.Sprintf("🚩 rg %s (%s to clear)", strings.Join(m.flags, " "), m.keymap.ClearExcludes.Help().Key)
+}
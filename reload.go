@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How often the viewed file is checked for changes on disk, more slowly in
+// low-power mode
+const (
+	fileWatchPeriod         = 2 * time.Second
+	fileWatchLowPowerPeriod = 10 * time.Second
+)
+
+// What's checked to tell if a file has changed since it was loaded
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func statFile(path string) (fileStamp, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, false
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}, true
+}
+
+type fileWatchMsg struct {
+	token int
+}
+
+type fileStatMsg struct {
+	token int
+	stamp fileStamp
+	ok    bool
+}
+
+// Start watching the file that's just been loaded for changes, if it's one
+// on disk
+func (m *model) watchFile() tea.Cmd {
+	m.fileState.changed = false
+	stamp, ok := statFile(m.fileState.item.fullPath)
+	if !ok || m.fileState.item.remote != nil || m.review.stagedFile(m.fileState.item.fullPath) != nil {
+		return nil
+	}
+	m.fileState.stamp = stamp
+	return m.nextFileWatch()
+}
+
+func (m model) nextFileWatch() tea.Cmd {
+	period := fileWatchPeriod
+	if m.lowPower {
+		period = fileWatchLowPowerPeriod
+	}
+	token := m.fileToken
+	return tea.Tick(period, func(time.Time) tea.Msg { return fileWatchMsg{token} })
+}
+
+func (m *model) handleFileWatch(msg fileWatchMsg) tea.Cmd {
+	if msg.token != m.fileToken || m.fileState.doc == nil {
+		return nil
+	}
+	path := m.fileState.item.fullPath
+	return func() tea.Msg {
+		stamp, ok := statFile(path)
+		return fileStatMsg{token: msg.token, stamp: stamp, ok: ok}
+	}
+}
+
+// Offer to reload the file once it's changed. Following the file keeps up
+// with it already.
+func (m *model) handleFileStat(msg fileStatMsg) tea.Cmd {
+	if msg.token != m.fileToken || m.fileState.doc == nil {
+		return nil
+	}
+	if !m.fileState.changed && !m.fileState.follow && (!msg.ok || msg.stamp != m.fileState.stamp) {
+		m.fileState.changed = true
+		name := m.paths.show(m.fileState.item.fileName)
+		if msg.ok {
+			m.statusMessage = fmt.Sprintf("%s has changed on disk (%s to reload)", name, m.keymap.Reload.Help().Key)
+		} else {
+			m.statusMessage = fmt.Sprintf("%s is no longer on disk", name)
+		}
+		m.statusMessageType = "info"
+	}
+	return m.nextFileWatch()
+}
+
+// Load the viewed file again, keeping the viewer where it is
+func (m *model) reloadFile() tea.Cmd {
+	if m.fileState.doc == nil || m.fileState.item.remote != nil || m.review.stagedFile(m.fileState.item.fullPath) != nil {
+		return nil
+	}
+	m.statusMessage = fmt.Sprintf("Reloaded %s", m.paths.show(m.fileState.item.fileName))
+	m.statusMessageType = "info"
+	m.fileState.blame = nil
+	// openFile remembers where the viewer is and goes back there
+	return m.openFile(m.fileState.item)
+}
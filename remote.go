@@ -0,0 +1,466 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Code search providers for repositories that aren't cloned locally
+const (
+	remoteGitHub = "github"
+	remoteGitLab = "gitlab"
+)
+
+// How many pages of 100 hits a remote search fetches, and how long it will
+// wait out a rate limit before giving up and saying when to try again
+const (
+	remotePages        = 3
+	remoteRateLimitMax = 30 * time.Second
+)
+
+var remoteClient = &http.Client{Timeout: 30 * time.Second}
+
+// Settings for the remote code search backend
+type remoteConfig struct {
+	// "github" or "gitlab"
+	Provider string `json:"provider"`
+
+	// The API's base URL, for GitHub Enterprise or a self-hosted GitLab.
+	// Defaults to https://api.github.com or https://gitlab.com.
+	URL string `json:"url"`
+
+	// Access token. Defaults to $GITHUB_TOKEN or $GITLAB_TOKEN.
+	Token string `json:"token"`
+
+	// What to search: GitHub qualifiers like "org:acme" added to every
+	// query, or the path of a GitLab group. Everything visible if empty.
+	Scope string `json:"scope"`
+
+	// Where repositories are cloned to open a result. Defaults to a
+	// directory in the user cache directory.
+	CloneDir string `json:"cloneDir"`
+}
+
+func (rc remoteConfig) token() string {
+	if rc.Token != "" {
+		return rc.Token
+	}
+	if rc.Provider == remoteGitLab {
+		return os.Getenv("GITLAB_TOKEN")
+	}
+	return os.Getenv("GITHUB_TOKEN")
+}
+
+func (rc remoteConfig) providerName() string {
+	if rc.Provider == remoteGitLab {
+		return "GitLab"
+	}
+	return "GitHub"
+}
+
+// Where a repository from the remote backend is cloned to
+func (rc remoteConfig) cloneDir(repo string) (string, error) {
+	dir := rc.CloneDir
+	if dir == "" {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(cache, "lazyrg", "clones")
+	}
+	return filepath.Join(dir, filepath.FromSlash(repo)), nil
+}
+
+// Where a remote result lives. Results from the remote backend carry one;
+// local results don't.
+type remoteHit struct {
+	repo     string // owner/name, or the GitLab namespace path
+	cloneURL string
+	path     string // within the repository
+}
+
+type remoteResultsMsg struct {
+	id      int
+	results []Item
+	err     error
+}
+
+type clonedMsg struct {
+	item Item // the result, now pointing into the clone
+	err  error
+}
+
+// Run the pattern against the remote backend instead of rg
+func (m *model) startRemoteSearch() tea.Cmd {
+	rc := m.remote
+	switch rc.Provider {
+	case "":
+		m.statusMessage = "No remote search configured (set remote.provider in the config)"
+		m.statusMessageType = "error"
+		return nil
+	case remoteGitHub:
+		if rc.token() == "" {
+			m.statusMessage = "GitHub code search needs a token: set GITHUB_TOKEN or remote.token in the config"
+			m.statusMessageType = "error"
+			return nil
+		}
+	}
+
+	m.currentSearchPattern = m.searchInput.Value()
+	if m.search != nil {
+		m.search.stop()
+		m.search = nil
+	}
+	m.searchID++
+	m.paths.root = ""
+	m.searchStarted = time.Now()
+	m.resetResults()
+	m.activeTab = resultsTab
+	m.statusMessage = fmt.Sprintf("Searching %s for: %s", rc.providerName(), m.currentSearchPattern)
+	m.statusMessageType = "info"
+
+	id, pattern := m.searchID, m.currentSearchPattern
+	return func() tea.Msg {
+		var results []Item
+		var err error
+		if rc.Provider == remoteGitLab {
+			results, err = searchGitLab(rc, pattern)
+		} else {
+			results, err = searchGitHub(rc, pattern)
+		}
+		return remoteResultsMsg{id: id, results: results, err: err}
+	}
+}
+
+// GET an API URL, waiting out short rate limits
+func remoteGet(rc remoteConfig, rawURL string, v any) error {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+		if err != nil {
+			return err
+		}
+		if token := rc.token(); token != "" {
+			if rc.Provider == remoteGitLab {
+				req.Header.Set("PRIVATE-TOKEN", token)
+			} else {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		if rc.Provider != remoteGitLab {
+			// Asks for the matched fragments along with each file
+			req.Header.Set("Accept", "application/vnd.github.text-match+json")
+		}
+
+		resp, err := remoteClient.Do(req)
+		if err != nil {
+			return err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		if rateLimited(resp) {
+			wait := retryAfter(resp.Header)
+			if attempt < 2 && wait <= remoteRateLimitMax {
+				time.Sleep(wait)
+				continue
+			}
+			return fmt.Errorf("rate limited by %s, try again in %s", rc.providerName(), wait.Round(time.Second))
+		}
+		if resp.StatusCode != http.StatusOK {
+			var apiErr struct {
+				Message string `json:"message"`
+			}
+			if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+				return fmt.Errorf("%s: %s", rc.providerName(), apiErr.Message)
+			}
+			return fmt.Errorf("%s: %s", rc.providerName(), resp.Status)
+		}
+		return json.Unmarshal(body, v)
+	}
+}
+
+// GitHub says 403 with no requests remaining, GitLab says 429
+func rateLimited(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0"
+}
+
+// How long a rate limit lasts, from whichever header the provider sent
+func retryAfter(header http.Header) time.Duration {
+	if s, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+		return time.Duration(s) * time.Second
+	}
+	for _, name := range []string{"X-RateLimit-Reset", "RateLimit-Reset"} {
+		if reset, err := strconv.ParseInt(header.Get(name), 10, 64); err == nil {
+			return max(time.Until(time.Unix(reset, 0)), time.Second)
+		}
+	}
+	return time.Minute
+}
+
+func searchGitHub(rc remoteConfig, pattern string) ([]Item, error) {
+	base := strings.TrimSuffix(rc.URL, "/")
+	if base == "" {
+		base = "https://api.github.com"
+	}
+	query := pattern
+	if rc.Scope != "" {
+		query += " " + rc.Scope
+	}
+
+	var results []Item
+	for page := 1; page <= remotePages; page++ {
+		var resp struct {
+			TotalCount int `json:"total_count"`
+			Items      []struct {
+				Path       string `json:"path"`
+				Repository struct {
+					FullName string `json:"full_name"`
+					HTMLURL  string `json:"html_url"`
+				} `json:"repository"`
+				TextMatches []struct {
+					Property string `json:"property"`
+					Fragment string `json:"fragment"`
+					Matches  []struct {
+						Indices []int `json:"indices"`
+					} `json:"matches"`
+				} `json:"text_matches"`
+			} `json:"items"`
+		}
+		u := fmt.Sprintf("%s/search/code?q=%s&per_page=100&page=%d", base, url.QueryEscape(query), page)
+		if err := remoteGet(rc, u, &resp); err != nil {
+			if len(results) > 0 {
+				// Keep the pages we got
+				break
+			}
+			return nil, err
+		}
+
+		for _, hit := range resp.Items {
+			remote := &remoteHit{
+				repo:     hit.Repository.FullName,
+				cloneURL: hit.Repository.HTMLURL + ".git",
+				path:     hit.Path,
+			}
+			for _, tm := range hit.TextMatches {
+				if tm.Property != "content" {
+					continue
+				}
+				// GitHub doesn't say which line a fragment is from, so the
+				// line is found once the file is cloned
+				var spans [][2]int
+				for _, match := range tm.Matches {
+					if len(match.Indices) == 2 {
+						spans = append(spans, [2]int{match.Indices[0], match.Indices[1]})
+					}
+				}
+				for _, item := range fragmentItems(tm.Fragment, spans) {
+					item.fileName = remote.repo + "/" + remote.path
+					item.fullPath = item.fileName
+					item.remote = remote
+					results = append(results, item)
+				}
+			}
+		}
+		if len(resp.Items) < 100 || page*100 >= resp.TotalCount {
+			break
+		}
+	}
+	return results, nil
+}
+
+// A result for each line of a fragment of a file with matches on it.
+// GitHub gives the matches as character offsets into the fragment, not
+// bytes.
+func fragmentItems(fragment string, spans [][2]int) []Item {
+	runes := []rune(fragment)
+	var items []Item
+	lastLine := -1
+	for _, span := range spans {
+		start, end := min(span[0], len(runes)), min(span[1], len(runes))
+		before := string(runes[:start])
+		lineStart := strings.LastIndexByte(before, '\n') + 1
+		line := fragment[lineStart:]
+		if i := strings.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i]
+		}
+		matchStart := len(before) - lineStart
+		matchEnd := min(matchStart+len(string(runes[start:end])), len(line))
+
+		if lineStart == lastLine {
+			// Another match on the same line
+			item := lineItem(line, matchStart, matchEnd)
+			last := &items[len(items)-1]
+			last.lineMatches = append(last.lineMatches, item.lineMatches...)
+			last.matches = append(last.matches, item.matches...)
+			continue
+		}
+		items = append(items, lineItem(line, matchStart, matchEnd))
+		lastLine = lineStart
+	}
+	return items
+}
+
+// A result for a line with a match at [start, end), set up the way
+// parseMatch sets up rg's
+func lineItem(line string, start, end int) Item {
+	line = strings.TrimRight(line, "\r")
+	content := strings.TrimSpace(line)
+	trimmed := len(line) - len(strings.TrimLeft(line, " \t"))
+	item := Item{
+		content:     content,
+		column:      start + 1,
+		lineMatches: []matchSpan{{start: start, end: end}},
+	}
+	if s, e := min(max(start-trimmed, 0), len(content)), min(max(end-trimmed, 0), len(content)); s < e {
+		item.matches = []matchSpan{{start: s, end: e}}
+	}
+	return item
+}
+
+func searchGitLab(rc remoteConfig, pattern string) ([]Item, error) {
+	base := strings.TrimSuffix(rc.URL, "/")
+	if base == "" {
+		base = "https://gitlab.com"
+	}
+	endpoint := base + "/api/v4/search"
+	if rc.Scope != "" {
+		endpoint = base + "/api/v4/groups/" + url.PathEscape(rc.Scope) + "/search"
+	}
+
+	projects := map[int]*remoteHit{}
+	query := strings.ToLower(pattern)
+	var results []Item
+	for page := 1; page <= remotePages; page++ {
+		var hits []struct {
+			Data      string `json:"data"`
+			Path      string `json:"path"`
+			Startline int    `json:"startline"`
+			ProjectID int    `json:"project_id"`
+		}
+		u := fmt.Sprintf("%s?scope=blobs&search=%s&per_page=100&page=%d", endpoint, url.QueryEscape(pattern), page)
+		if err := remoteGet(rc, u, &hits); err != nil {
+			if len(results) > 0 {
+				break
+			}
+			return nil, err
+		}
+
+		for _, hit := range hits {
+			project, ok := projects[hit.ProjectID]
+			if !ok {
+				var p struct {
+					PathWithNamespace string `json:"path_with_namespace"`
+					HTTPURLToRepo     string `json:"http_url_to_repo"`
+				}
+				if err := remoteGet(rc, fmt.Sprintf("%s/api/v4/projects/%d", base, hit.ProjectID), &p); err != nil {
+					return nil, err
+				}
+				project = &remoteHit{repo: p.PathWithNamespace, cloneURL: p.HTTPURLToRepo}
+				projects[hit.ProjectID] = project
+			}
+			remote := &remoteHit{repo: project.repo, cloneURL: project.cloneURL, path: hit.Path}
+
+			// The hit is a chunk of the file starting at startline; the
+			// results are the lines in it with the pattern on them
+			for i, line := range strings.Split(hit.Data, "\n") {
+				start := strings.Index(strings.ToLower(line), query)
+				if start < 0 || query == "" {
+					continue
+				}
+				item := lineItem(line, start, start+len(query))
+				item.fileName = remote.repo + "/" + remote.path
+				item.fullPath = item.fileName
+				item.lineNum = hit.Startline + i
+				item.remote = remote
+				results = append(results, item)
+			}
+		}
+		if len(hits) < 100 {
+			break
+		}
+	}
+	return results, nil
+}
+
+// Open a remote result, cloning its repository first if it hasn't been
+func (m *model) openRemote(item Item) tea.Cmd {
+	dir, err := m.remote.cloneDir(item.remote.repo)
+	if err != nil {
+		m.statusMessage = fmt.Sprintf("Error: %s", err)
+		m.statusMessageType = "error"
+		return nil
+	}
+	if isRepo(dir) {
+		return locateRemote(item, dir, false)
+	}
+	m.ask(fmt.Sprintf("Clone %s into %s?", item.remote.repo, dir), func(m *model) tea.Cmd {
+		m.statusMessage = fmt.Sprintf("Cloning %s...", item.remote.repo)
+		m.statusMessageType = "info"
+		return locateRemote(item, dir, true)
+	})
+	return nil
+}
+
+// Clone the result's repository if asked to, then point the result at the
+// file in the clone, finding its line if the provider didn't say
+func locateRemote(item Item, dir string, clone bool) tea.Cmd {
+	return func() tea.Msg {
+		if clone {
+			if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+				return clonedMsg{err: err}
+			}
+			out, err := exec.Command("git", "clone", "--depth", "1", item.remote.cloneURL, dir).CombinedOutput()
+			if err != nil {
+				msg := strings.TrimSpace(string(out))
+				if i := strings.LastIndexByte(msg, '\n'); i >= 0 {
+					msg = msg[i+1:]
+				}
+				return clonedMsg{err: errors.New(msg)}
+			}
+		}
+
+		local := item
+		local.fullPath = filepath.Join(dir, filepath.FromSlash(item.remote.path))
+		local.fileName = local.fullPath
+		local.remote = nil
+		if local.lineNum == 0 {
+			local.lineNum = findLine(local.fullPath, item.content)
+		}
+		return clonedMsg{item: local}
+	}
+}
+
+// The first line of a file containing text, or 1
+func findLine(path, text string) int {
+	file, err := os.Open(path)
+	if err != nil {
+		return 1
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		if strings.Contains(scanner.Text(), text) {
+			return n
+		}
+	}
+	return 1
+}
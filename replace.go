@@ -0,0 +1,614 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Lines of unchanged context around each hunk, in the review and the patch
+const replaceContext = 3
+
+var (
+	diffRemovedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5F87"))
+	diffAddedStyle   = lipgloss.NewStyle().Foreground(special)
+	diffContextStyle = lipgloss.NewStyle().Foreground(subtle)
+
+	diffRemovedEmphasisStyle = diffRemovedStyle.Bold(true).Underline(true)
+	diffAddedEmphasisStyle   = diffAddedStyle.Bold(true).Underline(true)
+)
+
+// What's been decided about a hunk
+const (
+	hunkUndecided = iota
+	hunkAccepted
+	hunkSkipped
+)
+
+// A run of adjacent lines a replacement changes
+type replaceHunk struct {
+	start    int      // index of the first line it changes
+	old      []string // the lines as they are
+	new      []string // the lines as they'd be after replacing
+	decision int
+}
+
+// A file with replacements pending, as it was read when the review started
+type replaceFile struct {
+	path    string
+	content []byte
+	lines   []string
+	hunks   []replaceHunk
+}
+
+// A replacement across the results, reviewed hunk by hunk before anything
+// is written. It starts out asking for the replacement text.
+type replaceReview struct {
+	re      *regexp.Regexp
+	input   textinput.Model
+	editing bool // still typing the replacement
+	with    string
+	files   []*replaceFile
+	file    int // the file and hunk being reviewed
+	hunk    int
+	staged  bool // put aside to browse the results, which show it inline
+}
+
+// Start a replacement over the results, asking for the replacement text,
+// or go back to reviewing the one that's staged
+func (m *model) openReplace() tea.Cmd {
+	if m.review != nil && m.review.staged {
+		m.review.staged = false
+		return nil
+	}
+	if m.blockedByReadOnly("replace") {
+		return nil
+	}
+	re, err := regexp.Compile(replacePattern(m.currentSearchPattern, m.flags))
+	if err != nil {
+		m.statusMessage = fmt.Sprintf("Can't replace: %s", err)
+		m.statusMessageType = "error"
+		return nil
+	}
+
+	input := textinput.New()
+	input.Prompt = "Replace with ❯ "
+	input.PromptStyle = searchPromptStyle
+	input.Placeholder = "$1, ${name} for capture groups"
+	input.Cursor.Style = lipgloss.NewStyle().Foreground(special)
+	m.review = &replaceReview{re: re, input: input, editing: true}
+	return m.review.input.Focus()
+}
+
+// The search pattern as rg matched it: flags added to the search, like -i
+// and -w from a suggestion, change which text it matches, so replacing
+// has to match the same
+func replacePattern(pattern string, flags []string) string {
+	if slices.Contains(flags, "-w") || slices.Contains(flags, "--word-regexp") {
+		pattern = `\b(?:` + pattern + `)\b`
+	}
+	if slices.Contains(flags, "-i") || slices.Contains(flags, "--ignore-case") {
+		pattern = "(?i)" + pattern
+	}
+	return pattern
+}
+
+// Work out the hunks replacing the pattern in the results' lines would
+// change, file by file in result order
+func buildReplaceFiles(re *regexp.Regexp, with string, items []Item) ([]*replaceFile, error) {
+	var files []*replaceFile
+	byPath := map[string]*replaceFile{}
+	changed := map[*replaceFile][]int{}
+	for _, item := range items {
+		if item.remote != nil {
+			continue
+		}
+		file, ok := byPath[item.fullPath]
+		if !ok {
+			content, err := os.ReadFile(item.fullPath)
+			if err != nil {
+				return nil, err
+			}
+			file = &replaceFile{path: item.fullPath, content: content, lines: strings.Split(string(content), "\n")}
+			byPath[item.fullPath] = file
+			files = append(files, file)
+		}
+		if i := item.lineNum - 1; i >= 0 && i < len(file.lines) {
+			changed[file] = append(changed[file], i)
+		}
+	}
+
+	var pending []*replaceFile
+	for _, file := range files {
+		lines := changed[file]
+		sort.Ints(lines)
+		for j, i := range lines {
+			if j > 0 && lines[j-1] == i {
+				continue
+			}
+			line := strings.TrimSuffix(file.lines[i], "\r")
+			cr := file.lines[i][len(line):]
+			replaced := re.ReplaceAllString(line, with)
+			if replaced == line {
+				continue
+			}
+			newLines := strings.Split(replaced, "\n")
+			for k := range newLines {
+				newLines[k] += cr
+			}
+
+			if n := len(file.hunks); n > 0 && file.hunks[n-1].start+len(file.hunks[n-1].old) == i {
+				// Adjacent to the last change, so part of the same hunk
+				file.hunks[n-1].old = append(file.hunks[n-1].old, file.lines[i])
+				file.hunks[n-1].new = append(file.hunks[n-1].new, newLines...)
+				continue
+			}
+			file.hunks = append(file.hunks, replaceHunk{start: i, old: []string{file.lines[i]}, new: newLines})
+		}
+		if len(file.hunks) > 0 {
+			pending = append(pending, file)
+		}
+	}
+	return pending, nil
+}
+
+// Handle keys while reviewing a replacement
+func (m model) updateReview(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	r := m.review
+	if msg.String() == "ctrl+c" {
+		return m, tea.Quit
+	}
+
+	if r.editing {
+		switch msg.String() {
+		case "esc":
+			m.review = nil
+			return m, nil
+		case "enter":
+			r.with = r.input.Value()
+			files, err := buildReplaceFiles(r.re, r.with, m.targetResults())
+			if err != nil {
+				m.review = nil
+				m.statusMessage = fmt.Sprintf("Can't replace: %s", err)
+				m.statusMessageType = "error"
+				return m, nil
+			}
+			if len(files) == 0 {
+				m.review = nil
+				m.statusMessage = "Nothing to replace"
+				m.statusMessageType = "info"
+				return m, nil
+			}
+			r.files = files
+			r.editing = false
+			r.input.Blur()
+			return m, nil
+		}
+		var cmd tea.Cmd
+		r.input, cmd = r.input.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "esc", "q":
+		m.review = nil
+		m.statusMessage = "Replace cancelled, nothing was changed"
+		m.statusMessageType = "info"
+		return m, nil
+	case "y":
+		r.decide(hunkAccepted, false)
+	case "n":
+		r.decide(hunkSkipped, false)
+	case "a":
+		r.decide(hunkAccepted, true)
+	case "d":
+		r.decide(hunkSkipped, true)
+	case "k", "up":
+		r.back()
+		return m, nil
+	case "w":
+		m.savePatch()
+		return m, nil
+	case "c":
+		m.reportCopy("the patch", m.copyToClipboard("patch", r.patch(m.paths.root)))
+		return m, nil
+	case "b":
+		r.staged = true
+		m.statusMessage = fmt.Sprintf("Replacement staged: open results to see it in their files, %s to go back to the review", m.keymap.Replace.Help().Key)
+		m.statusMessageType = "info"
+		return m, nil
+	default:
+		return m, nil
+	}
+
+	if r.file == len(r.files) {
+		m.applyReview()
+	}
+	return m, nil
+}
+
+// Decide the current hunk, or it and the rest of its file, and move on
+func (r *replaceReview) decide(decision int, restOfFile bool) {
+	hunks := r.files[r.file].hunks
+	if restOfFile {
+		for i := r.hunk; i < len(hunks); i++ {
+			hunks[i].decision = decision
+		}
+		r.file, r.hunk = r.file+1, 0
+		return
+	}
+	hunks[r.hunk].decision = decision
+	r.hunk++
+	if r.hunk == len(hunks) {
+		r.file, r.hunk = r.file+1, 0
+	}
+}
+
+// Go back a hunk to change the decision on it
+func (r *replaceReview) back() {
+	switch {
+	case r.hunk > 0:
+		r.hunk--
+	case r.file > 0:
+		r.file--
+		r.hunk = len(r.files[r.file].hunks) - 1
+	}
+}
+
+// The lines of a file with its accepted hunks applied
+func (f *replaceFile) replaced() []string {
+	var lines []string
+	pos := 0
+	for _, h := range f.hunks {
+		if h.decision != hunkAccepted {
+			continue
+		}
+		lines = append(lines, f.lines[pos:h.start]...)
+		lines = append(lines, h.new...)
+		pos = h.start + len(h.old)
+	}
+	return append(lines, f.lines[pos:]...)
+}
+
+// The hunks of a file that keep says to, as a unified diff with paths
+// relative to root
+func (f *replaceFile) diff(root string, keep func(replaceHunk) bool) string {
+	var kept []replaceHunk
+	for _, h := range f.hunks {
+		if keep(h) {
+			kept = append(kept, h)
+		}
+	}
+	if len(kept) == 0 {
+		return ""
+	}
+
+	// A trailing newline leaves an empty last element that isn't a line;
+	// without one, a hunk reaching the last line has to say so
+	lines := f.lines
+	newlineAtEnd := len(lines) > 0 && lines[len(lines)-1] == ""
+	if newlineAtEnd {
+		lines = lines[:len(lines)-1]
+	}
+	writeLine := func(b *strings.Builder, prefix, line string, last bool) {
+		b.WriteString(prefix + line + "\n")
+		if last && !newlineAtEnd {
+			b.WriteString("\\ No newline at end of file\n")
+		}
+	}
+
+	path := exportPath(root, f.path)
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", path, path)
+	delta := 0
+	for i := 0; i < len(kept); {
+		// Hunks close enough for their context to overlap share a header
+		j := i + 1
+		for j < len(kept) && kept[j].start-(kept[j-1].start+len(kept[j-1].old)) <= 2*replaceContext {
+			j++
+		}
+		from := max(kept[i].start-replaceContext, 0)
+		to := min(kept[j-1].start+len(kept[j-1].old)+replaceContext, len(lines))
+
+		var body strings.Builder
+		oldCount, newCount := to-from, to-from
+		pos := from
+		for _, h := range kept[i:j] {
+			for ; pos < h.start; pos++ {
+				writeLine(&body, " ", lines[pos], pos == len(lines)-1)
+			}
+			for k, line := range h.old {
+				writeLine(&body, "-", line, h.start+k == len(lines)-1)
+			}
+			for k, line := range h.new {
+				writeLine(&body, "+", line, h.start+len(h.old) == len(lines) && k == len(h.new)-1)
+			}
+			pos += len(h.old)
+			newCount += len(h.new) - len(h.old)
+		}
+		for ; pos < to; pos++ {
+			writeLine(&body, " ", lines[pos], pos == len(lines)-1)
+		}
+
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", from+1, oldCount, from+1+delta, newCount)
+		b.WriteString(body.String())
+		delta += newCount - oldCount
+		i = j
+	}
+	return b.String()
+}
+
+func accepted(h replaceHunk) bool { return h.decision == hunkAccepted }
+
+func notSkipped(h replaceHunk) bool { return h.decision != hunkSkipped }
+
+// The changes still in the running (accepted, or not yet decided) as one
+// patch for git apply, to be run in the search directory
+func (r *replaceReview) patch(root string) string {
+	var patch strings.Builder
+	for _, f := range r.files {
+		patch.WriteString(f.diff(root, notSkipped))
+	}
+	return patch.String()
+}
+
+// Save the pending changes as a patch instead of writing them, leaving
+// the review open
+func (m *model) savePatch() {
+	if m.blockedByReadOnly("save a patch") {
+		return
+	}
+	name := fmt.Sprintf("lazyrg-%s.patch", time.Now().Format("20060102-150405"))
+	if err := os.WriteFile(name, []byte(m.review.patch(m.paths.root)), 0o644); err != nil {
+		m.statusMessage = fmt.Sprintf("Error saving patch: %s", err)
+		m.statusMessageType = "error"
+		return
+	}
+	m.statusMessage = fmt.Sprintf("Patch saved to %s, nothing was changed: git apply it in %s", name, m.paths.root)
+	m.statusMessageType = "info"
+}
+
+// Write the accepted hunks, log them, and save them as a patch
+func (m *model) applyReview() {
+	r := m.review
+	m.review = nil
+
+	var patch strings.Builder
+	var changed, failed []string
+	applied, skipped := 0, 0
+	for _, f := range r.files {
+		diff := f.diff(m.paths.root, accepted)
+		for _, h := range f.hunks {
+			if h.decision == hunkAccepted {
+				applied++
+			} else {
+				skipped++
+			}
+		}
+		if diff == "" {
+			continue
+		}
+
+		// Don't overwrite changes made since the review started
+		if current, err := os.ReadFile(f.path); err != nil || !bytes.Equal(current, f.content) {
+			failed = append(failed, f.path)
+			continue
+		}
+		info, err := os.Stat(f.path)
+		if err == nil {
+			err = os.WriteFile(f.path, []byte(strings.Join(f.replaced(), "\n")), info.Mode())
+		}
+		if err != nil {
+			failed = append(failed, f.path)
+			continue
+		}
+		changed = append(changed, f.path)
+		patch.WriteString(diff)
+	}
+
+	if len(changed) == 0 {
+		m.statusMessage = fmt.Sprintf("Nothing replaced: %d hunks skipped", skipped)
+		m.statusMessageType = "info"
+		if len(failed) > 0 {
+			m.statusMessage = fmt.Sprintf("Nothing replaced: %d files changed on disk during the review", len(failed))
+			m.statusMessageType = "error"
+		}
+		return
+	}
+
+	name := fmt.Sprintf("lazyrg-%s.patch", time.Now().Format("20060102-150405"))
+	patchErr := os.WriteFile(name, []byte(patch.String()), 0o644)
+	// The files are written either way, so a failure to log them is only
+	// added to what was applied
+	auditErr := recordAudit(auditEntry{
+		Action: fmt.Sprintf("replace %q with %q", m.currentSearchPattern, r.with),
+		Files:  changed,
+	})
+
+	m.statusMessage = fmt.Sprintf("Applied %d hunks in %d files, skipped %d", applied, len(changed), skipped)
+	m.statusMessageType = "info"
+	if len(failed) > 0 {
+		m.statusMessage += fmt.Sprintf("; %d files changed on disk during the review were left alone", len(failed))
+		m.statusMessageType = "error"
+	}
+	if patchErr != nil {
+		m.statusMessage += fmt.Sprintf("; error saving patch: %s", patchErr)
+		m.statusMessageType = "error"
+	} else {
+		m.statusMessage += "; patch saved to " + name
+	}
+	if auditErr != nil {
+		m.statusMessage += fmt.Sprintf("; error writing audit log: %s", auditErr)
+		m.statusMessageType = "error"
+	}
+}
+
+func (m model) reviewView() string {
+	r := m.review
+	width := m.width - 8
+	title := searchPromptStyle.Render(fmt.Sprintf("Replace %s", m.currentSearchPattern))
+	if r.editing {
+		return lipgloss.JoinVertical(lipgloss.Left,
+			title,
+			"",
+			r.input.View(),
+			"",
+			diffContextStyle.Render(fmt.Sprintf("in %d results · enter to review the changes · esc to cancel", len(m.targetResults()))),
+		)
+	}
+
+	f := r.files[r.file]
+	h := f.hunks[r.hunk]
+	accepted, skipped, total := 0, 0, 0
+	for _, file := range r.files {
+		for _, hunk := range file.hunks {
+			total++
+			switch hunk.decision {
+			case hunkAccepted:
+				accepted++
+			case hunkSkipped:
+				skipped++
+			}
+		}
+	}
+
+	rows := []string{
+		title + diffContextStyle.Render(fmt.Sprintf(" → %s · file %d/%d · hunk %d/%d", r.with, r.file+1, len(r.files), r.hunk+1, len(f.hunks))),
+		"",
+		m.paths.show(f.path),
+	}
+	gutter := func(n int) string { return fmt.Sprintf("%5d ", n) }
+	for i := max(h.start-replaceContext, 0); i < h.start; i++ {
+		rows = append(rows, diffContextStyle.Render(gutter(i+1)+"  "+expandTabs(f.lines[i], batTabWidth)))
+	}
+	for i, line := range h.old {
+		rows = append(rows, diffRemovedStyle.Render(gutter(h.start+i+1)+"- "+expandTabs(line, batTabWidth)))
+	}
+	for _, line := range h.new {
+		rows = append(rows, diffAddedStyle.Render("      + "+expandTabs(line, batTabWidth)))
+	}
+	end := h.start + len(h.old)
+	for i := end; i < min(end+replaceContext, len(f.lines)); i++ {
+		rows = append(rows, diffContextStyle.Render(gutter(i+1)+"  "+expandTabs(f.lines[i], batTabWidth)))
+	}
+	for i := range rows {
+		rows[i] = ansi.Truncate(rows[i], width, "…")
+	}
+
+	rows = append(rows,
+		"",
+		fmt.Sprintf("%d accepted · %d skipped · %d to go", accepted, skipped, total-accepted-skipped),
+		diffContextStyle.Render("y accept · n skip · a accept rest of file · d skip rest of file · k back · b browse the results · w save patch · c copy patch · esc cancel"),
+	)
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
+
+// The file's pending changes, if a replacement is staged and it has any
+// that haven't been skipped
+func (r *replaceReview) stagedFile(path string) *replaceFile {
+	if r == nil || r.editing {
+		return nil
+	}
+	for _, f := range r.files {
+		if f.path != path {
+			continue
+		}
+		for _, h := range f.hunks {
+			if h.decision != hunkSkipped {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// Show a file with its pending hunks inline: the old lines marked removed
+// and the new ones added, with the part of each line that changes
+// emphasized. It opens at the first hunk.
+func loadStagedFile(f *replaceFile, token int) tea.Cmd {
+	return func() tea.Msg {
+		digits := max(len(strconv.Itoa(len(f.lines))), 4)
+		var lines []string
+		top := -1
+		pos := 0
+		for _, h := range f.hunks {
+			if h.decision == hunkSkipped {
+				continue
+			}
+			for ; pos < h.start; pos++ {
+				lines = append(lines, fmt.Sprintf("  %*d | ", digits, pos+1)+expandTabs(f.lines[pos], batTabWidth))
+			}
+			if top < 0 {
+				top = max(len(lines)-replaceContext, 0)
+			}
+			for i, line := range h.old {
+				old, _ := inlineDiff(line, pairedLine(h.new, i, len(h.old)))
+				lines = append(lines, diffRemovedStyle.Render(fmt.Sprintf("- %*d | ", digits, h.start+i+1))+old)
+			}
+			for i, line := range h.new {
+				_, added := inlineDiff(pairedLine(h.old, i, len(h.new)), line)
+				lines = append(lines, diffAddedStyle.Render(fmt.Sprintf("+ %*s | ", digits, ""))+added)
+			}
+			pos = h.start + len(h.old)
+		}
+		for ; pos < len(f.lines); pos++ {
+			lines = append(lines, fmt.Sprintf("  %*d | ", digits, pos+1)+expandTabs(f.lines[pos], batTabWidth))
+		}
+		doc := &fileDocument{lines: lines, gutter: digits + 5}
+		return fileLoadedMsg{token: token, final: true, doc: doc, top: max(top, 0)}
+	}
+}
+
+// The line of other at i when a hunk's old and new lines pair up one to
+// one, so there's something to compare with
+func pairedLine(other []string, i, n int) string {
+	if len(other) != n {
+		return ""
+	}
+	return other[i]
+}
+
+// The old and new versions of a line, styled as removed and added, with
+// what changes between them (everything after the common prefix and
+// before the common suffix) emphasized
+func inlineDiff(old, new string) (string, string) {
+	if old == "" || new == "" {
+		// Nothing to compare with
+		return expandTabs(diffRemovedStyle.Render(old), batTabWidth), expandTabs(diffAddedStyle.Render(new), batTabWidth)
+	}
+	prefix := 0
+	for prefix < min(len(old), len(new)) && old[prefix] == new[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < min(len(old), len(new))-prefix && old[len(old)-1-suffix] == new[len(new)-1-suffix] {
+		suffix++
+	}
+	// Don't split a multibyte character
+	for prefix > 0 && prefix < len(old) && !utf8.RuneStart(old[prefix]) {
+		prefix--
+	}
+	for suffix > 0 && !utf8.RuneStart(old[len(old)-suffix]) {
+		suffix--
+	}
+	style := func(s string, base, emphasis lipgloss.Style) string {
+		if s == "" {
+			return ""
+		}
+		end := len(s) - suffix
+		return expandTabs(base.Render(s[:prefix])+emphasis.Render(s[prefix:end])+base.Render(s[end:]), batTabWidth)
+	}
+	return style(old, diffRemovedStyle, diffRemovedEmphasisStyle), style(new, diffAddedStyle, diffAddedEmphasisStyle)
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// n lines of text, with foo in those numbered
+func numberedLines(n int, foo ...int) string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = "line " + string(rune('a'+i))
+	}
+	for _, i := range foo {
+		lines[i-1] = "a foo here"
+	}
+	return strings.Join(lines, "\n")
+}
+
+// The hunks replacing foo on the lines given, all accepted
+func replaceHunks(t *testing.T, content, with string, lineNums ...int) (*replaceFile, string) {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var items []Item
+	for _, line := range lineNums {
+		items = append(items, Item{fullPath: path, lineNum: line})
+	}
+	files, err := buildReplaceFiles(regexp.MustCompile("foo"), with, items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("got %d files with changes, want 1", len(files))
+	}
+	for i := range files[0].hunks {
+		files[0].hunks[i].decision = hunkAccepted
+	}
+	return files[0], dir
+}
+
+func TestReplaceDiffApplies(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+	tests := []struct {
+		name    string
+		content string
+		with    string
+		lines   []int // the results' line numbers
+		skip    []int // hunks left out
+	}{
+		{name: "one line", content: "a\nfoo\nb\n", with: "bar", lines: []int{2}},
+		{name: "first and last lines", content: "foo\na\nfoo\n", with: "bar", lines: []int{1, 3}},
+		{name: "no newline at end, last line", content: "a\nb\nfoo", with: "bar", lines: []int{3}},
+		{name: "no newline at end, context reaches the end", content: "a\nfoo\nb", with: "bar", lines: []int{2}},
+		{name: "no newline at end, away from the end", content: numberedLines(12, 2), with: "bar", lines: []int{2}},
+		{name: "hunks sharing context", content: numberedLines(20, 2, 7) + "\n", with: "bar", lines: []int{2, 7}},
+		{name: "separate hunks", content: numberedLines(30, 2, 15, 28) + "\n", with: "bar", lines: []int{2, 15, 28}},
+		{name: "separate hunks, no newline at end", content: numberedLines(30, 2, 30), with: "bar", lines: []int{2, 30}},
+		{name: "adjacent lines", content: numberedLines(10, 4, 5, 6) + "\n", with: "bar", lines: []int{4, 5, 6}},
+		{name: "lines added before a later hunk", content: numberedLines(30, 3, 20) + "\n", with: "bar\nbaz", lines: []int{3, 20}},
+		{name: "skipped hunk", content: numberedLines(30, 2, 15, 28) + "\n", with: "bar", lines: []int{2, 15, 28}, skip: []int{1}},
+		{name: "windows line endings", content: "a\r\nfoo\r\nb\r\n", with: "bar", lines: []int{2}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f, dir := replaceHunks(t, test.content, test.with, test.lines...)
+			for _, i := range test.skip {
+				f.hunks[i].decision = hunkSkipped
+			}
+			patch := f.diff(dir, accepted)
+			want := strings.Join(f.replaced(), "\n")
+
+			apply := exec.Command("git", "apply", "-")
+			apply.Dir = dir
+			apply.Stdin = strings.NewReader(patch)
+			if out, err := apply.CombinedOutput(); err != nil {
+				t.Fatalf("git apply: %v\n%s\npatch:\n%s", err, out, patch)
+			}
+			got, err := os.ReadFile(f.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != want {
+				t.Errorf("after git apply:\n%q\nwant:\n%q\npatch:\n%s", got, want, patch)
+			}
+		})
+	}
+}
+
+func TestReplaceDiffNoNewlineAtEnd(t *testing.T) {
+	f := &replaceFile{
+		path:  "/src/file.txt",
+		lines: []string{"a", "foo"},
+		hunks: []replaceHunk{{start: 1, old: []string{"foo"}, new: []string{"bar"}, decision: hunkAccepted}},
+	}
+	want := "--- a/file.txt\n+++ b/file.txt\n@@ -1,2 +1,2 @@\n a\n-foo\n\\ No newline at end of file\n+bar\n\\ No newline at end of file\n"
+	if got := f.diff("/src", accepted); got != want {
+		t.Errorf("diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// git apply goes by the old file's line numbers, so the new file's are
+// checked here
+func TestReplaceDiffHunkHeaders(t *testing.T) {
+	f, dir := replaceHunks(t, numberedLines(30, 3, 20)+"\n", "bar\nbaz", 3, 20)
+	var headers []string
+	for _, line := range strings.Split(f.diff(dir, accepted), "\n") {
+		if strings.HasPrefix(line, "@@") {
+			headers = append(headers, line)
+		}
+	}
+	want := []string{"@@ -1,6 +1,7 @@", "@@ -17,7 +18,8 @@"}
+	if strings.Join(headers, "\n") != strings.Join(want, "\n") {
+		t.Errorf("hunk headers %q, want %q", headers, want)
+	}
+}
+
+func TestApplyReview(t *testing.T) {
+	tests := []struct {
+		name       string
+		auditFails bool
+		want       string
+	}{
+		{name: "logged", want: "Applied 2 hunks in 1 files, skipped 1; patch saved to "},
+		{name: "audit log can't be written", auditFails: true, want: "; error writing audit log: "},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := t.TempDir()
+			if test.auditFails {
+				// A file where the state directory should be
+				state = filepath.Join(state, "file")
+				if err := os.WriteFile(state, nil, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Setenv("XDG_STATE_HOME", state)
+
+			// The patch is saved in the working directory
+			wd, err := os.Getwd()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chdir(t.TempDir()); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { os.Chdir(wd) }) //nolint: errcheck
+
+			f, dir := replaceHunks(t, numberedLines(30, 2, 15, 28)+"\n", "bar", 2, 15, 28)
+			f.hunks[1].decision = hunkSkipped
+			m := initialModel()
+			m.paths.root = dir
+			m.currentSearchPattern = "foo"
+			m.review = &replaceReview{with: "bar", files: []*replaceFile{f}}
+			m.applyReview()
+
+			if !strings.Contains(m.statusMessage, test.want) {
+				t.Errorf("status %q, want it to contain %q", m.statusMessage, test.want)
+			}
+			got, err := os.ReadFile(f.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := strings.Join(f.replaced(), "\n"); string(got) != want {
+				t.Errorf("file is\n%s\nwant\n%s", got, want)
+			}
+			if n := strings.Count(string(got), "bar"); n != 2 {
+				t.Errorf("%d lines replaced, want 2", n)
+			}
+			patches, _ := filepath.Glob("lazyrg-*.patch")
+			if len(patches) != 1 {
+				t.Errorf("got patches %v, want one", patches)
+			}
+			if !test.auditFails {
+				log, err := os.ReadFile(filepath.Join(state, "lazyrg", "audit.log"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !strings.Contains(string(log), f.path) {
+					t.Errorf("audit log doesn't mention %s:\n%s", f.path, log)
+				}
+			}
+		})
+	}
+}
+
+func TestReplacePattern(t *testing.T) {
+	tests := []struct {
+		flags []string
+		line  string
+		want  string
+	}{
+		{nil, "foo foobar Foo", "X Xbar Foo"},
+		{[]string{"-w"}, "foo foobar Foo", "X foobar Foo"},
+		{[]string{"-i"}, "foo foobar Foo", "X Xbar X"},
+		{[]string{"-i", "-w"}, "foo foobar Foo", "X foobar X"},
+		{[]string{"--word-regexp", "--ignore-case"}, "FOO.foo_", "X.foo_"},
+	}
+	for _, test := range tests {
+		re := regexp.MustCompile(replacePattern("foo", test.flags))
+		if got := re.ReplaceAllString(test.line, "X"); got != test.want {
+			t.Errorf("with %v, replacing in %q gave %q, want %q", test.flags, test.line, got, test.want)
+		}
+	}
+}
+
+// The patch saved or copied from the review has the hunks not skipped,
+// including those not decided yet
+func TestReplaceReviewPatch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+	f, dir := replaceHunks(t, numberedLines(30, 2, 15, 28)+"\n", "bar", 2, 15, 28)
+	f.hunks[0].decision = hunkSkipped
+	f.hunks[1].decision = hunkUndecided
+	r := &replaceReview{files: []*replaceFile{f}}
+
+	apply := exec.Command("git", "apply", "-")
+	apply.Dir = dir
+	apply.Stdin = strings.NewReader(r.patch(dir))
+	if out, err := apply.CombinedOutput(); err != nil {
+		t.Fatalf("git apply: %v\n%s", err, out)
+	}
+	got, err := os.ReadFile(f.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.ReplaceAll(numberedLines(30, 15, 28), "foo", "bar"), "\n")
+	lines[1] = "a foo here"
+	want := strings.Join(lines, "\n") + "\n"
+	if string(got) != want {
+		t.Errorf("after git apply:\n%q\nwant:\n%q", got, want)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// One repository's share of a multi-repo search
+type repoSummary struct {
+	path    string
+	display string
+	matches int
+	files   int
+}
+
+func (r repoSummary) Title() string { return fmt.Sprintf("%6d  %s", r.matches, r.display) }
+
+func (r repoSummary) Description() string {
+	if r.files == 1 {
+		return "        1 file"
+	}
+	return fmt.Sprintf("        %d files", r.files)
+}
+
+func (r repoSummary) FilterValue() string { return r.display }
+
+func isRepo(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+// Expand the repositories given with -repos. A path that's a git repository
+// is used as it is, and any other directory stands for the repositories
+// directly inside it, like a directory of clones. A leading ~ is the home
+// directory, for paths from the config file.
+func findRepos(paths []string) ([]string, error) {
+	var repos []string
+	for _, path := range paths {
+		if rest, ok := strings.CutPrefix(path, "~/"); ok {
+			if home, err := os.UserHomeDir(); err == nil {
+				path = filepath.Join(home, rest)
+			}
+		}
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		if isRepo(path) {
+			repos = append(repos, path)
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		for _, entry := range entries {
+			if dir := filepath.Join(path, entry.Name()); entry.IsDir() && isRepo(dir) {
+				repos = append(repos, dir)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s is not a git repository and has none inside it", path)
+		}
+	}
+	return repos, nil
+}
+
+// The deepest directory containing all of paths
+func commonDir(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+	dir := filepath.Clean(paths[0])
+	for _, path := range paths[1:] {
+		path = filepath.Clean(path)
+		for dir != path && !strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				return dir
+			}
+			dir = parent
+		}
+	}
+	return dir
+}
+
+func newRepoList() list.Model {
+	repoList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	repoList.Title = "Repositories"
+	repoList.SetShowHelp(false)
+	repoList.SetStatusBarItemName("repository", "repositories")
+	repoList.Styles.Title = lipgloss.NewStyle().
+		Foreground(special).
+		Bold(true).
+		MarginLeft(2)
+	return repoList
+}
+
+// The repository a result path is in
+func (m model) repoOf(path string) (string, bool) {
+	repo := ""
+	for _, r := range m.repos {
+		if strings.HasPrefix(path, r+string(filepath.Separator)) && len(r) > len(repo) {
+			repo = r
+		}
+	}
+	return repo, repo != ""
+}
+
+// Open the per-repository match counts for the current results, most
+// matches first
+func (m *model) openRepos() tea.Cmd {
+	if len(m.repos) == 0 {
+		m.statusMessage = "Not searching multiple repositories (start lazyrg with -repos)"
+		m.statusMessageType = "error"
+		return nil
+	}
+
+	summaries := make(map[string]*repoSummary, len(m.repos))
+	for _, repo := range m.repos {
+		summaries[repo] = &repoSummary{path: repo, display: m.paths.show(repo)}
+	}
+	lastFile := ""
+	for _, result := range m.results {
+		repo, ok := m.repoOf(result.fullPath)
+		if !ok {
+			continue
+		}
+		summaries[repo].matches++
+		if result.fullPath != lastFile {
+			summaries[repo].files++
+			lastFile = result.fullPath
+		}
+	}
+
+	items := make([]list.Item, 0, len(m.repos))
+	for _, repo := range m.repos {
+		items = append(items, *summaries[repo])
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].(repoSummary).matches > items[j].(repoSummary).matches
+	})
+	m.showRepos = true
+	return m.repoList.SetItems(items)
+}
+
+// Narrow the results to one repository, with the result filter so it shows
+// and clears like any other
+func (m *model) drillIntoRepo(repo string) tea.Cmd {
+	pattern := "^" + regexp.QuoteMeta(repo+string(filepath.Separator))
+	filter, err := parseResultFilter(pattern)
+	if err != nil {
+		return nil
+	}
+	m.resultsState.filterInput.SetValue(pattern)
+	m.resultsState.filter = filter
+	m.resultsState.filterErr = nil
+	m.activeTab = resultsTab
+	m.layoutResults()
+	m.refreshResults()
+	m.reportResultCount()
+	return m.updatePreview()
+}
+
+// Handle keys while the repository counts are open
+func (m model) updateRepos(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.repoList.SettingFilter() {
+		switch {
+		case key.Matches(msg, m.keymap.Quit):
+			return m, tea.Quit
+
+		case key.Matches(msg, m.keymap.Back) || key.Matches(msg, m.keymap.Repos):
+			m.showRepos = false
+			return m, nil
+
+		case key.Matches(msg, m.keymap.Enter):
+			repo, ok := m.repoList.SelectedItem().(repoSummary)
+			if !ok {
+				return m, nil
+			}
+			m.showRepos = false
+			return m, m.drillIntoRepo(repo.path)
+		}
+	}
+
+	var cmd tea.Cmd
+	m.repoList, cmd = m.repoList.Update(msg)
+	return m, cmd
+}
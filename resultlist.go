@@ -0,0 +1,349 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/paginator"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Each result takes a title line and a description line, with a blank line
+// between results
+const (
+	resultHeight  = 2
+	resultSpacing = 1
+)
+
+// The results list. It looks and behaves like a bubbles list, but it holds
+// the results as a plain slice plus indices into it and only ever looks at
+// the page on screen, so a search with hundreds of thousands of matches
+// scrolls and filters as quickly as a small one.
+type resultList struct {
+	title       string
+	results     []Item // the whole result set, shared with the model
+	base        []int  // indices of the results that pass the result filter
+	visible     []int  // base, narrowed further by the text typed after "/"
+	query       string // the query visible was filtered with
+	cursor      int    // index into visible
+	state       list.FilterState
+	filterInput textinput.Model
+	paginator   paginator.Model
+	delegate    resultDelegate
+	keys        list.KeyMap
+	styles      list.Styles
+	width       int
+	height      int
+}
+
+func newResultList(title string, delegate resultDelegate) resultList {
+	styles := list.DefaultStyles()
+	styles.Title = lipgloss.NewStyle().
+		Foreground(special).
+		Bold(true).
+		MarginLeft(2)
+	styles.FilterPrompt = lipgloss.NewStyle().
+		Foreground(special)
+	styles.FilterCursor = lipgloss.NewStyle().
+		Foreground(highlight)
+
+	filterInput := textinput.New()
+	filterInput.Prompt = "Filter: "
+	filterInput.PromptStyle = styles.FilterPrompt
+	filterInput.Cursor.Style = styles.FilterCursor
+
+	p := paginator.New()
+	p.Type = paginator.Dots
+	p.ActiveDot = styles.ActivePaginationDot.String()
+	p.InactiveDot = styles.InactivePaginationDot.String()
+
+	return resultList{
+		title:       title,
+		filterInput: filterInput,
+		paginator:   p,
+		delegate:    delegate,
+		keys:        list.DefaultKeyMap(),
+		styles:      styles,
+	}
+}
+
+func (l *resultList) setSize(width, height int) {
+	l.width, l.height = width, height
+	l.filterInput.Width = width - lipgloss.Width(l.filterInput.Prompt) - 3
+	l.updatePagination()
+}
+
+// Replace the filtered indices, keeping the cursor on the same result if
+// it's still visible
+func (l *resultList) setResults(results []Item, base []int) {
+	selected := -1
+	if l.cursor < len(l.visible) {
+		selected = l.visible[l.cursor]
+	}
+
+	l.results, l.base = results, base
+	l.applyFilter()
+
+	l.cursor = 0
+	if selected >= 0 {
+		// Indices only ever grow, so visible is sorted
+		if i := sort.SearchInts(l.visible, selected); i < len(l.visible) && l.visible[i] == selected {
+			l.cursor = i
+		}
+	}
+	l.updatePagination()
+}
+
+// Add newly streamed results without touching the ones already filtered
+func (l *resultList) appendResults(results []Item, added []int) {
+	l.results = results
+	l.base = append(l.base, added...)
+	if query := l.filterValue(); query == "" {
+		l.visible = l.base
+	} else {
+		for _, i := range added {
+			if l.matchesFilter(i, query) {
+				l.visible = append(l.visible, i)
+			}
+		}
+	}
+	l.updatePagination()
+}
+
+func (l *resultList) filterValue() string {
+	return strings.ToLower(strings.TrimSpace(l.filterInput.Value()))
+}
+
+func (l *resultList) matchesFilter(i int, query string) bool {
+	return containsFold(l.results[i].fileName, query) || containsFold(l.results[i].content, query)
+}
+
+// Filter the results for the current query. Typing more of the query only
+// ever removes results, so in that case only the visible ones are checked.
+func (l *resultList) applyFilter() {
+	query := l.filterValue()
+	candidates := l.base
+	switch {
+	case query == "":
+		l.visible = l.base
+		l.query = ""
+		return
+	case l.query != "" && strings.HasPrefix(query, l.query):
+		candidates = l.visible
+	}
+
+	var visible []int
+	for _, i := range candidates {
+		if l.matchesFilter(i, query) {
+			visible = append(visible, i)
+		}
+	}
+	l.visible, l.query = visible, query
+}
+
+// Whether s contains the lower-case substr, ignoring case, without the
+// allocations of lower-casing every result
+func containsFold(s, substr string) bool {
+	n := len(substr)
+	if n == 0 {
+		return true
+	}
+	first := substr[0]
+	for i := 0; i+n <= len(s); i++ {
+		if c := s[i]; c != first && c|0x20 != first {
+			continue
+		}
+		if strings.EqualFold(s[i:i+n], substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *resultList) resetFilter() {
+	l.state = list.Unfiltered
+	l.filterInput.Blur()
+	l.filterInput.SetValue("")
+	l.setResults(l.results, l.base)
+}
+
+func (l *resultList) count() int { return len(l.visible) }
+
+// How many results pass the result filter, before the "/" filter
+func (l *resultList) total() int { return len(l.base) }
+
+func (l *resultList) at(i int) Item { return l.results[l.visible[i]] }
+
+func (l *resultList) index() int { return l.cursor }
+
+func (l *resultList) selected() (Item, bool) {
+	if l.cursor >= len(l.visible) {
+		return Item{}, false
+	}
+	return l.at(l.cursor), true
+}
+
+func (l *resultList) selectIndex(i int) {
+	l.cursor = max(min(i, len(l.visible)-1), 0)
+	l.updatePagination()
+}
+
+func (l *resultList) cursorDown() { l.selectIndex(l.cursor + 1) }
+
+func (l *resultList) filterState() list.FilterState { return l.state }
+
+func (l *resultList) settingFilter() bool { return l.state == list.Filtering }
+
+func (l *resultList) perPage() int {
+	available := l.height - 2 - 2 - 1 // title bar, status bar and pagination
+	return max(1, available/(resultHeight+resultSpacing))
+}
+
+// Keep the page on the cursor
+func (l *resultList) updatePagination() {
+	l.paginator.PerPage = l.perPage()
+	l.paginator.SetTotalPages(max(len(l.visible), 1))
+	l.paginator.Page = l.cursor / l.paginator.PerPage
+}
+
+func (l resultList) Update(msg tea.Msg) (resultList, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if l.state == list.Filtering {
+		if ok {
+			switch {
+			case key.Matches(keyMsg, l.keys.CancelWhileFiltering):
+				l.resetFilter()
+				return l, nil
+			case key.Matches(keyMsg, l.keys.AcceptWhileFiltering):
+				l.filterInput.Blur()
+				l.state = list.FilterApplied
+				if l.filterValue() == "" {
+					l.resetFilter()
+				}
+				return l, nil
+			}
+		}
+
+		before := l.filterInput.Value()
+		var cmd tea.Cmd
+		l.filterInput, cmd = l.filterInput.Update(msg)
+		if l.filterInput.Value() != before {
+			l.applyFilter()
+			l.cursor = 0
+			l.updatePagination()
+		}
+		return l, cmd
+	}
+	if !ok {
+		return l, nil
+	}
+
+	perPage := l.paginator.PerPage
+	switch {
+	case key.Matches(keyMsg, l.keys.CursorUp):
+		l.selectIndex(l.cursor - 1)
+	case key.Matches(keyMsg, l.keys.CursorDown):
+		l.selectIndex(l.cursor + 1)
+	case key.Matches(keyMsg, l.keys.PrevPage):
+		l.selectIndex((l.paginator.Page - 1) * perPage)
+	case key.Matches(keyMsg, l.keys.NextPage):
+		if l.paginator.Page < l.paginator.TotalPages-1 {
+			l.selectIndex((l.paginator.Page + 1) * perPage)
+		}
+	case key.Matches(keyMsg, l.keys.GoToStart):
+		l.selectIndex(0)
+	case key.Matches(keyMsg, l.keys.GoToEnd):
+		l.selectIndex(len(l.visible) - 1)
+	case key.Matches(keyMsg, l.keys.ClearFilter) && l.state == list.FilterApplied:
+		l.resetFilter()
+	case key.Matches(keyMsg, l.keys.Filter) && len(l.base) > 0:
+		l.state = list.Filtering
+		l.filterInput.CursorEnd()
+		return l, l.filterInput.Focus()
+	}
+	return l, nil
+}
+
+func (l resultList) View() string {
+	var (
+		sections    []string
+		availHeight = l.height
+	)
+
+	title := l.styles.Title.Render(l.title)
+	if l.state == list.Filtering {
+		title = l.filterInput.View()
+	}
+	for _, v := range []string{l.styles.TitleBar.Render(title), l.statusView()} {
+		sections = append(sections, v)
+		availHeight -= lipgloss.Height(v)
+	}
+
+	pagination := l.paginationView()
+	availHeight -= lipgloss.Height(pagination)
+
+	sections = append(sections, lipgloss.NewStyle().Height(availHeight).Render(l.pageView()))
+	sections = append(sections, pagination)
+	return lipgloss.JoinVertical(lipgloss.Left, sections...)
+}
+
+func (l resultList) statusView() string {
+	var status string
+	switch {
+	case l.state == list.Filtering && len(l.visible) == 0:
+		status = l.styles.StatusEmpty.Render("Nothing matched")
+	case l.state != list.Filtering && len(l.base) == 0:
+		status = l.styles.StatusEmpty.Render("No results")
+	default:
+		if l.state == list.FilterApplied {
+			status += fmt.Sprintf("“%s” ", ansi.Truncate(strings.TrimSpace(l.filterInput.Value()), 10, "…"))
+		}
+		status += fmt.Sprintf("%d results", len(l.visible))
+	}
+
+	if filtered := len(l.base) - len(l.visible); filtered > 0 {
+		status += l.styles.DividerDot.String()
+		status += l.styles.StatusBarFilterCount.Render(fmt.Sprintf("%d filtered", filtered))
+	}
+	return l.styles.StatusBar.Render(status)
+}
+
+// Dots for a handful of pages, a page number otherwise. Checking the page
+// count first avoids building a string of dots for every page of a huge
+// result set just to find it doesn't fit.
+func (l resultList) paginationView() string {
+	p := l.paginator
+	if p.TotalPages < 2 {
+		return ""
+	}
+	if p.TotalPages*ansi.StringWidth(p.InactiveDot) > l.width-l.styles.PaginationStyle.GetHorizontalFrameSize() {
+		p.Type = paginator.Arabic
+		return l.styles.PaginationStyle.Render(l.styles.ArabicPagination.Render(p.View()))
+	}
+	return l.styles.PaginationStyle.Render(p.View())
+}
+
+func (l resultList) pageView() string {
+	if len(l.visible) == 0 {
+		if l.state == list.Filtering {
+			return ""
+		}
+		return l.styles.NoItems.Render("No results.")
+	}
+
+	var b strings.Builder
+	start, end := l.paginator.GetSliceBounds(len(l.visible))
+	for i := start; i < end; i++ {
+		l.delegate.render(&b, l, i, l.at(i))
+		if i != end-1 {
+			b.WriteString(strings.Repeat("\n", resultSpacing+1))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,465 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	resultFilterStyle = lipgloss.NewStyle().
+				PaddingLeft(2)
+
+	resultFilterErrorStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#FF5F87"))
+)
+
+// Everything the results tab keeps while you're on other tabs
+type resultsState struct {
+	list           resultList
+	filter         *resultFilter
+	filterInput    textinput.Model
+	filterErr      error
+	editingFilter  bool
+	sidebar        list.Model
+	showSidebar    bool
+	sidebarFocused bool
+	sidebarIndex   map[string]int // path to position in the sidebar
+	sidebarSort    string
+	dirty          map[string]bool // files git has uncommitted changes to
+	dirtySearch    int             // the search dirty was found for
+	showPreview    bool
+	preview        *preview
+	previewing     resultKey // the result the preview is (or is being) loaded for
+	scanned        int       // how many results have been offered to the list
+	limit          int       // how many results the list takes before loading the next page
+	hscroll        *horizontalScroll
+	labels         *resultLabels
+	minimap        *minimap // also the index of matched files the jump prompt searches
+	jumpInput      textinput.Model
+	jumping        bool
+	jumpMatches    []int     // positions in the minimap's files, best match first
+	jumpChoice     int       // which of jumpMatches is selected
+	jumpFrom       int       // the list cursor when the prompt opened, to go back to
+	ignored        int       // results left out as known false positives
+	opened         resultKey // the result last opened in the file view, to come back to
+}
+
+// How many results are loaded into the list at a time
+const resultPageSize = 1000
+
+func newResultFilter() textinput.Model {
+	resultFilter := textinput.New()
+	resultFilter.Placeholder = "regex on path or line, or lang:Go; prefix with ! to exclude"
+	resultFilter.Prompt = "Filter ❯ "
+	resultFilter.PromptStyle = searchPromptStyle
+	resultFilter.TextStyle = lipgloss.NewStyle().Foreground(highlight)
+	resultFilter.Cursor.Style = lipgloss.NewStyle().Foreground(special)
+	return resultFilter
+}
+
+// Filters starting with this keep the results in one language, like
+// "lang:Go", rather than matching a regex
+const languageFilterPrefix = "lang:"
+
+// A client-side filter over the current result set
+type resultFilter struct {
+	re       *regexp.Regexp
+	language string
+	invert   bool
+}
+
+// Compile the filter input. An empty pattern yields a nil filter.
+func parseResultFilter(pattern string) (*resultFilter, error) {
+	invert := strings.HasPrefix(pattern, "!")
+	pattern = strings.TrimPrefix(pattern, "!")
+	if pattern == "" {
+		return nil, nil
+	}
+	if language, ok := strings.CutPrefix(pattern, languageFilterPrefix); ok {
+		return &resultFilter{language: language, invert: invert}, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &resultFilter{re: re, invert: invert}, nil
+}
+
+func (f *resultFilter) keep(item Item) bool {
+	if f == nil {
+		return true
+	}
+	var matched bool
+	if f.re == nil {
+		matched = strings.EqualFold(languageOf(item.fullPath), f.language)
+	} else {
+		matched = f.re.MatchString(item.fullPath) || f.re.MatchString(item.content)
+	}
+	return matched != f.invert
+}
+
+// Identifies a result across list rebuilds
+type resultKey struct {
+	path   string
+	line   int
+	column int
+}
+
+func (i Item) key() resultKey {
+	return resultKey{path: i.fullPath, line: i.lineNum, column: i.column}
+}
+
+// Toggle the mark on the selected result and move on to the next one
+func (m *model) toggleMark() {
+	item, ok := m.resultsState.list.selected()
+	if !ok {
+		return
+	}
+	if m.marked[item.key()] {
+		delete(m.marked, item.key())
+	} else {
+		m.marked[item.key()] = true
+	}
+	m.resultsState.list.cursorDown()
+	m.loadMoreResults()
+	m.reportMarks()
+}
+
+// Mark every visible result, or clear the marks if they're all marked already
+func (m *model) toggleMarkAll() {
+	l := m.resultsState.list
+	all := l.count() > 0
+	for i := range l.count() {
+		if !m.marked[l.at(i).key()] {
+			all = false
+			break
+		}
+	}
+	for i := range l.count() {
+		if all {
+			delete(m.marked, l.at(i).key())
+		} else {
+			m.marked[l.at(i).key()] = true
+		}
+	}
+	m.reportMarks()
+}
+
+func (m *model) reportMarks() {
+	m.statusMessage = fmt.Sprintf("%d results marked", len(m.marked))
+	m.statusMessageType = "info"
+}
+
+// The results a batch action applies to: every marked result, in result
+// order, or just the selected one if nothing is marked
+func (m model) selectedResults() []Item {
+	if len(m.marked) == 0 {
+		if item, ok := m.resultsState.list.selected(); ok {
+			return []Item{item}
+		}
+		return nil
+	}
+	var items []Item
+	for _, result := range m.results {
+		if m.marked[result.key()] {
+			items = append(items, result)
+		}
+	}
+	return items
+}
+
+// Move to the first match in the next (dir > 0) or previous file
+func (m *model) jumpFile(dir int) {
+	l := &m.resultsState.list
+	if l.count() == 0 {
+		return
+	}
+
+	i := l.index()
+	if dir > 0 {
+		path := l.at(i).fullPath
+		for i < l.count()-1 && l.at(i).fullPath == path {
+			i++
+		}
+		if l.at(i).fullPath == path {
+			// Already in the last loaded file
+			m.loadMoreResults()
+			return
+		}
+	} else {
+		// Back past the start of this file, then to the start of the one before
+		for i > 0 && l.at(i-1).fullPath == l.at(i).fullPath {
+			i--
+		}
+		if i > 0 {
+			i--
+			for i > 0 && l.at(i-1).fullPath == l.at(i).fullPath {
+				i--
+			}
+		}
+	}
+	l.selectIndex(i)
+	m.loadMoreResults()
+	m.followSidebar()
+}
+
+// Distinct file paths among results, in order of first appearance
+func uniquePaths(items []Item) []string {
+	seen := map[string]bool{}
+	var paths []string
+	for _, item := range items {
+		if !seen[item.fullPath] {
+			seen[item.fullPath] = true
+			paths = append(paths, item.fullPath)
+		}
+	}
+	return paths
+}
+
+// Add streamed results and load as many as the current page allows
+func (m *model) appendResults(results []Item) {
+	if len(m.excludes) > 0 {
+		// Already running when the file was excluded
+		results = slices.DeleteFunc(results, func(result Item) bool {
+			return m.excluded(result.fullPath)
+		})
+	}
+	if len(m.dismissed) > 0 {
+		results = slices.DeleteFunc(results, func(result Item) bool {
+			return m.dismissed[result.dismissKey()]
+		})
+	}
+	if len(m.ignoreRules) > 0 {
+		n := len(results)
+		results = slices.DeleteFunc(results, m.ignoredResult)
+		m.resultsState.ignored += n - len(results)
+	}
+	if m.expandMatches {
+		results = splitMatches(results)
+	}
+	m.results = append(m.results, results...)
+	m.loadResults()
+}
+
+// Pass results that get through the result filter on to the list, up to
+// the page limit. The rest wait until the list is scrolled near its end.
+func (m *model) loadResults() {
+	s := &m.resultsState
+	var added []int
+	for s.scanned < len(m.results) && s.list.total()+len(added) < s.limit {
+		if s.filter.keep(m.results[s.scanned]) {
+			added = append(added, s.scanned)
+		}
+		s.scanned++
+	}
+
+	visible := s.list.count()
+	s.list.appendResults(m.results, added)
+	m.extendSidebar(visible)
+}
+
+// Select the result last opened in the file view again, if it's still in
+// the list, so coming back from the viewer lands where you left
+func (m *model) returnToOpened() {
+	l := &m.resultsState.list
+	if item, ok := l.selected(); ok && item.key() == m.resultsState.opened {
+		return
+	}
+	for i := 0; i < l.count(); i++ {
+		if l.at(i).key() == m.resultsState.opened {
+			l.selectIndex(i)
+			m.followSidebar()
+			return
+		}
+	}
+}
+
+// Select the result at index target in the result set, loading results up
+// to it if it's past the loaded pages. If the quick filter hides it, the
+// first visible result after it is selected instead.
+func (m *model) selectResult(target int) bool {
+	s := &m.resultsState
+	for s.scanned <= target && s.scanned < len(m.results) {
+		s.limit += resultPageSize
+		m.loadResults()
+	}
+	l := &s.list
+	i := sort.Search(l.count(), func(i int) bool { return l.visible[i] >= target })
+	if i == l.count() {
+		// Hidden by the quick filter, along with everything after it
+		return false
+	}
+	l.selectIndex(i)
+	m.loadMoreResults()
+	m.followSidebar()
+	return true
+}
+
+// Load the next page once the cursor is on the last loaded page
+func (m *model) loadMoreResults() {
+	s := &m.resultsState
+	if s.scanned == len(m.results) || s.list.index() < s.list.count()-s.list.paginator.PerPage {
+		return
+	}
+	s.limit += resultPageSize
+	m.loadResults()
+	if m.search == nil {
+		m.reportResultCount()
+	}
+}
+
+func (m model) allLoaded() bool {
+	return m.resultsState.scanned == len(m.results)
+}
+
+// Re-run the result filter from the start of the result set, keeping the
+// selection on the same result where it's still visible
+func (m *model) refreshResults() {
+	s := &m.resultsState
+	var base []int
+	s.scanned = 0
+	for s.scanned < len(m.results) && len(base) < s.limit {
+		if s.filter.keep(m.results[s.scanned]) {
+			base = append(base, s.scanned)
+		}
+		s.scanned++
+	}
+	s.list.setResults(m.results, base)
+	s.minimap.reset()
+	m.syncSidebar()
+}
+
+// Clear the result set ahead of a new search
+func (m *model) resetResults() {
+	m.results = nil
+	m.staleResults = false
+	m.resultsState.ignored = 0
+	m.resultsState.minimap.reset()
+	m.resultsState.scanned = 0
+	m.resultsState.limit = resultPageSize
+	clear(m.marked)
+	m.resultsState.preview = nil
+	m.resultsState.previewing = resultKey{}
+	m.resultsState.list.setResults(nil, nil)
+	m.syncSidebar()
+}
+
+// The width inside the document's border and padding, shared by the
+// results list and the panes either side of it
+func (m model) resultsWidth() int {
+	return m.width - 4 - docStyle.GetHorizontalPadding() - docStyle.GetHorizontalBorderSize()
+}
+
+// Size the results list around the filter bar and sidebar, if they're showing
+func (m *model) layoutResults() {
+	height := m.listHeight
+	if m.showResultsBar() {
+		height -= 2
+	}
+	sidebarWidth := m.sidebarWidth()
+	m.resultsState.list.setSize(m.resultsWidth()-sidebarWidth-m.previewWidth()-m.minimapWidth(), height)
+	m.resultsState.sidebar.SetSize(max(sidebarWidth-2, 0), height)
+}
+
+// Whether there's a bar above the results, for the result filter or the
+// jump prompt
+func (m model) showResultsBar() bool {
+	return m.showResultFilter() || m.resultsState.jumping
+}
+
+func (m model) showResultFilter() bool {
+	return m.resultsState.editingFilter || m.resultsState.filter != nil
+}
+
+// Start editing the result filter
+func (m *model) openResultFilter() tea.Cmd {
+	m.resultsState.editingFilter = true
+	m.resultsState.filterInput.CursorEnd()
+	m.layoutResults()
+	return m.resultsState.filterInput.Focus()
+}
+
+// Handle keys while the result filter input has focus. The filter is applied
+// as you type whenever the pattern compiles.
+func (m model) updateResultFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.String() == "ctrl+c":
+		return m, tea.Quit
+
+	case key.Matches(msg, m.keymap.Enter):
+		m.resultsState.editingFilter = false
+		m.resultsState.filterInput.Blur()
+		m.layoutResults()
+		return m, nil
+
+	case key.Matches(msg, m.keymap.Back):
+		m.resultsState.editingFilter = false
+		m.resultsState.filterInput.Blur()
+		m.resultsState.filterInput.SetValue("")
+		m.resultsState.filter = nil
+		m.resultsState.filterErr = nil
+		m.layoutResults()
+		m.refreshResults()
+		m.reportResultCount()
+		return m, m.updatePreview()
+	}
+
+	var cmd tea.Cmd
+	m.resultsState.filterInput, cmd = m.resultsState.filterInput.Update(msg)
+
+	filter, err := parseResultFilter(m.resultsState.filterInput.Value())
+	m.resultsState.filterErr = err
+	if err != nil {
+		return m, cmd
+	}
+	m.resultsState.filter = filter
+	m.layoutResults()
+	m.refreshResults()
+	m.reportResultCount()
+	return m, tea.Batch(cmd, m.updatePreview())
+}
+
+// Show how many results are visible in the status bar
+func (m *model) reportResultCount() {
+	m.statusMessageType = "info"
+	switch {
+	case len(m.results) == 0:
+		m.statusMessage = "No results found"
+	case !m.allLoaded():
+		m.statusMessage = fmt.Sprintf("%s of %s results loaded", formatCount(m.resultsState.scanned), formatCount(len(m.results)))
+	case m.resultsState.filter != nil:
+		m.statusMessage = fmt.Sprintf("Showing %d of %d results", m.resultsState.list.total(), len(m.results))
+	default:
+		m.statusMessage = fmt.Sprintf("Found %d results", len(m.results))
+	}
+	if m.resultsState.ignored > 0 {
+		m.statusMessage += fmt.Sprintf(" (%d false positives left out)", m.resultsState.ignored)
+	}
+}
+
+// n with thousands separators
+func formatCount(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+func (m model) resultFilterView() string {
+	view := m.resultsState.filterInput.View()
+	if m.resultsState.filterErr != nil {
+		view += "  " + resultFilterErrorStyle.Render(m.resultsState.filterErr.Error())
+	}
+	return resultFilterStyle.Render(view) + "\n"
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Placeholders in a command run over files: {} runs it once for each file,
+// {+} once with all of them
+const (
+	eachFilePlaceholder = "{}"
+	allFilesPlaceholder = "{+}"
+)
+
+// Open the prompt for a command to run over the marked results' files (or
+// the selected result's), with its output going to the pipe pane
+func (m *model) openRunOnFiles() tea.Cmd {
+	if m.blockedByReadOnly("run commands on files") {
+		return nil
+	}
+	files := uniquePaths(m.selectedResults())
+	if len(files) == 0 {
+		m.statusMessage = "No files to run a command on"
+		m.statusMessageType = "error"
+		return nil
+	}
+	m.pipe.show = true
+	m.pipe.files = files
+	m.pipe.input.Prompt = fmt.Sprintf("Run on %d files ❯ ", len(files))
+	if len(files) == 1 {
+		m.pipe.input.Prompt = "Run on 1 file ❯ "
+	}
+	m.pipe.input.Placeholder = "command, {} for each file or {+} for all at once, like goimports -w {} or git add {+}"
+	return m.pipe.input.Focus()
+}
+
+// The command lines to run: the command's words with {} filled in with
+// each file in turn, or {+} with all of them. With neither, the files are
+// added to the end, one at a time.
+func filesCommands(command string, files []string) [][]string {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil
+	}
+	for i, field := range fields {
+		if field == allFilesPlaceholder {
+			args := append(append(append([]string{}, fields[:i]...), files...), fields[i+1:]...)
+			return [][]string{args}
+		}
+	}
+	each := false
+	for _, field := range fields {
+		if strings.Contains(field, eachFilePlaceholder) {
+			each = true
+		}
+	}
+	var commands [][]string
+	for _, file := range files {
+		args := make([]string, 0, len(fields)+1)
+		for _, field := range fields {
+			args = append(args, strings.ReplaceAll(field, eachFilePlaceholder, file))
+		}
+		if !each {
+			args = append(args, file)
+		}
+		commands = append(commands, args)
+	}
+	return commands
+}
+
+// Run the command over the files one after another, without a shell so
+// paths need no quoting, and log each command line with what it printed
+// and whether it failed. The status reports the first failure. As the
+// command may well change the files, it goes in the audit log.
+func (m *model) runOnFiles(command string) tea.Cmd {
+	commands := filesCommands(command, m.pipe.files)
+	if len(commands) == 0 {
+		return nil
+	}
+
+	if m.pipe.cancel != nil {
+		m.pipe.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.pipe.cancel = cancel
+	m.pipe.id++
+	m.pipe.command = command
+	m.pipe.count = len(m.pipe.files)
+	m.pipe.running = true
+	m.pipe.output.SetContent("")
+
+	dir := m.paths.root
+	id := m.pipe.id
+	files := m.pipe.files
+	return func() tea.Msg {
+		defer cancel()
+		var out limitedBuffer
+		out.limit = pipeOutputLimit
+		var firstErr error
+		failed := 0
+		for _, args := range commands {
+			if ctx.Err() != nil {
+				break
+			}
+			fmt.Fprintf(&out, "$ %s\n", strings.Join(args, " "))
+			cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+			cmd.Dir = dir
+			cmd.Stdout = &out
+			cmd.Stderr = &out
+			if err := cmd.Run(); err != nil {
+				fmt.Fprintf(&out, "✗ %s\n", err)
+				failed++
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+		}
+		output := out.String()
+		if out.truncated {
+			output += fmt.Sprintf("\n⋯ output cut off at %s", formatBytes(pipeOutputLimit))
+		}
+		if failed > 1 {
+			firstErr = fmt.Errorf("%w (and %d more failed)", firstErr, failed-1)
+		}
+		auditErr := recordAudit(auditEntry{Action: "run on files", Files: files, Command: command})
+		return pipeDoneMsg{id: id, output: output, err: firstErr, auditErr: auditErr}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFilesCommands(t *testing.T) {
+	files := []string{"/src/a.go", "/src/b c.go"}
+	tests := []struct {
+		command string
+		want    [][]string
+	}{
+		{"", nil},
+		{"goimports -w", [][]string{{"goimports", "-w", "/src/a.go"}, {"goimports", "-w", "/src/b c.go"}}},
+		{"goimports -w {}", [][]string{{"goimports", "-w", "/src/a.go"}, {"goimports", "-w", "/src/b c.go"}}},
+		{"cp {} {}.orig", [][]string{{"cp", "/src/a.go", "/src/a.go.orig"}, {"cp", "/src/b c.go", "/src/b c.go.orig"}}},
+		{"git add {+}", [][]string{{"git", "add", "/src/a.go", "/src/b c.go"}}},
+		{"wc -l {+} --total=always", [][]string{{"wc", "-l", "/src/a.go", "/src/b c.go", "--total=always"}}},
+	}
+	for _, test := range tests {
+		if got := filesCommands(test.command, files); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("filesCommands(%q) = %q, want %q", test.command, got, test.want)
+		}
+	}
+}
+
+// The command can change the files, so like replacing it goes in the
+// audit log
+func TestRunOnFiles(t *testing.T) {
+	if _, err := exec.LookPath("touch"); err != nil {
+		t.Skip("touch isn't installed")
+	}
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+	files := []string{filepath.Join(dir, "a"), filepath.Join(dir, "b")}
+
+	m := initialModel()
+	m.paths.root = dir
+	m.pipe.files = files
+	msg, ok := m.runOnFiles("touch")().(pipeDoneMsg)
+	if !ok {
+		t.Fatal("running the command didn't finish with a pipeDoneMsg")
+	}
+	m.handlePipeDone(msg)
+
+	if want := "Ran touch on 2 files"; m.statusMessage != want {
+		t.Errorf("status %q, want %q", m.statusMessage, want)
+	}
+	for _, file := range files {
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("command wasn't run on %s: %v", file, err)
+		}
+	}
+	entries, err := readAuditLog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d audit log entries, want 1", len(entries))
+	}
+	if entry := entries[0]; entry.Command != "touch" || !reflect.DeepEqual(entry.Files, files) {
+		t.Errorf("audit log entry %+v, want touch on %v", entry, files)
+	}
+}
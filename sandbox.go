@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Build the command that runs rg with args over paths. In sandbox mode rg
+// runs under bubblewrap, or firejail if that's what's installed, with no
+// network, nothing writable and none of the home directory visible except
+// the directories being searched, so `--pre` preprocessors and anything they
+// start can't do any harm in an untrusted directory. If neither tool is
+// installed the search fails rather than run unsandboxed. Cancelling ctx
+// kills the process.
+func rgCommand(ctx context.Context, args []string, paths []string, sandbox bool) (*exec.Cmd, error) {
+	if !sandbox {
+		return exec.CommandContext(ctx, "rg", append(args, paths...)...), nil
+	}
+
+	// Paths outside the search directories won't exist in the sandbox, so
+	// neither will a relative path's starting point
+	paths = slices.Clone(paths)
+	for i, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		paths[i] = abs
+	}
+	home, _ := os.UserHomeDir()
+	rg := append(append([]string{"--", "rg"}, args...), paths...)
+
+	if bwrap, err := exec.LookPath("bwrap"); err == nil {
+		bwrapArgs := []string{
+			"--ro-bind", "/", "/",
+			"--dev", "/dev",
+			"--proc", "/proc",
+			"--tmpfs", "/tmp",
+			"--unshare-all",
+			"--die-with-parent",
+			"--new-session",
+		}
+		if home != "" {
+			bwrapArgs = append(bwrapArgs, "--tmpfs", home)
+		}
+		// After the home directory is hidden, so a directory inside it
+		// shows through
+		for _, path := range paths {
+			bwrapArgs = append(bwrapArgs, "--ro-bind", path, path)
+		}
+		return exec.CommandContext(ctx, bwrap, append(bwrapArgs, rg...)...), nil
+	}
+
+	if firejail, err := exec.LookPath("firejail"); err == nil {
+		firejailArgs := []string{
+			"--quiet",
+			"--noprofile",
+			"--net=none",
+			"--private-tmp",
+			"--read-only=/",
+		}
+		// Whitelisting a directory in the home directory hides the rest of
+		// it; with nothing to whitelist, an empty home does the same
+		private := true
+		for _, path := range paths {
+			if home != "" && strings.HasPrefix(path+string(filepath.Separator), home+string(filepath.Separator)) {
+				firejailArgs = append(firejailArgs, "--whitelist="+path, "--read-only="+path)
+				private = false
+			}
+		}
+		if private {
+			firejailArgs = append(firejailArgs, "--private")
+		}
+		return exec.CommandContext(ctx, firejail, append(firejailArgs, rg...)...), nil
+	}
+
+	return nil, errors.New("sandbox mode needs bwrap or firejail, and neither is installed")
+}
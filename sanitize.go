@@ -0,0 +1,96 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// How result lines are cleaned up for the list, set with "sanitize" in the
+// config
+type sanitizeConfig struct {
+	// Show runs of whitespace inside the line as a single space
+	CollapseSpaces bool `json:"collapseSpaces"`
+
+	// Leave out the line's indentation (the default)
+	StripIndent *bool `json:"stripIndent"`
+
+	// Show control characters as their Unicode pictures (␛ for escape),
+	// rather than sending them to the terminal (the default)
+	ControlChars *bool `json:"controlChars"`
+}
+
+type sanitizeOptions struct {
+	collapseSpaces bool
+	keepIndent     bool
+	controlChars   bool
+}
+
+func (c sanitizeConfig) options() sanitizeOptions {
+	return sanitizeOptions{
+		collapseSpaces: c.CollapseSpaces,
+		keepIndent:     c.StripIndent != nil && !*c.StripIndent,
+		controlChars:   c.ControlChars == nil || *c.ControlChars,
+	}
+}
+
+// A visible stand-in for a control character
+func controlPicture(r rune) rune {
+	switch {
+	case r < 0x20:
+		return 0x2400 + r
+	case r == 0x7f:
+		return '␡'
+	}
+	return utf8.RuneError
+}
+
+// The line a result shows in the list, cleaned up as configured, with its
+// matches moved to where they end up. The result itself keeps the line as
+// it was, for copying and exporting.
+func (o sanitizeOptions) line(item Item) (string, []matchSpan) {
+	s, offset := item.content, 0
+	if o.keepIndent {
+		indent := expandTabs(item.indent, batTabWidth)
+		s, offset = indent+s, len(indent)
+	}
+	if !o.collapseSpaces && !o.controlChars && offset == 0 {
+		return s, item.matches
+	}
+
+	// Where each byte of s ends up
+	moved := make([]int, len(s)+1)
+	var b strings.Builder
+	space := false
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		for j := i; j < i+size; j++ {
+			moved[j] = b.Len()
+		}
+		start := i
+		i += size
+		switch {
+		case o.collapseSpaces && unicode.IsSpace(r):
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+			continue
+		case o.controlChars && unicode.IsControl(r) && r != '\t':
+			b.WriteRune(controlPicture(r))
+		default:
+			b.WriteString(s[start:i])
+		}
+		space = false
+	}
+	moved[len(s)] = b.Len()
+
+	var spans []matchSpan
+	for _, span := range item.matches {
+		start, end := moved[min(span.start+offset, len(s))], moved[min(span.end+offset, len(s))]
+		if start < end {
+			spans = append(spans, matchSpan{start: start, end: end})
+		}
+	}
+	return b.String(), spans
+}
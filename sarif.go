@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// The parts of SARIF 2.1.0 (the Static Analysis Results Interchange
+// Format) an export of search results fills in
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifRuleID  = "lazyrg/pattern"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                        `json:"tool"`
+	OriginalURIBaseIDs map[string]sarifArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	ColumnKind         string                           `json:"columnKind"`
+	Results            []sarifResult                    `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int          `json:"startLine"`
+	StartColumn int          `json:"startColumn,omitempty"`
+	EndColumn   int          `json:"endColumn,omitempty"`
+	Snippet     sarifMessage `json:"snippet"`
+}
+
+// Write the results as a SARIF log, for GitHub code scanning and the like:
+// the pattern is the one rule, and each match is a warning about it, with
+// its file relative to the search root and its line as the snippet.
+// Columns count characters rather than bytes.
+func writeSARIF(w io.Writer, data exportData) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "lazyrg",
+			InformationURI: "https://github.com/lafarr/lazyrg",
+			Rules: []sarifRule{{
+				ID:               sarifRuleID,
+				Name:             "SearchPattern",
+				ShortDescription: sarifMessage{Text: fmt.Sprintf("Matches %s", data.pattern)},
+			}},
+		}},
+		OriginalURIBaseIDs: map[string]sarifArtifactLocation{
+			"SRCROOT": {URI: fileURI(data.root) + "/"},
+		},
+		ColumnKind: "unicodeCodePoints",
+		Results:    []sarifResult{},
+	}
+	for _, item := range data.items {
+		text := item.indent + item.content
+		location := sarifArtifactLocation{URI: fileURI(item.fullPath)}
+		if rel := exportPath(data.root, item.fullPath); rel != item.fullPath {
+			location = sarifArtifactLocation{URI: (&url.URL{Path: rel}).EscapedPath(), URIBaseID: "SRCROOT"}
+		}
+		spans := item.matches
+		if len(spans) == 0 {
+			spans = []matchSpan{{}}
+		}
+		for _, span := range spans {
+			start, end := span.start+len(item.indent), min(span.end+len(item.indent), len(text))
+			region := sarifRegion{StartLine: item.lineNum, Snippet: sarifMessage{Text: text}}
+			message := fmt.Sprintf("Matches %s", data.pattern)
+			if start < end {
+				region.StartColumn = utf8.RuneCountInString(text[:start]) + 1
+				region.EndColumn = utf8.RuneCountInString(text[:end]) + 1
+				message = fmt.Sprintf("%s matches %s", strings.TrimSpace(text[start:end]), data.pattern)
+			}
+			run.Results = append(run.Results, sarifResult{
+				RuleID:  sarifRuleID,
+				Level:   "warning",
+				Message: sarifMessage{Text: message},
+				Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: location,
+					Region:           region,
+				}}},
+			})
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}})
+}
+
+// A file:// URI for an absolute path
+func fileURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// A set of rg file types and excluded paths for searching one kind of
+// project, suggested when one of the Detect files is at the search root
+type scopeTemplate struct {
+	Name    string   `json:"name"`
+	Detect  []string `json:"detect"`  // file names at the root of such a project
+	Types   []string `json:"types"`   // rg --type names to search
+	Exclude []string `json:"exclude"` // globs to leave out, like vendor
+}
+
+var builtinScopes = []scopeTemplate{
+	{Name: "Go project", Detect: []string{"go.mod"}, Types: []string{"go"}, Exclude: []string{"vendor", "testdata"}},
+	{Name: "Node", Detect: []string{"package.json"}, Exclude: []string{"node_modules", "dist"}},
+	{Name: "Python", Detect: []string{"pyproject.toml", "setup.py", "requirements.txt"}, Exclude: []string{".venv", "__pycache__"}},
+}
+
+// The built-in templates followed by the config's, which replace built-in
+// ones with the same name
+func mergeScopes(builtin, custom []scopeTemplate) []scopeTemplate {
+	scopes := append([]scopeTemplate(nil), builtin...)
+	for _, scope := range custom {
+		replaced := false
+		for i := range scopes {
+			if scopes[i].Name == scope.Name {
+				scopes[i] = scope
+				replaced = true
+			}
+		}
+		if !replaced {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// Whether dir looks like the root of a project the template is for
+func (s scopeTemplate) detect(dir string) bool {
+	for _, name := range s.Detect {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// rg flags narrowing a search to the template
+func (s scopeTemplate) args() []string {
+	var args []string
+	for _, t := range s.Types {
+		args = append(args, "-t", t)
+	}
+	for _, glob := range s.Exclude {
+		args = append(args, "-g", "!"+glob)
+	}
+	return args
+}
+
+func (s scopeTemplate) summary() string {
+	var parts []string
+	if len(s.Types) > 0 {
+		parts = append(parts, "types "+strings.Join(s.Types, ", "))
+	}
+	if len(s.Exclude) > 0 {
+		parts = append(parts, "excluding "+strings.Join(s.Exclude, ", "))
+	}
+	if len(parts) == 0 {
+		return "no restrictions"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// A template in the picker. The first item, with no template, clears the
+// scope.
+type scopeItem struct {
+	scope     *scopeTemplate
+	suggested bool
+}
+
+func (s scopeItem) Title() string {
+	switch {
+	case s.scope == nil:
+		return "No scope"
+	case s.suggested:
+		return s.scope.Name + "  (suggested)"
+	}
+	return s.scope.Name
+}
+
+func (s scopeItem) Description() string {
+	if s.scope == nil {
+		return "Search every file rg would"
+	}
+	return s.scope.summary()
+}
+
+func (s scopeItem) FilterValue() string { return s.Title() }
+
+func newScopeList() list.Model {
+	scopeList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	scopeList.Title = "Search Scopes"
+	scopeList.SetShowHelp(false)
+	scopeList.SetStatusBarItemName("scope", "scopes")
+	scopeList.Styles.Title = lipgloss.NewStyle().
+		Foreground(special).
+		Bold(true).
+		MarginLeft(2)
+	return scopeList
+}
+
+// The directory the next search starts from
+func (m model) searchRoot() string {
+	paths, _ := m.searchPaths()
+	return commonDir(paths)
+}
+
+// The templates for the kind of project at the search root
+func (m model) suggestedScopes() []scopeTemplate {
+	root := m.searchRoot()
+	var suggested []scopeTemplate
+	for _, scope := range m.scopes {
+		if scope.detect(root) {
+			suggested = append(suggested, scope)
+		}
+	}
+	return suggested
+}
+
+// Open the scope picker, with the suggested templates first
+func (m *model) openScopes() {
+	suggested := map[string]bool{}
+	for _, scope := range m.suggestedScopes() {
+		suggested[scope.Name] = true
+	}
+	items := []list.Item{scopeItem{}}
+	for _, want := range []bool{true, false} {
+		for i := range m.scopes {
+			if suggested[m.scopes[i].Name] == want {
+				items = append(items, scopeItem{scope: &m.scopes[i], suggested: want})
+			}
+		}
+	}
+	m.scopeList.SetItems(items)
+	m.scopeList.Select(0)
+	if len(suggested) > 0 {
+		m.scopeList.Select(1)
+	}
+	m.showScopes = true
+}
+
+func (m model) updateScopes(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.scopeList.SettingFilter() {
+		switch {
+		case key.Matches(msg, m.keymap.Quit):
+			return m, tea.Quit
+
+		case key.Matches(msg, m.keymap.Back) || key.Matches(msg, m.keymap.Scopes):
+			m.showScopes = false
+			return m, nil
+
+		case key.Matches(msg, m.keymap.Enter):
+			item, ok := m.scopeList.SelectedItem().(scopeItem)
+			if !ok {
+				return m, nil
+			}
+			m.showScopes = false
+			m.scope = item.scope
+			m.statusMessage = "Searching every file"
+			if m.scope != nil {
+				m.statusMessage = fmt.Sprintf("Searching with the %s scope: %s", m.scope.Name, m.scope.summary())
+			}
+			m.statusMessageType = "info"
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.scopeList, cmd = m.scopeList.Update(msg)
+	return m, cmd
+}
+
+// The active scope, or a suggestion, as shown in the search tab
+func (m model) scopeView() string {
+	scopesKey := m.keymap.Scopes.Help().Key
+	if m.scope != nil {
+		return fmt.Sprintf("🎯 Scope: %s (%s to change)", m.scope.Name, scopesKey)
+	}
+	if suggested := m.suggestedScopes(); len(suggested) > 0 {
+		return fmt.Sprintf("💡 Looks like a %s: %s to pick a search scope", suggested[0].Name, scopesKey)
+	}
+	return ""
+}
+
+// The scope -type gives: just those rg file types, like go or go,proto
+func typesScope(types string) *scopeTemplate {
+	var names []string
+	for _, name := range strings.Split(types, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	return &scopeTemplate{Name: strings.Join(names, ", ") + " files", Types: names}
+}
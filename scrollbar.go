@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Marks on the file viewer's scrollbar: the part of the file in view, and
+// the matches n and N go through, with the current one picked out
+const (
+	scrollThumb     = " "
+	scrollLiteThumb = "┃" // with no border to draw over, in the lite style
+	scrollHit       = "•"
+)
+
+// Draw a scrollbar down the viewer's right edge, over its border, for a file
+// too long to fit. Each row stands for an equal share of the document's
+// lines: the rows in view make up the thumb, and rows with matches get a
+// tick, so where the hits are in the file shows at a glance.
+func (m model) scrollbar(view string) string {
+	doc := m.fileState.doc
+	style := m.fileState.viewer.Style
+	lines := strings.Split(view, "\n")
+	first, last := style.GetBorderTopSize(), len(lines)-style.GetBorderBottomSize()
+	rows := last - first
+	n := len(doc.lines)
+	if rows <= 0 || n <= rows {
+		return view
+	}
+
+	top := m.fileState.viewer.YOffset
+	thumbFrom := top * rows / n
+	thumbTo := max(thumbFrom+1, min((top+rows)*rows/n, rows))
+	hitRows, current := map[int]bool{}, -1
+	for i, hit := range m.fileState.hits {
+		line := hit.line - 1 + m.fileState.lineOffset
+		if line < 0 || line >= n {
+			continue
+		}
+		hitRows[line*rows/n] = true
+		if i == m.fileState.hit {
+			current = line * rows / n
+		}
+	}
+
+	// The thumb is a solid bar in the border's color, with any ticks in it
+	// drawn on top
+	thumb := style.GetBorderRightForeground()
+	marks := make([]string, rows)
+	for r := range marks {
+		inThumb := r >= thumbFrom && r < thumbTo
+		mark, markStyle := "", lipgloss.NewStyle()
+		switch {
+		case r == current:
+			mark, markStyle = scrollHit, previewMatchGutterStyle
+		case hitRows[r]:
+			mark, markStyle = scrollHit, hitGutterStyle
+		case inThumb && style.GetBorderRightSize() == 0:
+			mark, markStyle = scrollLiteThumb, previewMatchGutterStyle
+		case inThumb:
+			mark = scrollThumb
+		}
+		if inThumb && style.GetBorderRightSize() > 0 {
+			markStyle = markStyle.Background(thumb)
+		}
+		if mark != "" {
+			marks[r] = markStyle.Render(mark)
+		}
+	}
+
+	for r, mark := range marks {
+		if mark == "" {
+			continue
+		}
+		line := lines[first+r]
+		width := ansi.StringWidth(line)
+		lines[first+r] = ansi.Truncate(line, width-1, "") + mark
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How many matches to collect before handing a batch to the UI, and how long
+// to hold on to a partial batch before flushing it anyway.
+const (
+	searchBatchSize     = 500
+	searchFlushInterval = 50 * time.Millisecond
+)
+
+// Searches that take longer than this send a notification when they finish
+const longSearchThreshold = 5 * time.Second
+
+// Message types for a streamed search. Every message carries the id of the
+// search that produced it so results from a superseded search can be dropped.
+type searchStartedMsg struct {
+	stream *searchStream
+}
+
+type searchResultsMsg struct {
+	id      int
+	results []Item
+}
+
+type searchFinishedMsg struct {
+	id    int
+	err   error
+	stats *searchStats
+	index *indexUse // how the index narrowed the search, if it did
+}
+
+// A running rg process whose matches are delivered through msgs.
+type searchStream struct {
+	id     int
+	cmd    *exec.Cmd
+	cancel context.CancelFunc // kills rg
+	msgs   chan tea.Msg
+	done   chan struct{}
+	stats  *searchStats // from rg's summary, once it's been read
+	paused bool
+	held   bool // a batch arrived while paused, so nothing is waiting for the next
+	index  *indexUse
+}
+
+// Wait for the next batch (or the final message) from the stream.
+func (s *searchStream) next() tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-s.msgs
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+// Stop the rg process and stop delivering messages. Safe to call more than once.
+func (s *searchStream) stop() {
+	select {
+	case <-s.done:
+		return
+	default:
+		close(s.done)
+	}
+	if s.paused {
+		// So it can die, inside a sandbox too
+		resumeProcess(s.cmd.Process) //nolint: errcheck
+	}
+	s.cancel()
+}
+
+// Deliver msg unless the stream has been stopped.
+func (s *searchStream) send(msg tea.Msg) bool {
+	select {
+	case s.msgs <- msg:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// JSON lines emitted by `rg --json`. Paths and lines that aren't valid UTF-8
+// come through base64 encoded in Bytes instead of Text.
+type rgMessage struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+type rgText struct {
+	Text  string `json:"text"`
+	Bytes string `json:"bytes"`
+}
+
+func (t rgText) String() string {
+	if t.Bytes != "" {
+		if b, err := base64.StdEncoding.DecodeString(t.Bytes); err == nil {
+			return string(b)
+		}
+	}
+	return t.Text
+}
+
+type rgMatch struct {
+	Path       rgText       `json:"path"`
+	Lines      rgText       `json:"lines"`
+	LineNumber int          `json:"line_number"`
+	Submatches []rgSubmatch `json:"submatches"`
+}
+
+type rgSubmatch struct {
+	Match rgText `json:"match"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// Turn a `match` message into a result item.
+func parseMatch(data json.RawMessage) (Item, bool) {
+	var match rgMatch
+	if err := json.Unmarshal(data, &match); err != nil {
+		return Item{}, false
+	}
+	path := match.Path.String()
+	line := strings.TrimRight(match.Lines.String(), "\r\n")
+	content := strings.TrimSpace(line)
+
+	// Submatch offsets are relative to the untrimmed line
+	trimmed := len(line) - len(strings.TrimLeftFunc(line, unicode.IsSpace))
+	column := 1
+	if len(match.Submatches) > 0 {
+		column = match.Submatches[0].Start + 1
+	}
+
+	var matches []matchSpan
+	var rawMatches []matchSpan
+	for _, sub := range match.Submatches {
+		rawMatches = append(rawMatches, matchSpan{start: sub.Start, end: sub.End})
+		start := min(max(sub.Start-trimmed, 0), len(content))
+		end := min(max(sub.End-trimmed, 0), len(content))
+		if start < end {
+			matches = append(matches, matchSpan{start: start, end: end})
+		}
+	}
+
+	return Item{
+		fileName:    path,
+		lineNum:     match.LineNumber,
+		content:     content,
+		indent:      line[:trimmed],
+		fullPath:    path,
+		column:      column,
+		matches:     matches,
+		lineMatches: rawMatches,
+	}, true
+}
+
+// Run ripgrep, streaming its matches back as they are found
+func executeRipgrep(id int, pattern string, flags []string, paths []string, sandbox bool) tea.Cmd {
+	return func() tea.Msg {
+		if pattern == "" {
+			return searchFinishedMsg{
+				id:  id,
+				err: fmt.Errorf("empty search pattern"),
+			}
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		args := append([]string{"--json"}, flags...)
+		cmd, err := rgCommand(ctx, append(args, "-e", pattern), paths, sandbox)
+		if err != nil {
+			cancel()
+			return searchFinishedMsg{id: id, err: err}
+		}
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			cancel()
+			return searchFinishedMsg{id: id, err: err}
+		}
+		if err := cmd.Start(); err != nil {
+			cancel()
+			return searchFinishedMsg{id: id, err: err}
+		}
+
+		stream := &searchStream{
+			id:     id,
+			cmd:    cmd,
+			cancel: cancel,
+			msgs:   make(chan tea.Msg),
+			done:   make(chan struct{}),
+		}
+		go stream.run(stdout, &stderr, strings.Join(paths, ", "))
+
+		return searchStartedMsg{stream: stream}
+	}
+}
+
+// Read rg's output, batching matches into searchResultsMsgs, and finish with
+// a searchFinishedMsg once the process exits.
+func (s *searchStream) run(stdout io.Reader, stderr *bytes.Buffer, path string) {
+	defer close(s.msgs)
+
+	items := make(chan Item)
+	go func() {
+		defer close(items)
+		reader := bufio.NewReader(stdout)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 {
+				var msg rgMessage
+				if json.Unmarshal(line, &msg) == nil {
+					switch msg.Type {
+					case "match":
+						if item, ok := parseMatch(msg.Data); ok {
+							select {
+							case items <- item:
+							case <-s.done:
+								return
+							}
+						}
+					case "summary":
+						s.stats = parseSummary(msg.Data)
+					}
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	// Set once all of rg's output has been sent, when how it exited is
+	// worth reporting
+	finished := false
+	found := 0
+	defer func() {
+		// Reap rg, whether it finished or was killed, once its output has
+		// been read to the end
+		for range items {
+		}
+		err := s.cmd.Wait()
+		s.cancel()
+		if finished {
+			s.send(searchFinishedMsg{id: s.id, err: searchError(err, stderr.String(), path, found), stats: s.stats, index: s.index})
+		}
+	}()
+
+	ticker := time.NewTicker(searchFlushInterval)
+	defer ticker.Stop()
+
+	var batch []Item
+	flush := func() bool {
+		if len(batch) == 0 {
+			return true
+		}
+		found += len(batch)
+		ok := s.send(searchResultsMsg{id: s.id, results: batch})
+		batch = nil
+		return ok
+	}
+
+	for reading := true; reading; {
+		select {
+		case item, ok := <-items:
+			if !ok {
+				reading = false
+				break
+			}
+			batch = append(batch, item)
+			if len(batch) >= searchBatchSize && !flush() {
+				return
+			}
+		case <-ticker.C:
+			if !flush() {
+				return
+			}
+		case <-s.done:
+			return
+		}
+	}
+	finished = flush()
+}
+
+// Translate rg's exit status into an error worth showing to the user.
+func searchError(err error, stderr string, path string, found int) error {
+	if err == nil {
+		return nil
+	}
+	if strings.Contains(stderr, "No such file or directory") && found == 0 {
+		return fmt.Errorf("directory not found: %s", path)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// rg returns exit code 1 when no matches were found, which is not an error for us
+		if exitErr.ExitCode() == 1 {
+			return nil
+		}
+		// Exit code 2 with matches usually means some files couldn't be read;
+		// the results we did get are still worth showing
+		if found > 0 {
+			return nil
+		}
+		if msg := strings.TrimSpace(stderr); msg != "" {
+			return errors.New(strings.SplitN(msg, "\n", 2)[0])
+		}
+	}
+	return err
+}
+
+// Run the search described by the inputs, replacing any that's still running
+func (m *model) startSearch() tea.Cmd {
+	m.saveSession()
+	cmd := m.runSearch()
+	m.resetResults()
+	m.activeTab = resultsTab
+	return cmd
+}
+
+// The paths the inputs say to search, and how to describe them
+func (m model) searchPaths() ([]string, string) {
+	switch {
+	case m.directoryInput.Value() != "":
+		return []string{m.directoryInput.Value()}, m.directoryInput.Value()
+	case len(m.stdinFiles) > 0:
+		return m.stdinFiles, fmt.Sprintf("%d files from stdin", len(m.stdinFiles))
+	case len(m.repos) > 0:
+		// rg searches the repositories in parallel like any other paths
+		return m.repos, fmt.Sprintf("%d repositories", len(m.repos))
+	}
+	return []string{m.currentPath}, m.currentPath
+}
+
+// Run rg for the inputs, leaving the results as they are
+func (m *model) runSearch() tea.Cmd {
+	m.currentSearchPattern = m.searchInput.Value()
+	searchPaths, where := m.searchPaths()
+	if m.search != nil {
+		m.search.stop()
+		m.search = nil
+	}
+	m.searchID++
+	m.suggestion = nil
+	m.loadedFrom = ""
+	m.paths.root = commonDir(searchPaths)
+	if info, err := os.Stat(m.paths.root); err == nil && !info.IsDir() {
+		// Searching a single file
+		m.paths.root = filepath.Dir(m.paths.root)
+	}
+	m.searchStarted = time.Now()
+	m.statusMessage = fmt.Sprintf("Searching for: %s in %s", m.currentSearchPattern, where)
+	m.statusMessageType = "info"
+	if idx := m.indexFor(searchPaths); idx != nil && m.pre == "" {
+		return searchIndexed(idx, m.searchID, m.currentSearchPattern, m.searchFlags(searchPaths), searchPaths, m.sandbox)
+	}
+	return executeRipgrep(m.searchID, m.currentSearchPattern, m.searchFlags(searchPaths), searchPaths, m.sandbox)
+}
+
+// rg flags for the exclusions and the scope
+func (m model) searchFlags(paths []string) []string {
+	flags := m.excludeArgs(paths)
+	if m.pre != "" {
+		flags = append(flags, "--pre", m.pre)
+	}
+	if m.lowPower {
+		flags = append(flags, "--threads", strconv.Itoa(lowPowerThreads))
+	}
+	if m.scope != nil {
+		flags = append(flags, m.scope.args()...)
+	}
+	return append(flags, m.flags...)
+}
+
+// Kill the running search and go back to the search tab, keeping whatever
+// results it had found
+func (m *model) cancelSearch() {
+	m.search.stop()
+	m.search = nil
+	if m.staleResults {
+		// None of them are from this search
+		m.resetResults()
+	}
+	m.searchID++
+	m.activeTab = searchTab
+	m.searchInput.Focus()
+	m.statusMessage = fmt.Sprintf("Cancelled search for: %s (%s results found)", m.currentSearchPattern, formatCount(len(m.results)))
+	m.statusMessageType = "info"
+}
+
+// Let the user know when a search that took a while has finished, since
+// they've probably switched to something else
+func (m model) notifyLongSearch() tea.Cmd {
+	if time.Since(m.searchStarted) < longSearchThreshold {
+		return nil
+	}
+	return m.notify(fmt.Sprintf("Search for %q finished: %s", m.currentSearchPattern, m.statusMessage))
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var selectionStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("#1A1A1A")).
+	Background(lipgloss.AdaptiveColor{Light: "#A8D8F0", Dark: "#5FAFD7"})
+
+// The document lines that are lines of the file, which a selection stays
+// inside: not bat's header or a chunk's placeholders
+func (m model) selectableLines() (int, int) {
+	offset := m.fileState.lineOffset
+	if chunk := m.fileState.chunk; chunk != nil {
+		return chunk.first - 1 + offset, chunk.last - 1 + offset
+	}
+	return offset, len(m.fileState.doc.lines) - 1
+}
+
+// Start selecting lines to copy, from the line the file was opened at if
+// it's in view and the middle of the viewer otherwise
+func (m *model) startSelection() {
+	if m.fileState.doc == nil || m.fileLoading {
+		return
+	}
+	if m.fileState.binary || m.review.stagedFile(m.fileState.item.fullPath) != nil {
+		m.statusMessage = "Only lines of a text file on disk can be selected"
+		m.statusMessageType = "error"
+		return
+	}
+	top, rows := m.fileState.viewer.YOffset, m.fileRows()
+	line := m.fileState.item.lineNum - 1 + m.fileState.lineOffset
+	if m.fileState.item.lineNum < 1 || line < top || line >= top+rows {
+		line = top + rows/2
+	}
+	first, last := m.selectableLines()
+	line = min(max(line, first), last)
+	m.fileState.selecting = true
+	m.fileState.selAnchor, m.fileState.selCursor = line, line
+	m.noteSelection()
+}
+
+// The lines of the viewer the file's lines take up
+func (m model) fileRows() int {
+	return max(m.fileState.viewer.Height-m.fileState.viewer.Style.GetVerticalFrameSize(), 1)
+}
+
+// The selected document lines, top first
+func (m model) selection() (int, int) {
+	from, to := m.fileState.selAnchor, m.fileState.selCursor
+	return min(from, to), max(from, to)
+}
+
+func (m *model) noteSelection() {
+	from, to := m.selection()
+	offset := m.fileState.lineOffset
+	m.statusMessage = fmt.Sprintf("Selected lines %d–%d (%d) · %s to copy, %s to cancel", from-offset+1, to-offset+1, to-from+1, m.keymap.Yank.Help().Key, m.keymap.Back.Help().Key)
+	m.statusMessageType = "info"
+}
+
+// Move the end of the selection, scrolling to keep it in view
+func (m *model) moveSelection(delta int) {
+	first, last := m.selectableLines()
+	cursor := min(max(m.fileState.selCursor+delta, first), last)
+	m.fileState.selCursor = cursor
+	top, rows := m.fileState.viewer.YOffset, m.fileRows()
+	if cursor < top {
+		m.fileState.viewer.SetYOffset(cursor)
+	} else if cursor >= top+rows {
+		m.fileState.viewer.SetYOffset(cursor - rows + 1)
+	}
+	m.renderVisibleFile()
+	m.noteSelection()
+}
+
+// Copy the selected lines as they are in the file, without the gutter or
+// any highlighting
+func (m *model) copySelection() {
+	from, to := m.selection()
+	offset := m.fileState.lineOffset
+	first, last := from-offset+1, to-offset+1
+	lines, err := readFileLines(m.fileState.item.fullPath, first, last, m.indexedFile(m.fileState.item.fullPath))
+	if err != nil {
+		m.reportCopy("", err)
+		return
+	}
+	what := fmt.Sprintf("line %d", first)
+	if last > first {
+		what = fmt.Sprintf("lines %d–%d", first, last)
+	}
+	m.reportCopy(what, m.copyToClipboard("lines", strings.Join(lines, "\n")))
+}
+
+// Lines first to last (1-based) of a file, or as many of them as it has
+func readFileLines(path string, first, last int, entry *indexedFile) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	offset, err := seekLine(file, first, entry)
+	if err != nil {
+		return nil, err
+	}
+	lines, _, _, err := readLinesFrom(file, offset, last-first+1)
+	return lines, err
+}
+
+// Handle keys while selecting lines: the viewer's movement keys move the
+// end of the selection, and the copy key copies it
+func (m model) updateSelection(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	keys := m.fileState.viewer.KeyMap
+	rows := m.fileRows()
+	switch {
+	case msg.String() == "ctrl+c":
+		return m, tea.Quit
+	case key.Matches(msg, m.keymap.Back), key.Matches(msg, m.keymap.Select):
+		m.fileState.selecting = false
+		m.statusMessage = ""
+		return m, nil
+	case key.Matches(msg, m.keymap.Yank):
+		m.fileState.selecting = false
+		m.copySelection()
+		return m, nil
+	case key.Matches(msg, m.keymap.SearchWord):
+		return m, m.searchSelection()
+	case key.Matches(msg, keys.Up):
+		m.moveSelection(-1)
+	case key.Matches(msg, keys.Down):
+		m.moveSelection(1)
+	case key.Matches(msg, keys.PageUp):
+		m.moveSelection(-rows)
+	case key.Matches(msg, keys.PageDown):
+		m.moveSelection(rows)
+	case key.Matches(msg, keys.HalfPageUp):
+		m.moveSelection(-rows / 2)
+	case key.Matches(msg, keys.HalfPageDown):
+		m.moveSelection(rows / 2)
+	default:
+		return m, nil
+	}
+	return m, m.moreOfFile()
+}
+
+// Show a selected line of the document in the selection's colors, past the
+// gutter
+func (m model) highlightSelection(i int, rendered string) string {
+	if !m.fileState.selecting {
+		return rendered
+	}
+	if from, to := m.selection(); i < from || i > to {
+		return rendered
+	}
+	gutter := m.fileState.doc.gutter
+	return ansi.Truncate(rendered, gutter, "") + selectionStyle.Render(ansi.Strip(ansi.TruncateLeft(rendered, gutter, ""))+" ")
+}
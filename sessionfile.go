@@ -0,0 +1,408 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Bumped when a change to sessionFile would confuse an older lazyrg
+const sessionFileVersion = 1
+
+// Lines of the file kept either side of each exported result
+const sessionContext = 2
+
+// A search written out for someone else to open with -import: what was
+// searched for, the results with a little of the file around each, and
+// the pins. Paths are relative to the search directory, so they resolve
+// against the importer's own checkout.
+type sessionFile struct {
+	Version  int             `json:"version"`
+	Pattern  string          `json:"pattern"`
+	Flags    []string        `json:"flags"` // rg's flags for the search, for reference; they're never run
+	Excludes []string        `json:"excludes,omitempty"`
+	Exported time.Time       `json:"exported"`
+	Results  []sessionResult `json:"results"`
+	Pins     []pin           `json:"pins,omitempty"`
+}
+
+type sessionResult struct {
+	Path      string      `json:"path"`
+	Line      int         `json:"line"`
+	Column    int         `json:"column"`
+	Text      string      `json:"text"`                // the line, trimmed
+	Spans     [][2]int    `json:"spans,omitempty"`     // matches in text
+	LineSpans [][2]int    `json:"lineSpans,omitempty"` // matches in the line as it is in the file
+	Context   sessionSnip `json:"context"`
+}
+
+// The lines around a result
+type sessionSnip struct {
+	Before []string `json:"before,omitempty"`
+	After  []string `json:"after,omitempty"`
+}
+
+func spanPairs(spans []matchSpan) [][2]int {
+	var pairs [][2]int
+	for _, span := range spans {
+		pairs = append(pairs, [2]int{span.start, span.end})
+	}
+	return pairs
+}
+
+// The spans in a session file, which could come from anyone, cut to a line
+// length bytes long. Those that are backwards or entirely outside it are
+// dropped.
+func pairSpans(pairs [][2]int, length int) []matchSpan {
+	var spans []matchSpan
+	for _, pair := range pairs {
+		start, end := max(pair[0], 0), min(pair[1], length)
+		if start < end {
+			spans = append(spans, matchSpan{start: start, end: end})
+		}
+	}
+	return spans
+}
+
+// The lines around each of lineNums in a file, read in one pass
+func readContext(path string, lineNums []int, n int) map[int]sessionSnip {
+	snips := map[int]sessionSnip{}
+	file, err := os.Open(path)
+	if err != nil {
+		return snips
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	for _, lineNum := range lineNums {
+		if lineNum < 1 || lineNum > len(lines) {
+			continue
+		}
+		snips[lineNum] = sessionSnip{
+			Before: lines[max(lineNum-1-n, 0) : lineNum-1],
+			After:  lines[lineNum:min(lineNum+n, len(lines))],
+		}
+	}
+	return snips
+}
+
+// Everything about the current search worth handing to someone else
+func (m *model) sessionFile() sessionFile {
+	root := m.paths.root
+	searchPaths, _ := m.searchPaths()
+	s := sessionFile{
+		Version:  sessionFileVersion,
+		Pattern:  m.currentSearchPattern,
+		Flags:    m.searchFlags(searchPaths),
+		Exported: time.Now(),
+		Results:  make([]sessionResult, 0, len(m.results)),
+	}
+	for _, excluded := range m.excludes {
+		s.Excludes = append(s.Excludes, exportPath(root, excluded))
+	}
+
+	lineNums := map[string][]int{}
+	for _, result := range m.results {
+		lineNums[result.fullPath] = append(lineNums[result.fullPath], result.lineNum)
+	}
+	context := map[string]map[int]sessionSnip{}
+	for path, nums := range lineNums {
+		context[path] = readContext(path, nums, sessionContext)
+	}
+	for _, result := range m.results {
+		s.Results = append(s.Results, sessionResult{
+			Path:      exportPath(root, result.fullPath),
+			Line:      result.lineNum,
+			Column:    result.column,
+			Text:      result.content,
+			Spans:     spanPairs(result.matches),
+			LineSpans: spanPairs(result.lineMatches),
+			Context:   context[result.fullPath][result.lineNum],
+		})
+	}
+
+	for _, listItem := range m.pinList.Items() {
+		if p := listItem.(pin); !p.imported {
+			p.Path = exportPath(root, p.Path)
+			s.Pins = append(s.Pins, p)
+		}
+	}
+	return s
+}
+
+// Write the current search to a session file in the working directory
+func (m *model) exportSession() {
+	if m.blockedByReadOnly("export the search") {
+		return
+	}
+	if len(m.results) == 0 {
+		m.statusMessage = "No results to export"
+		m.statusMessageType = "error"
+		return
+	}
+
+	data, err := json.MarshalIndent(m.sessionFile(), "", "  ")
+	name := fmt.Sprintf("lazyrg-session-%s.json", time.Now().Format("20060102-150405"))
+	if err == nil {
+		err = os.WriteFile(name, data, 0o644)
+	}
+	if err != nil {
+		m.statusMessage = fmt.Sprintf("Error exporting session: %s", err)
+		m.statusMessageType = "error"
+		return
+	}
+	m.statusMessage = fmt.Sprintf("Exported the search for %s (%d results) to %s; open it with lazyrg -import %s", m.currentSearchPattern, len(m.results), name, name)
+	m.statusMessageType = "info"
+}
+
+// Read a session file written with alt+e
+func readSessionFile(path string) (sessionFile, error) {
+	var s sessionFile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("%s isn't a lazyrg session: %w", path, err)
+	}
+	if s.Version > sessionFileVersion {
+		return s, fmt.Errorf("%s is from a newer lazyrg (session version %d)", path, s.Version)
+	}
+	return s, nil
+}
+
+// Open a session file someone else exported, in place of a search. The
+// session is there to be looked at, so lazyrg goes read-only, and none of
+// the exporter's flags are run; the results' paths are taken to be relative
+// to the directory lazyrg would have searched.
+func (m *model) importSession(path string) error {
+	s, err := readSessionFile(path)
+	if err != nil {
+		return err
+	}
+	m.readOnly = true
+	count := m.showSessionFile(s, path, m.currentPath)
+	m.statusMessage = fmt.Sprintf("Imported the search for %s from %s (%d results, exported %s)", s.Pattern, path, count, s.Exported.Format("2006-01-02 15:04"))
+	m.statusMessageType = "info"
+	return nil
+}
+
+// Put a session's results in the Results tab, with its paths resolved
+// against root, and add its pins to the pins panel
+func (m *model) showSessionFile(s sessionFile, path, root string) int {
+	resolve := func(p string) string {
+		if filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(root, filepath.FromSlash(p))
+	}
+
+	m.currentSearchPattern = s.Pattern
+	m.searchInput.SetValue(s.Pattern)
+	m.paths.root = root
+	m.loadedFrom = path
+	for _, excluded := range s.Excludes {
+		if full := resolve(excluded); !slices.Contains(m.excludes, full) {
+			m.excludes = append(m.excludes, full)
+		}
+	}
+	m.importedContext = map[resultKey]sessionSnip{}
+	items := make([]Item, 0, len(s.Results))
+	for _, r := range s.Results {
+		full := resolve(r.Path)
+		item := Item{
+			fileName: full,
+			fullPath: full,
+			lineNum:  r.Line,
+			column:   r.Column,
+			content:  r.Text,
+			matches:  pairSpans(r.Spans, len(r.Text)),
+			// The line on disk could be any length; what reads it checks
+			lineMatches: pairSpans(r.LineSpans, math.MaxInt),
+		}
+		items = append(items, item)
+		m.importedContext[item.key()] = r.Context
+	}
+	m.resetResults()
+	m.appendResults(items)
+
+	for _, p := range s.Pins {
+		p.Path = resolve(p.Path)
+		p.imported = true
+		if !m.pinned[p.key()] {
+			m.pinned[p.key()] = true
+			m.pinList.InsertItem(len(m.pinList.Items()), p)
+		}
+	}
+
+	m.activeTab = resultsTab
+	m.searchInput.Blur()
+	return len(items)
+}
+
+func newLoadInput() textinput.Model {
+	input := textinput.New()
+	input.Placeholder = "lazyrg-session-….json"
+	input.Prompt = "Load session ❯ "
+	input.PromptStyle = searchPromptStyle
+	input.TextStyle = lipgloss.NewStyle().Foreground(highlight)
+	input.Cursor.Style = lipgloss.NewStyle().Foreground(special)
+	return input
+}
+
+// The session file saved last in the working directory, if there is one
+func latestSessionFile() string {
+	names, _ := filepath.Glob("lazyrg-session-*.json")
+	if len(names) == 0 {
+		return ""
+	}
+	// The timestamp in the name sorts them
+	return slices.Max(names)
+}
+
+// Ask for a session file to load in place of the results, starting with
+// the one saved last here
+func (m *model) openLoadSession() tea.Cmd {
+	m.showLoad = true
+	m.loadErr = nil
+	m.loadInput.SetValue(latestSessionFile())
+	m.loadInput.CursorEnd()
+	return m.loadInput.Focus()
+}
+
+// Load a saved search into the Results tab without running rg. The results
+// it replaces are kept with the earlier searches, and the loaded ones stay
+// there after a rerun of the same search, to look across or compare with.
+// Unlike -import, lazyrg doesn't go read-only.
+func (m *model) loadSession(path string) error {
+	path = absPath(path)
+	s, err := readSessionFile(path)
+	if err != nil {
+		return err
+	}
+	if m.search != nil {
+		m.search.stop()
+		m.search = nil
+	}
+	m.searchID++
+	m.saveSession()
+	root := m.paths.root
+	if root == "" {
+		root = m.currentPath
+	}
+	count := m.showSessionFile(s, path, root)
+	m.statusMessage = fmt.Sprintf("Loaded the search for %s from %s (%d results, saved %s); press enter in the Search tab to run it again", s.Pattern, filepath.Base(path), count, s.Exported.Format("2006-01-02 15:04"))
+	m.statusMessageType = "info"
+	return nil
+}
+
+// Handle keys while typing the session file to load
+func (m model) updateLoad(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.String() == "ctrl+c":
+		return m, tea.Quit
+
+	case key.Matches(msg, m.keymap.Back):
+		m.showLoad = false
+		m.loadInput.Blur()
+		return m, nil
+
+	case key.Matches(msg, m.keymap.Enter):
+		path := strings.TrimSpace(m.loadInput.Value())
+		if path == "" {
+			return m, nil
+		}
+		if err := m.loadSession(path); err != nil {
+			m.loadErr = err
+			return m, nil
+		}
+		m.showLoad = false
+		m.loadInput.Blur()
+		return m, m.updatePreview()
+	}
+
+	var cmd tea.Cmd
+	m.loadInput, cmd = m.loadInput.Update(msg)
+	m.loadErr = nil
+	return m, cmd
+}
+
+func (m model) loadView() string {
+	input := m.loadInput.View()
+	if m.loadErr != nil {
+		input += "  " + resultFilterErrorStyle.Render(m.loadErr.Error())
+	}
+	hint := "a session saved with alt+e · enter to load · esc to cancel"
+	return lipgloss.JoinVertical(lipgloss.Left,
+		resultFilterStyle.Render(input),
+		pipeOutputStyle.Foreground(subtle).Render(hint),
+	)
+}
+
+// How the finished search's results differ from a loaded session of the
+// same search, for the status bar
+func (m model) loadedDifference() string {
+	for i := len(m.sessions) - 1; i >= 0; i-- {
+		s := m.sessions[i]
+		if s.loadedFrom == "" || s.pattern != m.currentSearchPattern || s.root != m.paths.root {
+			continue
+		}
+		before := map[resultKey]bool{}
+		for _, item := range s.results {
+			before[item.key()] = true
+		}
+		added := 0
+		for _, item := range m.results {
+			if before[item.key()] {
+				delete(before, item.key())
+			} else {
+				added++
+			}
+		}
+		return fmt.Sprintf(" · %d new and %d gone since %s", added, len(before), filepath.Base(s.loadedFrom))
+	}
+	return ""
+}
+
+// An imported result's context from the session file, for when its file
+// isn't there to open
+func (m model) importedSnippet(item Item, token int) (tea.Cmd, bool) {
+	snip, ok := m.importedContext[item.key()]
+	if !ok {
+		return nil, false
+	}
+	if _, err := os.Stat(item.fullPath); err == nil {
+		return nil, false
+	}
+	return func() tea.Msg {
+		first := item.lineNum - len(snip.Before)
+		lines := append(append(append([]string{}, snip.Before...), item.content), snip.After...)
+		for i, line := range lines {
+			marker := "  "
+			if first+i == item.lineNum {
+				marker = "→ "
+				line = highlightSpans(line, item.matches, highlightStyle, matchStyle)
+			}
+			lines[i] = fmt.Sprintf("%s%6d | %s", marker, first+i, expandTabs(line, batTabWidth))
+		}
+		lines = append(lines, "", fmt.Sprintf("  (from the imported session; %s isn't here)", item.fullPath))
+		doc := &fileDocument{lines: lines, gutter: 11}
+		return fileLoadedMsg{token: token, final: true, doc: doc}
+	}, true
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPairSpans(t *testing.T) {
+	tests := []struct {
+		name   string
+		pairs  [][2]int
+		length int
+		want   []matchSpan
+	}{
+		{name: "none", pairs: nil, length: 5, want: nil},
+		{name: "within the line", pairs: [][2]int{{0, 2}, {3, 5}}, length: 5, want: []matchSpan{{0, 2}, {3, 5}}},
+		{name: "past the end", pairs: [][2]int{{5, 7}}, length: 2, want: nil},
+		{name: "running off the end", pairs: [][2]int{{1, 7}}, length: 2, want: []matchSpan{{1, 2}}},
+		{name: "before the start", pairs: [][2]int{{-3, 1}}, length: 2, want: []matchSpan{{0, 1}}},
+		{name: "all before the start", pairs: [][2]int{{-4, -1}}, length: 2, want: nil},
+		{name: "backwards", pairs: [][2]int{{2, 1}}, length: 5, want: nil},
+		{name: "empty", pairs: [][2]int{{1, 1}}, length: 5, want: nil},
+		{name: "only the bad ones dropped", pairs: [][2]int{{9, 12}, {1, 2}}, length: 5, want: []matchSpan{{1, 2}}},
+		{name: "no upper limit", pairs: [][2]int{{40, 50}}, length: math.MaxInt, want: []matchSpan{{40, 50}}},
+	}
+	for _, test := range tests {
+		if got := pairSpans(test.pairs, test.length); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: pairSpans(%v, %d) = %v, want %v", test.name, test.pairs, test.length, got, test.want)
+		}
+	}
+}
+
+// A session file from someone else can say anything about where its
+// matches are, and none of it should take down an export
+func TestImportSessionBadSpans(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session.json")
+	session := `{"version": 1, "pattern": "ab", "results": [
+		{"path": "a.go", "line": 1, "column": 1, "text": "ab", "spans": [[5, 7], [-3, 1], [2, 1]], "lineSpans": [[-4, -1], [0, 1]]}
+	]}`
+	if err := os.WriteFile(path, []byte(session), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := initialModel()
+	m.currentPath = dir
+	if err := m.importSession(path); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.results) != 1 {
+		t.Fatalf("got %d results, want 1", len(m.results))
+	}
+	item := m.results[0]
+	if want := []matchSpan{{0, 1}}; !reflect.DeepEqual(item.matches, want) {
+		t.Errorf("matches %v, want %v", item.matches, want)
+	}
+	if want := []matchSpan{{0, 1}}; !reflect.DeepEqual(item.lineMatches, want) {
+		t.Errorf("line matches %v, want %v", item.lineMatches, want)
+	}
+	if err := writeHTML(io.Discard, "ab", dir, "", m.results); err != nil {
+		t.Errorf("HTML export: %v", err)
+	}
+	for _, format := range exportFormats {
+		if err := format.write(io.Discard, exportData{pattern: "ab", root: dir, items: m.results}); err != nil {
+			t.Errorf("%s export: %v", format.name, err)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// How many earlier searches are kept, and how many results a search across
+// them lists
+const (
+	maxSessions    = 8
+	maxSessionHits = 1000
+)
+
+var sessionLabelStyle = lipgloss.NewStyle().
+	Foreground(special).
+	Bold(true)
+
+// An earlier search and its results, kept for searching across them
+type searchSession struct {
+	pattern    string
+	root       string
+	results    []Item
+	loadedFrom string // the session file they were loaded from, if they were
+}
+
+func (s *searchSession) label() string {
+	if s.loadedFrom != "" {
+		return fmt.Sprintf("[%s from %s]", s.pattern, filepath.Base(s.loadedFrom))
+	}
+	return fmt.Sprintf("[%s]", s.pattern)
+}
+
+// Whether two sessions are of the same search, loaded from the same file
+// or run here
+func (s *searchSession) same(other *searchSession) bool {
+	return s.pattern == other.pattern && s.root == other.root && s.loadedFrom == other.loadedFrom
+}
+
+// Keep the current results as a session before they're replaced. Running
+// the same search again replaces its session.
+func (m *model) saveSession() {
+	if len(m.results) == 0 || m.currentSearchPattern == "" {
+		return
+	}
+	session := &searchSession{pattern: m.currentSearchPattern, root: m.paths.root, results: m.results, loadedFrom: m.loadedFrom}
+	for i, s := range m.sessions {
+		if s.same(session) {
+			m.sessions = append(m.sessions[:i], m.sessions[i+1:]...)
+			break
+		}
+	}
+	m.sessions = append(m.sessions, session)
+	if len(m.sessions) > maxSessions {
+		m.sessions = m.sessions[len(m.sessions)-maxSessions:]
+	}
+}
+
+// A result from one of the sessions
+type sessionHit struct {
+	session *searchSession
+	item    Item
+}
+
+func (h sessionHit) Title() string {
+	return h.session.label() + " " + h.item.Title()
+}
+
+func (h sessionHit) Description() string { return h.item.content }
+func (h sessionHit) FilterValue() string { return h.item.FilterValue() }
+
+func newSessionInput() textinput.Model {
+	input := textinput.New()
+	input.Placeholder = "regex on path or line, like auth\\.go"
+	input.Prompt = "All searches ❯ "
+	input.PromptStyle = searchPromptStyle
+	input.TextStyle = lipgloss.NewStyle().Foreground(highlight)
+	input.Cursor.Style = lipgloss.NewStyle().Foreground(special)
+	return input
+}
+
+func newSessionList() list.Model {
+	sessionList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	sessionList.Title = "Across Searches"
+	sessionList.SetShowHelp(false)
+	sessionList.SetFilteringEnabled(false)
+	sessionList.SetStatusBarItemName("result", "results")
+	sessionList.Styles.Title = lipgloss.NewStyle().
+		Foreground(special).
+		Bold(true).
+		MarginLeft(2)
+	return sessionList
+}
+
+// The earlier searches and the current one, oldest first
+func (m model) allSessions() []*searchSession {
+	sessions := append([]*searchSession(nil), m.sessions...)
+	if len(m.results) > 0 {
+		current := &searchSession{pattern: m.currentSearchPattern, root: m.paths.root, results: m.results, loadedFrom: m.loadedFrom}
+		for i, s := range sessions {
+			if s.same(current) {
+				sessions = append(sessions[:i], sessions[i+1:]...)
+				break
+			}
+		}
+		sessions = append(sessions, current)
+	}
+	return sessions
+}
+
+// Open the search across every search of this run
+func (m *model) openSessions() tea.Cmd {
+	if len(m.allSessions()) == 0 {
+		m.statusMessage = "No searches to look across yet"
+		m.statusMessageType = "info"
+		return nil
+	}
+	m.showSessions = true
+	m.sessionInput.SetValue("")
+	m.matchSessions()
+	return m.sessionInput.Focus()
+}
+
+// List the results of every session that get through the query, labeled
+// with the search they came from
+func (m *model) matchSessions() {
+	filter, err := parseResultFilter(m.sessionInput.Value())
+	m.sessionErr = err
+	if err != nil {
+		return
+	}
+	var hits []list.Item
+	found := map[*searchSession]int{}
+	sessions := m.allSessions()
+	for _, s := range sessions {
+		for _, item := range s.results {
+			if filter.keep(item) {
+				found[s]++
+				if len(hits) < maxSessionHits {
+					hits = append(hits, sessionHit{session: s, item: item})
+				}
+			}
+		}
+	}
+	m.sessionList.SetItems(hits)
+	m.sessionList.ResetSelected()
+
+	var counts []string
+	for _, s := range sessions {
+		counts = append(counts, fmt.Sprintf("%s %d", s.label(), found[s]))
+	}
+	m.sessionList.Title = "Across Searches: " + strings.Join(counts, " · ")
+}
+
+// Keys go to the query, except for moving through and opening the results
+func (m model) updateSessions(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.String() == "ctrl+c":
+		return m, tea.Quit
+
+	case key.Matches(msg, m.keymap.Back) || key.Matches(msg, m.keymap.Sessions):
+		m.showSessions = false
+		m.sessionInput.Blur()
+		return m, nil
+
+	case key.Matches(msg, m.keymap.Enter):
+		hit, ok := m.sessionList.SelectedItem().(sessionHit)
+		if !ok {
+			return m, nil
+		}
+		m.showSessions = false
+		m.sessionInput.Blur()
+		m.activeTab = fileTab
+		m.statusMessage = fmt.Sprintf("Viewing file: %s (from the search for %s)", hit.item.fullPath, hit.session.pattern)
+		m.statusMessageType = "info"
+		return m, m.openFile(hit.item)
+
+	case msg.String() == "up", msg.String() == "down", msg.String() == "pgup", msg.String() == "pgdown":
+		var cmd tea.Cmd
+		m.sessionList, cmd = m.sessionList.Update(msg)
+		return m, cmd
+	}
+
+	before := m.sessionInput.Value()
+	var cmd tea.Cmd
+	m.sessionInput, cmd = m.sessionInput.Update(msg)
+	if m.sessionInput.Value() != before {
+		m.matchSessions()
+	}
+	return m, cmd
+}
+
+func (m model) sessionsView() string {
+	input := m.sessionInput.View()
+	if m.sessionErr != nil {
+		input += "  " + resultFilterErrorStyle.Render(m.sessionErr.Error())
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, resultFilterStyle.Render(input), "", m.sessionList.View())
+}
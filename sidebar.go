@@ -0,0 +1,316 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const maxSidebarWidth = 40
+
+var (
+	sidebarStyle = lipgloss.NewStyle().
+			BorderStyle(lipgloss.NormalBorder()).
+			BorderRight(true).
+			BorderForeground(subtle).
+			MarginRight(1)
+
+	sidebarTitleStyle = lipgloss.NewStyle().
+				Foreground(special).
+				Bold(true)
+
+	sidebarBlurredTitleStyle = lipgloss.NewStyle().
+					Foreground(subtle).
+					Bold(true)
+)
+
+const dirtyMarker = "●"
+
+// Orders for the files in the sidebar, which o goes through. They start out
+// in the order rg found them.
+const (
+	sortByFound    = "found"
+	sortByMatches  = "matches"
+	sortByModified = "modified"
+	sortByDirty    = "git status"
+)
+
+var sidebarSorts = []string{sortByFound, sortByMatches, sortByModified, sortByDirty}
+
+// A matched file in the sidebar, with how many of the visible results are
+// in it, when it last changed and whether git has uncommitted changes to it
+type fileSummary struct {
+	path     string
+	display  string
+	count    int
+	modified time.Time
+	dirty    bool
+}
+
+func (f fileSummary) Title() string {
+	dirty := " "
+	if f.dirty {
+		dirty = dirtyMarker
+	}
+	return fmt.Sprintf("%4d %4s %s %s", f.count, formatAge(f.modified), dirty, f.display)
+}
+
+func (f fileSummary) Description() string { return "" }
+func (f fileSummary) FilterValue() string { return f.display }
+
+func newFileSidebar() list.Model {
+	delegate := list.NewDefaultDelegate()
+	delegate.ShowDescription = false
+	delegate.SetSpacing(0)
+
+	sidebar := list.New([]list.Item{}, delegate, 0, 0)
+	sidebar.Title = sidebarTitle(sortByFound)
+	sidebar.SetShowHelp(false)
+	sidebar.SetShowStatusBar(false)
+	sidebar.SetFilteringEnabled(false)
+	sidebar.Styles.Title = sidebarTitleStyle
+	return sidebar
+}
+
+func (m model) sidebarWidth() int {
+	if !m.resultsState.showSidebar {
+		return 0
+	}
+	return min(maxSidebarWidth, m.resultsWidth()/3)
+}
+
+// Show and focus the sidebar, focus it if it's showing, or hide it if it
+// already has focus
+func (m *model) toggleSidebar() {
+	switch {
+	case !m.resultsState.showSidebar:
+		m.resultsState.showSidebar = true
+		m.resultsState.sidebarFocused = true
+		m.syncSidebar()
+	case !m.resultsState.sidebarFocused:
+		m.resultsState.sidebarFocused = true
+	default:
+		m.resultsState.showSidebar = false
+		m.resultsState.sidebarFocused = false
+	}
+	m.layoutResults()
+}
+
+func sidebarTitle(order string) string {
+	if order == sortByFound {
+		return "Files"
+	}
+	return "Files by " + order
+}
+
+// How long ago t was, in the largest unit that fits
+func formatAge(t time.Time) string {
+	if t.IsZero() {
+		return "?"
+	}
+	age := time.Since(t)
+	switch {
+	case age < time.Minute:
+		return "now"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	case age < 30*24*time.Hour:
+		return fmt.Sprintf("%dd", int(age.Hours()/24))
+	case age < 365*24*time.Hour:
+		return fmt.Sprintf("%dmo", int(age.Hours()/24/30))
+	}
+	return fmt.Sprintf("%dy", int(age.Hours()/24/365))
+}
+
+// The files under dir's git repository with uncommitted changes, untracked
+// ones included. Empty outside of git.
+func gitDirtyFiles(dir string) map[string]bool {
+	dirty := map[string]bool{}
+	root, err := gitRoot(dir)
+	if err != nil {
+		return dirty
+	}
+	out, err := exec.Command("git", "-C", root, "status", "--porcelain", "-z", "--untracked-files=all").Output()
+	if err != nil {
+		return dirty
+	}
+	entries := strings.Split(string(out), "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		dirty[filepath.Join(root, entry[3:])] = true
+		if entry[0] == 'R' || entry[0] == 'C' {
+			// The path it was renamed or copied from comes next
+			i++
+		}
+	}
+	return dirty
+}
+
+// Rebuild the file list from the visible results
+func (m *model) syncSidebar() {
+	if !m.resultsState.showSidebar {
+		return
+	}
+	if m.resultsState.dirtySearch != m.searchID {
+		m.resultsState.dirty = gitDirtyFiles(m.paths.root)
+		m.resultsState.dirtySearch = m.searchID
+	}
+	m.resultsState.sidebarIndex = map[string]int{}
+	m.resultsState.sidebar.SetItems(nil)
+	m.extendSidebar(0)
+}
+
+// Count the visible results from index from onwards into the file list, so
+// streamed results don't mean recounting everything
+func (m *model) extendSidebar(from int) {
+	s := &m.resultsState
+	if !s.showSidebar {
+		return
+	}
+
+	items := s.sidebar.Items()
+	for i := from; i < s.list.count(); i++ {
+		result := s.list.at(i)
+		j, ok := s.sidebarIndex[result.fullPath]
+		if !ok {
+			j = len(items)
+			s.sidebarIndex[result.fullPath] = j
+			summary := fileSummary{path: result.fullPath, display: m.paths.show(result.fileName), dirty: s.dirty[result.fullPath]}
+			if info, err := os.Stat(result.fullPath); err == nil {
+				summary.modified = info.ModTime()
+			}
+			items = append(items, summary)
+		}
+		summary := items[j].(fileSummary)
+		summary.count++
+		items[j] = summary
+	}
+	if s.sidebarSort != sortByFound {
+		sortFiles(items, s.sidebarSort)
+		for i, item := range items {
+			s.sidebarIndex[item.(fileSummary).path] = i
+		}
+	}
+	s.sidebar.SetItems(items)
+	m.followSidebar()
+}
+
+// Sort the sidebar's files, most matches, most recently modified or dirty
+// first, and by path among equals
+func sortFiles(items []list.Item, order string) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i].(fileSummary), items[j].(fileSummary)
+		switch order {
+		case sortByMatches:
+			if a.count != b.count {
+				return a.count > b.count
+			}
+		case sortByModified:
+			if !a.modified.Equal(b.modified) {
+				return a.modified.After(b.modified)
+			}
+		case sortByDirty:
+			if a.dirty != b.dirty {
+				return a.dirty
+			}
+		}
+		return a.path < b.path
+	})
+}
+
+// Go on to the next order for the sidebar's files
+func (m *model) cycleSidebarSort() {
+	s := &m.resultsState
+	i := 0
+	for i < len(sidebarSorts) && sidebarSorts[i] != s.sidebarSort {
+		i++
+	}
+	s.sidebarSort = sidebarSorts[(i+1)%len(sidebarSorts)]
+	s.sidebar.Title = sidebarTitle(s.sidebarSort)
+	m.syncSidebar()
+}
+
+// Select the file the results list is on
+func (m *model) followSidebar() {
+	if !m.resultsState.showSidebar {
+		return
+	}
+	if selected, ok := m.resultsState.list.selected(); ok {
+		m.resultsState.sidebar.Select(m.resultsState.sidebarIndex[selected.fullPath])
+	}
+}
+
+// Move the results list to the first match in the file selected in the sidebar
+func (m *model) jumpToSidebarFile() {
+	file, ok := m.resultsState.sidebar.SelectedItem().(fileSummary)
+	if !ok {
+		return
+	}
+	l := &m.resultsState.list
+	for i := range l.count() {
+		if l.at(i).fullPath == file.path {
+			l.selectIndex(i)
+			return
+		}
+	}
+}
+
+// Handle keys while the sidebar has focus. Moving through the files moves
+// the results list along with it.
+func (m model) updateSidebar(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keymap.Quit):
+		return m, tea.Quit
+
+	case key.Matches(msg, m.keymap.Help):
+		return m, m.openHelp()
+
+	case key.Matches(msg, m.keymap.Sidebar):
+		m.toggleSidebar()
+		return m, nil
+
+	case key.Matches(msg, m.keymap.Enter):
+		m.jumpToSidebarFile()
+		m.loadMoreResults()
+		m.resultsState.sidebarFocused = false
+		return m, m.updatePreview()
+
+	case key.Matches(msg, m.keymap.Back):
+		m.resultsState.sidebarFocused = false
+		return m, nil
+
+	case key.Matches(msg, m.keymap.SidebarSort):
+		m.cycleSidebarSort()
+		return m, m.updatePreview()
+	}
+
+	previous := m.resultsState.sidebar.Index()
+	var cmd tea.Cmd
+	m.resultsState.sidebar, cmd = m.resultsState.sidebar.Update(msg)
+	if m.resultsState.sidebar.Index() != previous {
+		m.jumpToSidebarFile()
+	}
+	return m, tea.Batch(cmd, m.updatePreview())
+}
+
+func (m model) sidebarView() string {
+	sidebar := m.resultsState.sidebar
+	if !m.resultsState.sidebarFocused {
+		sidebar.Styles.Title = sidebarBlurredTitleStyle
+	}
+	return m.bordered(sidebarStyle).Render(sidebar.View())
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	statsStyle = lipgloss.NewStyle().
+			BorderStyle(lipgloss.RoundedBorder()).
+			BorderForeground(highlight).
+			Padding(1, 2)
+
+	statsLabelStyle = lipgloss.NewStyle().
+			Foreground(subtle).
+			Width(18)
+)
+
+// What rg's summary says about a search, plus what it took to get the
+// results on screen
+type searchStats struct {
+	elapsed           time.Duration // rg's own time
+	searches          int           // files searched
+	searchesWithMatch int
+	bytesSearched     int64
+	matchedLines      int
+	matches           int
+
+	// Filled in when the search finishes
+	pattern  string
+	where    string
+	total    time.Duration // from starting the search to the last result
+	results  int           // results kept, after exclusions
+	perMatch bool          // whether results were split up by match
+}
+
+// The `summary` message rg --json ends with
+type rgSummary struct {
+	ElapsedTotal struct {
+		Secs  int64 `json:"secs"`
+		Nanos int64 `json:"nanos"`
+	} `json:"elapsed_total"`
+	Stats struct {
+		Searches          int   `json:"searches"`
+		SearchesWithMatch int   `json:"searches_with_match"`
+		BytesSearched     int64 `json:"bytes_searched"`
+		MatchedLines      int   `json:"matched_lines"`
+		Matches           int   `json:"matches"`
+	} `json:"stats"`
+}
+
+func parseSummary(data json.RawMessage) *searchStats {
+	var summary rgSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil
+	}
+	return &searchStats{
+		elapsed:           time.Duration(summary.ElapsedTotal.Secs)*time.Second + time.Duration(summary.ElapsedTotal.Nanos),
+		searches:          summary.Stats.Searches,
+		searchesWithMatch: summary.Stats.SearchesWithMatch,
+		bytesSearched:     summary.Stats.BytesSearched,
+		matchedLines:      summary.Stats.MatchedLines,
+		matches:           summary.Stats.Matches,
+	}
+}
+
+// Keep the finished search's statistics for the stats panel
+func (m *model) recordStats(stats *searchStats) {
+	if stats == nil {
+		return
+	}
+	stats.pattern = m.currentSearchPattern
+	stats.where = m.paths.root
+	stats.total = time.Since(m.searchStarted)
+	stats.results = len(m.results)
+	stats.perMatch = m.expandMatches
+	m.stats = stats
+}
+
+// n bytes in the largest unit that keeps it at least 1
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for n/div >= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// Milliseconds are too coarse for small searches
+func formatDuration(d time.Duration) string {
+	if d < 10*time.Millisecond {
+		return d.Round(time.Microsecond).String()
+	}
+	return d.Round(time.Millisecond).String()
+}
+
+func (m model) statsView() string {
+	s := m.stats
+	if s == nil {
+		return statsStyle.Render("No statistics yet: they're shown once a search with rg finishes")
+	}
+
+	row := func(label, value string) string {
+		return statsLabelStyle.Render(label) + value
+	}
+	results := formatCount(s.results) + " (one per matched line, after exclusions)"
+	if s.perMatch {
+		results = formatCount(s.results) + " (one per match, after exclusions)"
+	}
+	rows := []string{
+		searchPromptStyle.Render("Search Statistics"),
+		"",
+		row("Pattern", s.pattern),
+		row("Searched", s.where),
+		row("Files searched", formatCount(s.searches)),
+		row("Files matched", formatCount(s.searchesWithMatch)),
+		row("Bytes searched", formatBytes(s.bytesSearched)),
+		row("Matched lines", formatCount(s.matchedLines)),
+		row("Matches", formatCount(s.matches)),
+		row("Results", results),
+		row("rg time", formatDuration(s.elapsed)),
+		row("Total time", formatDuration(s.total)+" (including loading the results)"),
+	}
+	return statsStyle.Render(strings.Join(rows, "\n"))
+}
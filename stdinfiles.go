@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var stdinFilesStyle = lipgloss.NewStyle().
+	Foreground(special)
+
+// Whether lazyrg's stdin is a pipe or a file rather than the terminal
+func stdinPiped() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
+
+// The files listed one to a line, as git diff --name-only or find print
+// them, made absolute. Files that aren't there (like ones a diff deleted)
+// are left out, and so are repeats. Nothing at all, as cron and CI jobs
+// get on stdin, isn't a list, so there are no files and no error.
+func readFileList(r io.Reader) ([]string, error) {
+	var files []string
+	listed := false
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		path := strings.TrimSpace(scanner.Text())
+		if path == "" {
+			continue
+		}
+		listed = true
+		path = absPath(path)
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+		files = append(files, path)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if listed && len(files) == 0 {
+		return nil, fmt.Errorf("none of the files listed on stdin are there")
+	}
+	return files, nil
+}
+
+// The files from stdin being searched, as shown in the search tab
+func (m model) stdinFilesView() string {
+	what := filepath.Base(m.stdinFiles[0])
+	if len(m.stdinFiles) > 1 {
+		what = fmt.Sprintf("%d files", len(m.stdinFiles))
+	}
+	return stdinFilesStyle.Render(fmt.Sprintf("📄 Searching %s from stdin (type a directory to search it instead)", what))
+}
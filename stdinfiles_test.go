@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadFileList(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")
+	for _, path := range []string{a, b} {
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gone := filepath.Join(dir, "gone.go")
+
+	tests := []struct {
+		name    string
+		stdin   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "files", stdin: a + "\n" + b + "\n", want: []string{a, b}},
+		{name: "blank lines and repeats", stdin: "\n" + a + "\n\n" + a + "\n" + b, want: []string{a, b}},
+		{name: "deleted files skipped", stdin: gone + "\n" + a + "\n", want: []string{a}},
+		{name: "nothing, as cron gives", stdin: "", want: nil},
+		{name: "only blank lines", stdin: "\n\n", want: nil},
+		{name: "none there", stdin: gone + "\n", wantErr: true},
+	}
+	for _, test := range tests {
+		files, err := readFileList(strings.NewReader(test.stdin))
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: error %v, want an error: %v", test.name, err, test.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(files, test.want) {
+			t.Errorf("%s: files %v, want %v", test.name, files, test.want)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The names ctags files go by, looked for from the viewed file's directory
+// up
+var tagsFileNames = []string{"tags", ".tags"}
+
+// A definition from a ctags file: where a name is defined, by line number
+// or by a pattern matching the line
+type tag struct {
+	name    string
+	path    string
+	line    int
+	pattern string
+	kind    string
+}
+
+func (t tag) Title() string {
+	if t.kind != "" {
+		return fmt.Sprintf("%s  (%s)", t.name, t.kind)
+	}
+	return t.name
+}
+
+func (t tag) Description() string {
+	if t.line > 0 {
+		return fmt.Sprintf("%s:%d", t.path, t.line)
+	}
+	return fmt.Sprintf("%s · %s", t.path, strings.TrimSpace(t.pattern))
+}
+
+func (t tag) FilterValue() string { return t.path + " " + t.kind }
+
+// Where a jump to a definition came from, to go back to
+type tagReturn struct {
+	item Item
+	pos  viewPosition
+}
+
+type tagsFoundMsg struct {
+	token int
+	name  string
+	file  string // the tags file
+	tags  []tag
+	err   error
+}
+
+func newTagList() list.Model {
+	tagList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	tagList.SetShowHelp(false)
+	tagList.SetStatusBarItemName("definition", "definitions")
+	tagList.Styles.Title = lipgloss.NewStyle().
+		Foreground(special).
+		Bold(true).
+		MarginLeft(2)
+	return tagList
+}
+
+// The nearest tags file in dir or a directory above it
+func findTagsFile(dir string) string {
+	for {
+		for _, name := range tagsFileNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// The definitions of name in a tags file. Each line is the name, the file
+// (relative to the tags file) and an address, a line number or a /pattern/,
+// then ;" and extra fields like the kind.
+func lookupTags(tagsFile, name string) ([]tag, error) {
+	file, err := os.Open(tagsFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var tags []tag
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	prefix := name + "\t"
+	for scanner.Scan() {
+		text := scanner.Text()
+		if !strings.HasPrefix(text, prefix) {
+			continue
+		}
+		fields := strings.SplitN(text, "\t", 3)
+		if len(fields) < 3 {
+			continue
+		}
+		address, extra, _ := strings.Cut(fields[2], `;"`)
+		t := tag{name: name, path: fields[1]}
+		if !filepath.IsAbs(t.path) {
+			t.path = filepath.Join(filepath.Dir(tagsFile), t.path)
+		}
+		if n, err := strconv.Atoi(address); err == nil {
+			t.line = n
+		} else if len(address) >= 2 && (address[0] == '/' || address[0] == '?') {
+			t.pattern = address[1 : len(address)-1]
+		}
+		for _, field := range strings.Split(strings.TrimSpace(extra), "\t") {
+			if kind, ok := strings.CutPrefix(field, "kind:"); ok {
+				t.kind = kind
+			} else if len(field) == 1 {
+				t.kind = field
+			}
+		}
+		tags = append(tags, t)
+	}
+	return tags, scanner.Err()
+}
+
+// The line a tag points at. A pattern is the whole line, anchored with ^
+// and $, with / and \ escaped.
+func (t tag) resolve() (int, error) {
+	if t.line > 0 || t.pattern == "" {
+		return max(t.line, 1), nil
+	}
+	pattern := strings.NewReplacer(`\/`, "/", `\?`, "?", `\\`, `\`).Replace(t.pattern)
+	pattern = strings.TrimPrefix(pattern, "^")
+	whole := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	file, err := os.Open(t.path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	for n := 1; scanner.Scan(); n++ {
+		if line := scanner.Text(); line == pattern || (!whole && strings.HasPrefix(line, pattern)) {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("%s has changed since the tags were made", t.path)
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// The identifier n and N are on in the viewer (the matched one when the
+// file's just been opened): the first in the match, widened to the whole
+// word
+func (m model) identifierAtHit() string {
+	var line string
+	var start, end int
+	if hits := m.fileState.hits; len(hits) > 0 {
+		hit := hits[m.fileState.hit]
+		text, ok := readLine(m.fileState.item.fullPath, hit.line)
+		if !ok || hit.end > len(text) {
+			return ""
+		}
+		line, start, end = text, hit.start, hit.end
+	} else if item := m.fileState.item; len(item.lineMatches) > 0 {
+		text, ok := readLine(item.fullPath, item.lineNum)
+		if !ok || item.lineMatches[0].end > len(text) {
+			return ""
+		}
+		line, start, end = text, item.lineMatches[0].start, item.lineMatches[0].end
+	} else {
+		return ""
+	}
+	// The first identifier in the match, running on past it on either side
+	for start < end && !isIdentRune(rune(line[start])) {
+		start++
+	}
+	if start == end {
+		return ""
+	}
+	for start > 0 && isIdentRune(rune(line[start-1])) {
+		start--
+	}
+	for end = start; end < len(line) && isIdentRune(rune(line[end])); end++ {
+	}
+	return line[start:end]
+}
+
+// Look up the definition of the identifier at the current match in the
+// nearest tags file
+func (m *model) jumpToDefinition() tea.Cmd {
+	if m.fileState.doc == nil || m.fileState.item.remote != nil {
+		return nil
+	}
+	name := m.identifierAtHit()
+	if name == "" {
+		m.statusMessage = "No identifier at the match to look up"
+		m.statusMessageType = "error"
+		return nil
+	}
+	tagsFile := findTagsFile(filepath.Dir(m.fileState.item.fullPath))
+	if tagsFile == "" {
+		m.statusMessage = "No tags file here or above (make one with ctags -R)"
+		m.statusMessageType = "error"
+		return nil
+	}
+	token := m.fileToken
+	return func() tea.Msg {
+		tags, err := lookupTags(tagsFile, name)
+		return tagsFoundMsg{token: token, name: name, file: tagsFile, tags: tags, err: err}
+	}
+}
+
+// Go straight to a single definition, or offer the choice of several
+func (m *model) handleTagsFound(msg tagsFoundMsg) tea.Cmd {
+	if msg.token != m.fileToken {
+		return nil
+	}
+	switch {
+	case msg.err != nil:
+		m.statusMessage = fmt.Sprintf("Error reading %s: %s", msg.file, msg.err)
+		m.statusMessageType = "error"
+		return nil
+	case len(msg.tags) == 0:
+		m.statusMessage = fmt.Sprintf("No definition of %s in %s", msg.name, m.paths.show(msg.file))
+		m.statusMessageType = "info"
+		return nil
+	case len(msg.tags) == 1:
+		return m.gotoTag(msg.tags[0])
+	}
+	items := make([]list.Item, len(msg.tags))
+	for i, t := range msg.tags {
+		items[i] = t
+	}
+	m.tagList.Title = fmt.Sprintf("Definitions of %s", msg.name)
+	m.tagList.ResetFilter()
+	m.tagList.SetItems(items)
+	m.tagList.Select(0)
+	m.showTags = true
+	return nil
+}
+
+// Open a definition, keeping where the viewer was to come back to
+func (m *model) gotoTag(t tag) tea.Cmd {
+	line, err := t.resolve()
+	if err != nil {
+		m.statusMessage = fmt.Sprintf("Can't find the definition of %s: %s", t.name, err)
+		m.statusMessageType = "error"
+		return nil
+	}
+	m.tagStack = append(m.tagStack, tagReturn{
+		item: m.fileState.item,
+		pos:  viewPosition{line: m.fileState.viewer.YOffset - m.fileState.lineOffset + 1, xOffset: m.fileState.xOffset},
+	})
+
+	item := Item{fileName: t.path, fullPath: t.path, lineNum: line}
+	if text, ok := readLine(t.path, line); ok {
+		if i := strings.Index(text, t.name); i >= 0 {
+			item.column = i + 1
+			item.lineMatches = []matchSpan{{start: i, end: i + len(t.name)}}
+		}
+	}
+	m.statusMessage = fmt.Sprintf("Definition of %s: %s:%d (%s to go back)", t.name, m.paths.show(t.path), line, m.keymap.TagBack.Help().Key)
+	m.statusMessageType = "info"
+	return m.openFile(item)
+}
+
+// Go back to where the last jump to a definition was made from
+func (m *model) popTag() tea.Cmd {
+	if len(m.tagStack) == 0 {
+		return nil
+	}
+	back := m.tagStack[len(m.tagStack)-1]
+	m.tagStack = m.tagStack[:len(m.tagStack)-1]
+	if m.fileState.positions == nil {
+		m.fileState.positions = map[resultKey]viewPosition{}
+	}
+	m.fileState.positions[back.item.key()] = back.pos
+	m.statusMessage = fmt.Sprintf("Back to %s", m.paths.show(back.item.fileName))
+	m.statusMessageType = "info"
+	return m.openFile(back.item)
+}
+
+// Handle keys while choosing between definitions
+func (m model) updateTags(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.tagList.SettingFilter() {
+		switch {
+		case key.Matches(msg, m.keymap.Quit):
+			return m, tea.Quit
+
+		case key.Matches(msg, m.keymap.Back):
+			m.showTags = false
+			return m, nil
+
+		case key.Matches(msg, m.keymap.Enter):
+			t, ok := m.tagList.SelectedItem().(tag)
+			if !ok {
+				return m, nil
+			}
+			m.showTags = false
+			return m, m.gotoTag(t)
+		}
+	}
+
+	var cmd tea.Cmd
+	m.tagList, cmd = m.tagList.Update(msg)
+	return m, cmd
+}
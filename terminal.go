@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Where lazyrg is running, which decides how it reaches the clipboard and
+// how it gets the user's attention
+type session struct {
+	ssh    bool
+	tmux   bool
+	screen bool
+}
+
+func detectSession() session {
+	return session{
+		ssh:    os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CLIENT") != "",
+		tmux:   os.Getenv("TMUX") != "",
+		screen: os.Getenv("STY") != "",
+	}
+}
+
+// Desktop APIs on this machine can't reach the user's screen over SSH
+func (s session) remote() bool {
+	return s.ssh
+}
+
+// The terminal the program draws to
+func (m model) terminal() io.Writer {
+	if m.renderMonitor != nil {
+		return m.renderMonitor
+	}
+	return os.Stdout
+}
+
+// The terminal as a file, for programs lazyrg hands it over to: given a
+// writer that isn't one, exec would connect them through a pipe, and
+// pagers that find their output isn't a terminal just print the file
+func (m model) terminalFile() *os.File {
+	if m.renderMonitor != nil {
+		return m.renderMonitor.File
+	}
+	return os.Stdout
+}
+
+// Write text to the clipboard. Over SSH (or wherever there's no clipboard
+// tool) it goes through the terminal with OSC 52, wrapped so tmux and
+// screen pass it on to the outer terminal.
+func (m model) writeClipboard(text string) error {
+	if !m.session.remote() && !clipboard.Unsupported {
+		if err := clipboard.WriteAll(text); err == nil {
+			return nil
+		}
+	}
+
+	seq := osc52.New(text)
+	switch {
+	case m.session.tmux:
+		seq = seq.Tmux()
+	case m.session.screen:
+		seq = seq.Screen()
+	}
+	_, err := seq.WriteTo(m.terminal())
+	return err
+}
+
+// Get the user's attention: a desktop notification when running locally,
+// a tmux message inside tmux, and the terminal bell otherwise
+func (m model) notify(message string) tea.Cmd {
+	return func() tea.Msg {
+		var cmd *exec.Cmd
+		switch {
+		case m.session.tmux:
+			cmd = exec.Command("tmux", "display-message", message)
+		case m.session.remote():
+		case runtime.GOOS == "darwin":
+			cmd = exec.Command("osascript", "-e", "display notification "+appleScriptString(message)+` with title "lazyrg"`)
+		case runtime.GOOS == "linux":
+			cmd = exec.Command("notify-send", "lazyrg", message)
+		}
+
+		if cmd == nil || cmd.Run() != nil {
+			if _, err := io.WriteString(m.terminal(), "\a"); err != nil {
+				log.Printf("Error ringing terminal bell: %v", err)
+			}
+		}
+		return nil
+	}
+}
+
+func appleScriptString(s string) string {
+	quoted := []rune{'"'}
+	for _, r := range s {
+		if r == '"' || r == '\\' {
+			quoted = append(quoted, '\\')
+		}
+		quoted = append(quoted, r)
+	}
+	return string(append(quoted, '"'))
+}
+
+// Waits for Enter while the program has handed the terminal back, so the
+// shell's output from before lazyrg started can be read
+type peekCommand struct {
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+func (c *peekCommand) SetStdin(r io.Reader)  { c.stdin = r }
+func (c *peekCommand) SetStdout(w io.Writer) { c.stdout = w }
+func (c *peekCommand) SetStderr(io.Writer)   {}
+
+func (c *peekCommand) Run() error {
+	if _, err := io.WriteString(c.stdout, "\n[lazyrg] Press Enter to go back "); err != nil {
+		return err
+	}
+	_, err := bufio.NewReader(c.stdin).ReadString('\n')
+	return err
+}
+
+// Leave the alternate screen to show the terminal underneath until Enter
+// is pressed, then redraw
+func (m model) peekTerminal() tea.Cmd {
+	return tea.Exec(&peekCommand{}, func(err error) tea.Msg {
+		return peekDoneMsg{err: err}
+	})
+}
+
+type peekDoneMsg struct {
+	err error
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The config file a project can keep in its root, with settings for
+// everyone searching it
+const projectConfigName = ".lazyrg.json"
+
+// A project's config file, and whether it may run the commands in it
+type projectConfig struct {
+	path    string
+	data    []byte
+	hash    string // of the data, so a changed file has to be trusted again
+	cfg     config // only the settings the file sets
+	trusted bool
+	refused bool // for good, until the file changes
+}
+
+// Find and read the config file at the root of the project dir is in: the
+// git repository's top level, or dir itself outside of git
+func findProjectConfig(dir string) (*projectConfig, error) {
+	root := dir
+	if top, err := gitRoot(dir); err == nil {
+		root = top
+	}
+	path := filepath.Join(root, projectConfigName)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	project := &projectConfig{path: path, data: data}
+	if err := json.Unmarshal(data, &project.cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	sum := sha256.Sum256(data)
+	project.hash = hex.EncodeToString(sum[:])
+
+	decisions, err := loadTrust()
+	if err != nil {
+		return nil, err
+	}
+	if decision, ok := decisions[path]; ok && decision.Hash == project.hash {
+		project.trusted = decision.Trusted
+		project.refused = !decision.Trusted
+	}
+	return project, nil
+}
+
+// Whether the file runs commands without having been trusted (or refused)
+func (p *projectConfig) needsTrust() bool {
+	return !p.trusted && !p.refused && len(p.cfg.commands()) > 0
+}
+
+// Lay the project's settings over the user's, which unmarshaling over them
+// does, since it only replaces what the file sets. The user's commands stay
+// until the project is trusted.
+func (p *projectConfig) apply(cfg *config) error {
+	user := *cfg
+	if err := json.Unmarshal(p.data, cfg); err != nil {
+		return fmt.Errorf("%s: %w", p.path, err)
+	}
+	if !p.trusted {
+		cfg.Pre = user.Pre
+		cfg.Repos = user.Repos
+		cfg.Index = user.Index
+		cfg.Previewer = user.Previewer
+		cfg.Editor = user.Editor
+		cfg.NvimServer = user.NvimServer
+		cfg.Tmux = user.Tmux
+	}
+	// Trusted or not, a project can't turn off the user's protections or
+	// send their token or results somewhere else
+	cfg.Sandbox = cfg.Sandbox || user.Sandbox
+	cfg.ReadOnly = cfg.ReadOnly || user.ReadOnly
+	cfg.Remote = user.Remote
+	cfg.Webhook = user.Webhook
+	return nil
+}
+
+// The settings that run commands or search outside the project, described
+// for the trust prompt
+func (c config) commands() []string {
+	var commands []string
+	if c.Pre != "" {
+		commands = append(commands, "pre: "+c.Pre)
+	}
+	if len(c.Repos) > 0 {
+		commands = append(commands, "repos: "+strings.Join(c.Repos, ", "))
+	}
+	if len(c.Index) > 0 {
+		commands = append(commands, "index: "+strings.Join(c.Index, ", "))
+	}
+	if c.Previewer != "" {
+		commands = append(commands, "previewer: "+c.Previewer)
+	}
+	if c.Editor != "" {
+		commands = append(commands, "editor: "+c.Editor)
+	}
+	if c.NvimServer != "" {
+		// Whatever listens there is sent the files to open
+		commands = append(commands, "nvimServer: "+c.NvimServer)
+	}
+	if c.Tmux != "" {
+		// Its words are tmux's arguments, and tmux will run any command
+		commands = append(commands, "tmux: "+c.Tmux)
+	}
+	return commands
+}
+
+// A remembered answer to the trust prompt for one project config file
+type trustDecision struct {
+	Hash    string `json:"hash"`
+	Trusted bool   `json:"trusted"`
+}
+
+func trustPath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "trust.json"), nil
+}
+
+func loadTrust() (map[string]trustDecision, error) {
+	path, err := trustPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]trustDecision{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	decisions := map[string]trustDecision{}
+	return decisions, json.Unmarshal(data, &decisions)
+}
+
+func saveTrust(path string, decision trustDecision) error {
+	decisions, err := loadTrust()
+	if err != nil {
+		return err
+	}
+	decisions[path] = decision
+
+	file, err := trustPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(decisions, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// Ask whether to run the commands in a project's config. Yes and "never"
+// are remembered until the file changes; no only holds for this run.
+func (m *model) askTrust(project *projectConfig) {
+	question := fmt.Sprintf("%s wants to run commands or search outside the project (%s). Trust it?", project.path, strings.Join(project.cfg.commands(), "; "))
+	m.ask(question, func(m *model) tea.Cmd {
+		project.trusted = true
+		m.statusMessage = fmt.Sprintf("Trusted %s", project.path)
+		m.statusMessageType = "info"
+		m.rememberTrust(project)
+		cmd := m.applyProjectCommands(project.cfg)
+		if m.currentSearchPattern != "" {
+			// The search that already ran did without them
+			return tea.Batch(cmd, m.startSearch())
+		}
+		return cmd
+	}, answer{key: "d", label: "never", do: func(m *model) tea.Cmd {
+		project.refused = true
+		m.rememberTrust(project)
+		m.statusMessage = fmt.Sprintf("Won't run commands from %s until it changes", project.path)
+		m.statusMessageType = "info"
+		return nil
+	}})
+}
+
+func (m *model) rememberTrust(project *projectConfig) {
+	if err := saveTrust(project.path, trustDecision{Hash: project.hash, Trusted: project.trusted}); err != nil {
+		m.statusMessage = fmt.Sprintf("Error saving the trust decision: %s", err)
+		m.statusMessageType = "error"
+	}
+}
+
+// Take on the command settings of a project config once it's trusted
+func (m *model) applyProjectCommands(cfg config) tea.Cmd {
+	var cmd tea.Cmd
+	if cfg.Pre != "" {
+		m.pre = cfg.Pre
+	}
+	if len(cfg.Repos) > 0 {
+		if repos, err := findRepos(cfg.Repos); err == nil {
+			m.repos = repos
+		} else {
+			m.statusMessage = fmt.Sprintf("Error finding the project's repos: %s", err)
+			m.statusMessageType = "error"
+		}
+	}
+	if len(cfg.Index) > 0 {
+		// Indexing only goes on while there's something to index
+		idle := len(m.indexes) == 0
+		m.indexes = loadIndexes(cfg.Index)
+		if idle {
+			cmd = m.watchIndex()
+		}
+	}
+	m.usePreviewer(cfg.Previewer)
+	if cfg.Editor != "" {
+		m.editor.template = cfg.Editor
+	}
+	if cfg.NvimServer != "" {
+		m.editor.nvimServer = cfg.NvimServer
+	}
+	if cfg.Tmux != "" {
+		m.editor.tmux = cfg.Tmux
+	}
+	return cmd
+}
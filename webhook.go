@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var webhookClient = &http.Client{Timeout: 30 * time.Second}
+
+// Where alt+w sends the results
+type webhookConfig struct {
+	// The http or https URL the results are POSTed to, as the JSON export
+	URL string `json:"url"`
+
+	// Headers to send with them, like {"Authorization": "Bearer $TOKEN"}.
+	// $NAME and ${NAME} in the values are filled in from the environment,
+	// so secrets needn't be kept in the config.
+	Headers map[string]string `json:"headers"`
+}
+
+func (w webhookConfig) validate() error {
+	if w.URL == "" {
+		return nil
+	}
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook.url must be an http or https URL, not %q", w.URL)
+	}
+	return nil
+}
+
+type webhookSentMsg struct {
+	count  int
+	host   string
+	status string
+	err    error
+}
+
+// Send the results (the marked ones, or all that get through the filters)
+// to the webhook, once it's confirmed: they're leaving the machine
+func (m *model) sendToWebhook() {
+	if m.webhook.URL == "" {
+		m.statusMessage = "No webhook set: add webhook.url to the config"
+		m.statusMessageType = "error"
+		return
+	}
+	items := m.targetResults()
+	if len(items) == 0 {
+		m.statusMessage = "No results to send"
+		m.statusMessageType = "error"
+		return
+	}
+	var body bytes.Buffer
+	if err := writeJSON(&body, exportData{
+		pattern:      m.currentSearchPattern,
+		root:         m.paths.root,
+		linkTemplate: m.linkTemplate,
+		items:        items,
+	}); err != nil {
+		m.statusMessage = fmt.Sprintf("Error sending results: %s", err)
+		m.statusMessageType = "error"
+		return
+	}
+	webhook := m.webhook
+	host := webhookHost(webhook.URL)
+	m.ask(fmt.Sprintf("Send %d results (%s) to %s?", len(items), formatBytes(int64(body.Len())), host), func(m *model) tea.Cmd {
+		m.statusMessage = fmt.Sprintf("Sending %d results to %s…", len(items), host)
+		m.statusMessageType = "info"
+		return postResults(webhook, body.Bytes(), len(items))
+	})
+}
+
+func webhookHost(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return rawURL
+}
+
+// POST the results in the background. Anything but a 2xx is an error, with
+// the start of what the endpoint said about it.
+func postResults(webhook webhookConfig, body []byte, count int) tea.Cmd {
+	return func() tea.Msg {
+		msg := webhookSentMsg{count: count, host: webhookHost(webhook.URL)}
+		req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "lazyrg")
+		for name, value := range webhook.Headers {
+			req.Header.Set(name, os.ExpandEnv(value))
+		}
+		resp, err := webhookClient.Do(req)
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		defer resp.Body.Close()
+		msg.status = resp.Status
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			said, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+			msg.err = fmt.Errorf("%s", resp.Status)
+			if text := strings.Join(strings.Fields(string(said)), " "); text != "" {
+				msg.err = fmt.Errorf("%s: %s", resp.Status, text)
+			}
+		}
+		return msg
+	}
+}
+
+func (m *model) handleWebhookSent(msg webhookSentMsg) {
+	if msg.err != nil {
+		m.statusMessage = fmt.Sprintf("Error sending results to %s: %s", msg.host, msg.err)
+		m.statusMessageType = "error"
+		return
+	}
+	m.statusMessage = fmt.Sprintf("Sent %d results to %s (%s)", msg.count, msg.host, msg.status)
+	m.statusMessageType = "info"
+}
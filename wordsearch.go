@@ -0,0 +1,48 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Start a new search for the word at the match, as a whole word, where the
+// last search looked. The results it replaces are kept as a session.
+func (m *model) searchWord() tea.Cmd {
+	if m.fileState.doc == nil || m.fileState.item.remote != nil {
+		return nil
+	}
+	word := m.identifierAtHit()
+	if word == "" {
+		m.statusMessage = "No word at the match to search for"
+		m.statusMessageType = "error"
+		return nil
+	}
+	return m.searchFor(`\b` + regexp.QuoteMeta(word) + `\b`)
+}
+
+// Start a new search for the selected line, as it's written
+func (m *model) searchSelection() tea.Cmd {
+	from, to := m.selection()
+	if from != to {
+		m.statusMessage = "Select a single line to search for it"
+		m.statusMessageType = "error"
+		return nil
+	}
+	n := from - m.fileState.lineOffset + 1
+	text, ok := readLine(m.fileState.item.fullPath, n)
+	if text = strings.TrimSpace(text); !ok || text == "" {
+		m.statusMessage = "Nothing on the line to search for"
+		m.statusMessageType = "error"
+		return nil
+	}
+	m.fileState.selecting = false
+	return m.searchFor(regexp.QuoteMeta(text))
+}
+
+func (m *model) searchFor(pattern string) tea.Cmd {
+	m.searchInput.SetValue(pattern)
+	m.searchInput.CursorEnd()
+	return m.startSearch()
+}